@@ -8,6 +8,22 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := app.Import(os.Args[2:]); err != nil {
+			slog.Error("app import", slog.Any("error", err))
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "api-key" {
+		if err := app.IssueAPIKey(os.Args[2:]); err != nil {
+			slog.Error("app api-key", slog.Any("error", err))
+			os.Exit(1)
+		}
+		return
+	}
+
 	if err := app.Run(); err != nil {
 		slog.Error("app run", slog.Any("error", err))
 		os.Exit(1)
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/pkg/errors"
@@ -15,12 +16,142 @@ type ConfigDatabase struct {
 	PostgresPassword string `yaml:"password" env:"POSTGRES_PASSWORD"`
 }
 
+type ConfigPagination struct {
+	DefaultPageSize int `yaml:"default_page_size" env:"PAGE_SIZE_DEFAULT" env-default:"20"`
+	MaxPageSize     int `yaml:"max_page_size" env:"PAGE_SIZE_MAX" env-default:"100"`
+}
+
+type ConfigSoftDelete struct {
+	Retention     time.Duration `yaml:"retention" env:"SOFT_DELETE_RETENTION" env-default:"720h"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env:"PURGE_INTERVAL" env-default:"1h"`
+}
+
+// ConfigBatch limits batch creates and import chunks. A running import
+// records progress after every chunk; one that has recorded none for
+// ImportStaleAfter, say because its instance died, is marked failed.
+type ConfigBatch struct {
+	MaxSize          int           `yaml:"max_size" env:"BATCH_MAX_SIZE" env-default:"1000"`
+	ImportChunkSize  int           `yaml:"import_chunk_size" env:"IMPORT_CHUNK_SIZE" env-default:"500"`
+	ImportStaleAfter time.Duration `yaml:"import_stale_after" env:"IMPORT_STALE_AFTER" env-default:"15m"`
+}
+
+// ConfigOutbox picks where user events are published: log, file or http.
+// A relay leases BatchSize due events at a time for Lease; events it has
+// not published by then are claimed again.
+type ConfigOutbox struct {
+	Publisher    string        `yaml:"publisher" env:"OUTBOX_PUBLISHER" env-default:"log"`
+	FilePath     string        `yaml:"file_path" env:"OUTBOX_FILE" env-default:"outbox.ndjson"`
+	HTTPURL      string        `yaml:"http_url" env:"OUTBOX_HTTP_URL"`
+	HTTPTimeout  time.Duration `yaml:"http_timeout" env:"OUTBOX_HTTP_TIMEOUT" env-default:"5s"`
+	PollInterval time.Duration `yaml:"poll_interval" env:"OUTBOX_POLL_INTERVAL" env-default:"1s"`
+	BatchSize    int           `yaml:"batch_size" env:"OUTBOX_BATCH_SIZE" env-default:"100"`
+	Lease        time.Duration `yaml:"lease" env:"OUTBOX_LEASE" env-default:"1m"`
+	RetryBase    time.Duration `yaml:"retry_base" env:"OUTBOX_RETRY_BASE" env-default:"1s"`
+	RetryMax     time.Duration `yaml:"retry_max" env:"OUTBOX_RETRY_MAX" env-default:"5m"`
+}
+
+// ConfigWebhook sets how deliveries are sent and retried. A dispatcher
+// leases BatchSize due deliveries at a time for Lease, which should exceed
+// Timeout; deliveries it has not recorded by then are claimed again.
+type ConfigWebhook struct {
+	Timeout      time.Duration `yaml:"timeout" env:"WEBHOOK_TIMEOUT" env-default:"10s"`
+	PollInterval time.Duration `yaml:"poll_interval" env:"WEBHOOK_POLL_INTERVAL" env-default:"1s"`
+	BatchSize    int           `yaml:"batch_size" env:"WEBHOOK_BATCH_SIZE" env-default:"50"`
+	Lease        time.Duration `yaml:"lease" env:"WEBHOOK_LEASE" env-default:"1m"`
+	MaxAttempts  int           `yaml:"max_attempts" env:"WEBHOOK_MAX_ATTEMPTS" env-default:"8"`
+	RetryBase    time.Duration `yaml:"retry_base" env:"WEBHOOK_RETRY_BASE" env-default:"5s"`
+	RetryMax     time.Duration `yaml:"retry_max" env:"WEBHOOK_RETRY_MAX" env-default:"1h"`
+}
+
+type ConfigOpenAPI struct {
+	ValidateRequests bool `yaml:"validate_requests" env:"OPENAPI_VALIDATE" env-default:"false"`
+}
+
+// ConfigAuth holds the keys bearer tokens are verified with. Any mix of an
+// HS256 secret, a PEM public key and a JWKS file may be given. API keys are
+// accepted next to bearer tokens unless APIKeysEnabled is off. PolicyFile
+// maps roles to permissions; the built-in policy is used without it.
+type ConfigAuth struct {
+	JWTEnabled       bool          `yaml:"jwt_enabled" env:"AUTH_JWT_ENABLED" env-default:"true"`
+	JWTSecret        string        `yaml:"jwt_secret" env:"AUTH_JWT_SECRET"`
+	JWTPublicKeyFile string        `yaml:"jwt_public_key_file" env:"AUTH_JWT_PUBLIC_KEY_FILE"`
+	JWKSFile         string        `yaml:"jwks_file" env:"AUTH_JWKS_FILE"`
+	JWTIssuer        string        `yaml:"jwt_issuer" env:"AUTH_JWT_ISSUER"`
+	JWTAudience      string        `yaml:"jwt_audience" env:"AUTH_JWT_AUDIENCE"`
+	JWTLeeway        time.Duration `yaml:"jwt_leeway" env:"AUTH_JWT_LEEWAY" env-default:"30s"`
+	APIKeysEnabled   bool          `yaml:"api_keys_enabled" env:"AUTH_API_KEYS_ENABLED" env-default:"true"`
+	PolicyFile       string        `yaml:"policy_file" env:"AUTH_POLICY_FILE"`
+}
+
+// ConfigRateLimit sets the token buckets of HTTP clients. Limits read
+// <requests>/<period>[:<burst>], e.g. 20/1s:40, and a long period such as
+// 10000/24h acts as a quota. Routes holds "<METHOD> <path>=<limit>" rules
+// separated by semicolons; other routes get Default, if set. IP limits
+// every request by client address before credentials are checked, if set.
+// Store is memory or postgres.
+type ConfigRateLimit struct {
+	RateLimitEnabled bool          `yaml:"enabled" env:"RATE_LIMIT_ENABLED" env-default:"true"`
+	Store            string        `yaml:"store" env:"RATE_LIMIT_STORE" env-default:"memory"`
+	IP               string        `yaml:"ip" env:"RATE_LIMIT_IP" env-default:"200/1s:400"`
+	Default          string        `yaml:"default" env:"RATE_LIMIT_DEFAULT" env-default:"100/1s:200"`
+	Routes           string        `yaml:"routes" env:"RATE_LIMIT_ROUTES" env-default:"GET /user/:id=20/1s:40"`
+	CleanupInterval  time.Duration `yaml:"cleanup_interval" env:"RATE_LIMIT_CLEANUP_INTERVAL" env-default:"1m"`
+}
+
+// ConfigIdempotency sets how long responses to requests sent with an
+// Idempotency-Key are kept for replay, and after how long a request still
+// marked in flight is presumed lost and its key may be claimed again.
+type ConfigIdempotency struct {
+	TTL             time.Duration `yaml:"ttl" env:"IDEMPOTENCY_TTL" env-default:"24h"`
+	LockTimeout     time.Duration `yaml:"lock_timeout" env:"IDEMPOTENCY_LOCK_TIMEOUT" env-default:"1m"`
+	CleanupInterval time.Duration `yaml:"cleanup_interval" env:"IDEMPOTENCY_CLEANUP_INTERVAL" env-default:"1h"`
+}
+
+// ConfigTracing picks where spans are exported: none, stdout, file, which
+// writes one JSON span per line to File, or otlp, which sends them to
+// OTLPEndpoint over grpc or http/protobuf. Without an endpoint the
+// OTEL_EXPORTER_OTLP_* variables apply. Traces started by a caller keep
+// its sampling decision; new ones are sampled at SampleRatio.
+type ConfigTracing struct {
+	Exporter     string  `yaml:"exporter" env:"TRACING_EXPORTER" env-default:"none"`
+	ServiceName  string  `yaml:"service_name" env:"TRACING_SERVICE_NAME" env-default:"user-service"`
+	SampleRatio  float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" env-default:"1"`
+	File         string  `yaml:"file" env:"TRACING_FILE" env-default:"traces.jsonl"`
+	OTLPEndpoint string  `yaml:"otlp_endpoint" env:"TRACING_OTLP_ENDPOINT"`
+	OTLPProtocol string  `yaml:"otlp_protocol" env:"TRACING_OTLP_PROTOCOL" env-default:"grpc"`
+	OTLPInsecure bool    `yaml:"otlp_insecure" env:"TRACING_OTLP_INSECURE" env-default:"false"`
+}
+
+// ConfigHealth sets how long a dependency check may take and how long its
+// result is reused. Readiness fails once the share of pool connections in
+// use reaches PoolSaturation; 0 turns that check off. On shutdown,
+// readiness fails for ShutdownDelay before the server stops taking
+// requests, giving load balancers time to notice.
+type ConfigHealth struct {
+	CheckTimeout   time.Duration `yaml:"check_timeout" env:"HEALTH_CHECK_TIMEOUT" env-default:"2s"`
+	CacheFor       time.Duration `yaml:"cache_for" env:"HEALTH_CACHE_FOR" env-default:"1s"`
+	PoolSaturation float64       `yaml:"pool_saturation" env:"HEALTH_POOL_SATURATION" env-default:"0.9"`
+	ShutdownDelay  time.Duration `yaml:"shutdown_delay" env:"HEALTH_SHUTDOWN_DELAY" env-default:"5s"`
+}
+
 type Config struct {
 	ServicePort string `yaml:"service_port" env:"SERVICE_PORT" env-default:"8080"`
 	MetricsPort string `yaml:"metrics_port" env:"METRICS_PORT" env-default:"8081"`
+	GrpcPort    string `yaml:"grpc_port" env:"GRPC_PORT" env-default:"9090"`
 	LogLevel    string `yaml:"log_level" env:"LOG_LEVEL" env-default:"INFO"`
 	ConnString  string
 	ConfigDatabase
+	ConfigPagination
+	ConfigSoftDelete
+	ConfigBatch
+	ConfigOutbox
+	ConfigWebhook
+	ConfigOpenAPI
+	ConfigAuth
+	ConfigRateLimit
+	ConfigIdempotency
+	ConfigTracing
+	ConfigHealth
 }
 
 func Load() (*Config, error) {
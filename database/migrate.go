@@ -3,8 +3,11 @@ package database
 import (
 	"database/sql"
 	"embed"
-
+	"io/fs"
 	"log/slog"
+	"strconv"
+	"strings"
+
 	// Import for side effects - needed for initializing the PostgresSQL driver
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/pkg/errors"
@@ -50,3 +53,24 @@ func Migrate(url string) error {
 
 	return nil
 }
+
+// LatestVersion is the version of the newest embedded migration, the one a
+// database is at once Migrate has run.
+func LatestVersion() (int64, error) {
+	entries, err := fs.ReadDir(migrations, "migrations")
+	if err != nil {
+		return 0, errors.Wrap(err, "read migrations")
+	}
+
+	var latest int64
+	for _, entry := range entries {
+		prefix, _, _ := strings.Cut(entry.Name(), "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return 0, errors.Wrapf(err, "migration %s has no version", entry.Name())
+		}
+		latest = max(latest, version)
+	}
+
+	return latest, nil
+}
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+UPDATE users SET created_at = CURRENT_TIMESTAMP WHERE created_at IS NULL;
+UPDATE users SET updated_at = created_at WHERE updated_at IS NULL;
+
+ALTER TABLE users
+    ALTER COLUMN created_at SET NOT NULL,
+    ALTER COLUMN updated_at SET NOT NULL;
+
+-- Keyset pagination: every sort order is (column, id)
+CREATE INDEX idx_users_name_id ON users(name, id);
+CREATE INDEX idx_users_age_id ON users(age, id);
+CREATE INDEX idx_users_created_at_id ON users(created_at, id);
+CREATE INDEX idx_users_updated_at_id ON users(updated_at, id);
+
+CREATE INDEX idx_users_gender ON users(gender);
+CREATE INDEX idx_users_email_domain ON users(lower(split_part(email, '@', 2)));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_users_email_domain;
+DROP INDEX IF EXISTS idx_users_gender;
+DROP INDEX IF EXISTS idx_users_updated_at_id;
+DROP INDEX IF EXISTS idx_users_created_at_id;
+DROP INDEX IF EXISTS idx_users_age_id;
+DROP INDEX IF EXISTS idx_users_name_id;
+
+ALTER TABLE users
+    ALTER COLUMN created_at DROP NOT NULL,
+    ALTER COLUMN updated_at DROP NOT NULL;
+-- +goose StatementEnd
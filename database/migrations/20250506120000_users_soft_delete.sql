@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_users_deleted_at ON users(deleted_at) WHERE deleted_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Without the column soft-deleted users would come back to life, and
+-- deleting them here would lose them for good, so the rollback stops until
+-- an operator has purged or restored them.
+DO $$
+DECLARE
+    deleted BIGINT;
+BEGIN
+    SELECT count(*) INTO deleted FROM users WHERE deleted_at IS NOT NULL;
+
+    IF deleted > 0 THEN
+        RAISE EXCEPTION 'soft-deleted users exist'
+            USING DETAIL = format('%s users have deleted_at set', deleted),
+                  HINT = 'purge or restore the soft-deleted users, then rerun the rollback';
+    END IF;
+END
+$$;
+
+DROP INDEX IF EXISTS idx_users_deleted_at;
+
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Live accounts that share an email are not resolved here: which one to
+-- keep is a decision for an operator, so the migration stops and lists them.
+DO $$
+DECLARE
+    duplicates TEXT;
+BEGIN
+    SELECT string_agg(format('%s: %s', email, ids), E'\n' ORDER BY email)
+    INTO duplicates
+    FROM (
+        SELECT lower(email) AS email, string_agg(id::text, ', ' ORDER BY created_at, id) AS ids
+        FROM users
+        WHERE deleted_at IS NULL
+        GROUP BY lower(email)
+        HAVING count(*) > 1
+    ) d;
+
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'live users share an email'
+            USING DETAIL = duplicates,
+                  HINT = 'soft delete or change the email of all but one account per email, then rerun the migration';
+    END IF;
+END
+$$;
+
+CREATE UNIQUE INDEX idx_users_email_unique ON users(lower(email)) WHERE deleted_at IS NULL;
+
+ALTER TABLE users
+    ADD CONSTRAINT users_email_check CHECK (position('@' in email) > 1) NOT VALID,
+    ADD CONSTRAINT users_gender_check CHECK (gender IN ('male', 'female')) NOT VALID,
+    ADD CONSTRAINT users_age_check CHECK (age BETWEEN 0 AND 120) NOT VALID;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users
+    DROP CONSTRAINT IF EXISTS users_age_check,
+    DROP CONSTRAINT IF EXISTS users_gender_check,
+    DROP CONSTRAINT IF EXISTS users_email_check;
+
+DROP INDEX IF EXISTS idx_users_email_unique;
+-- +goose StatementEnd
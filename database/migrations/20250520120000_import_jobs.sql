@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS import_jobs (
+    id UUID PRIMARY KEY,
+    format VARCHAR(10) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    columns JSONB NOT NULL DEFAULT '{}',
+    total_rows BIGINT NOT NULL DEFAULT 0,
+    imported_rows BIGINT NOT NULL DEFAULT 0,
+    rejected_rows BIGINT NOT NULL DEFAULT 0,
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP WITH TIME ZONE,
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Rejected rows of an import, the source of the downloadable error report
+CREATE TABLE IF NOT EXISTS import_job_errors (
+    job_id UUID NOT NULL REFERENCES import_jobs(id) ON DELETE CASCADE,
+    line BIGINT NOT NULL,
+    error TEXT NOT NULL,
+    raw TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (job_id, line)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE import_job_errors;
+DROP TABLE import_jobs;
+-- +goose StatementEnd
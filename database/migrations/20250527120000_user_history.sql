@@ -0,0 +1,69 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS user_history (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    operation VARCHAR(10) NOT NULL,
+    before JSONB,
+    after JSONB NOT NULL,
+    version BIGINT NOT NULL,
+    actor TEXT,
+    request_id TEXT,
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_history_user_id ON user_history(user_id, id);
+CREATE INDEX IF NOT EXISTS idx_user_history_user_changed_at ON user_history(user_id, changed_at);
+
+-- Users older than the history start from a snapshot of their current state
+INSERT INTO user_history(user_id, operation, after, version, changed_at)
+SELECT id, 'snapshot', to_jsonb(users) - 'search_vector', version, updated_at
+FROM users;
+
+-- The actor and request id come from app.actor and app.request_id, which
+-- the repository sets for the transaction of every write
+CREATE OR REPLACE FUNCTION record_user_history() RETURNS trigger AS $$
+DECLARE
+    op TEXT;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        -- A purged user leaves no trace behind
+        DELETE FROM user_history WHERE user_id = OLD.id;
+        RETURN OLD;
+    END IF;
+
+    IF TG_OP = 'INSERT' THEN
+        op := 'create';
+    ELSIF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+        op := 'delete';
+    ELSIF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN
+        op := 'restore';
+    ELSE
+        op := 'update';
+    END IF;
+
+    INSERT INTO user_history(user_id, operation, before, after, version, actor, request_id)
+    VALUES (
+        NEW.id,
+        op,
+        CASE WHEN TG_OP = 'UPDATE' THEN to_jsonb(OLD) - 'search_vector' END,
+        to_jsonb(NEW) - 'search_vector',
+        NEW.version,
+        NULLIF(current_setting('app.actor', true), ''),
+        NULLIF(current_setting('app.request_id', true), '')
+    );
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER users_history
+AFTER INSERT OR UPDATE OR DELETE ON users
+FOR EACH ROW EXECUTE FUNCTION record_user_history();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS users_history ON users;
+DROP FUNCTION IF EXISTS record_user_history();
+DROP TABLE IF EXISTS user_history;
+-- +goose StatementEnd
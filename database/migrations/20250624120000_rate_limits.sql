@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Buckets are cheap to lose, so they skip the WAL
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_buckets (
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    burst DOUBLE PRECISION NOT NULL,
+    -- Tokens added per second
+    rate DOUBLE PRECISION NOT NULL,
+    -- Whether the last request got a token
+    allowed BOOLEAN NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS rate_limit_buckets;
+-- +goose StatementEnd
@@ -0,0 +1,79 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A purged user keeps its history so the audit trail of who changed what,
+-- and when, survives the purge. Only the personal data goes: the name and
+-- email are blanked in every entry, and the operations, versions, actors,
+-- request ids and timestamps stay.
+CREATE OR REPLACE FUNCTION record_user_history() RETURNS trigger AS $$
+DECLARE
+    op TEXT;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        UPDATE user_history
+        SET before = before || '{"name": null, "email": null}'::jsonb,
+            after = after || '{"name": null, "email": null}'::jsonb
+        WHERE user_id = OLD.id;
+        RETURN OLD;
+    END IF;
+
+    IF TG_OP = 'INSERT' THEN
+        op := 'create';
+    ELSIF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+        op := 'delete';
+    ELSIF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN
+        op := 'restore';
+    ELSE
+        op := 'update';
+    END IF;
+
+    INSERT INTO user_history(user_id, operation, before, after, version, actor, request_id)
+    VALUES (
+        NEW.id,
+        op,
+        CASE WHEN TG_OP = 'UPDATE' THEN to_jsonb(OLD) - 'search_vector' END,
+        to_jsonb(NEW) - 'search_vector',
+        NEW.version,
+        NULLIF(current_setting('app.actor', true), ''),
+        NULLIF(current_setting('app.request_id', true), '')
+    );
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION record_user_history() RETURNS trigger AS $$
+DECLARE
+    op TEXT;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        -- A purged user leaves no trace behind
+        DELETE FROM user_history WHERE user_id = OLD.id;
+        RETURN OLD;
+    END IF;
+
+    IF TG_OP = 'INSERT' THEN
+        op := 'create';
+    ELSIF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+        op := 'delete';
+    ELSIF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN
+        op := 'restore';
+    ELSE
+        op := 'update';
+    END IF;
+
+    INSERT INTO user_history(user_id, operation, before, after, version, actor, request_id)
+    VALUES (
+        NEW.id,
+        op,
+        CASE WHEN TG_OP = 'UPDATE' THEN to_jsonb(OLD) - 'search_vector' END,
+        to_jsonb(NEW) - 'search_vector',
+        NEW.version,
+        NULLIF(current_setting('app.actor', true), ''),
+        NULLIF(current_setting('app.request_id', true), '')
+    );
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
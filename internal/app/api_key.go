@@ -0,0 +1,73 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/krackl1n/golang-project/config"
+	"github.com/krackl1n/golang-project/database"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/repository"
+	"github.com/krackl1n/golang-project/internal/requestctx"
+	"github.com/krackl1n/golang-project/internal/storage"
+	"github.com/krackl1n/golang-project/internal/usecase"
+	"github.com/krackl1n/golang-project/internal/validation"
+	"github.com/pkg/errors"
+)
+
+// IssueAPIKey runs the api-key subcommand. It issues a key straight from
+// the database, which is how the first key holding api_keys:manage is made,
+// and prints it together with the only copy of the secret.
+func IssueAPIKey(args []string) error {
+	flags := flag.NewFlagSet("api-key", flag.ContinueOnError)
+	name := flags.String("name", "", "what the key is used for")
+	scopes := flags.String("scopes", "", "comma separated scopes, e.g. users:read,users:write")
+	ttl := flags.Duration("ttl", 0, "lifetime of the key, 0 for a key that does not expire")
+	if err := flags.Parse(args); err != nil {
+		return errors.Wrap(err, "parse flags")
+	}
+
+	createAPIKeyDTO := models.CreateAPIKeyDTO{Name: *name}
+	for _, scope := range strings.Split(*scopes, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			createAPIKeyDTO.Scopes = append(createAPIKeyDTO.Scopes, scope)
+		}
+	}
+	if *ttl > 0 {
+		expiresAt := time.Now().Add(*ttl)
+		createAPIKeyDTO.ExpiresAt = &expiresAt
+	}
+	if err := validation.Struct(createAPIKeyDTO); err != nil {
+		return errors.New(validation.Describe(err))
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return errors.Wrap(err, "load config")
+	}
+	loggerInit(cfg)
+
+	if err := database.Migrate(cfg.ConnString); err != nil {
+		return errors.Wrap(err, "migrations")
+	}
+
+	connDB, err := storage.GetConnect(cfg.ConnString)
+	if err != nil {
+		return errors.Wrap(err, "connect to database")
+	}
+	defer connDB.Close()
+
+	ctx := requestctx.WithActor(context.Background(), "cli")
+	key, err := usecase.NewAPIKey(repository.NewAPIKeyRepository(connDB)).IssueAPIKey(ctx, &createAPIKeyDTO)
+	if err != nil {
+		return errors.Wrap(err, "issue api key")
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return errors.Wrap(encoder.Encode(key), "print api key")
+}
@@ -1,19 +1,31 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"net"
+	"net/http"
 	"os"
 	"time"
 
 	"github.com/krackl1n/golang-project/config"
 	"github.com/krackl1n/golang-project/database"
+	"github.com/krackl1n/golang-project/internal/auth"
 	"github.com/krackl1n/golang-project/internal/cache"
+	"github.com/krackl1n/golang-project/internal/grpcserver"
 	"github.com/krackl1n/golang-project/internal/handler"
+	"github.com/krackl1n/golang-project/internal/logging"
 	"github.com/krackl1n/golang-project/internal/metrics"
+	"github.com/krackl1n/golang-project/internal/openapi"
+	"github.com/krackl1n/golang-project/internal/outbox"
+	"github.com/krackl1n/golang-project/internal/policy"
+	"github.com/krackl1n/golang-project/internal/ratelimit"
 	"github.com/krackl1n/golang-project/internal/repository"
 	"github.com/krackl1n/golang-project/internal/storage"
+	"github.com/krackl1n/golang-project/internal/tracing"
 	"github.com/krackl1n/golang-project/internal/usecase"
+	"github.com/krackl1n/golang-project/internal/webhook"
 	"github.com/pkg/errors"
 )
 
@@ -27,6 +39,19 @@ func Run() error {
 	loggerInit(cfg)
 	slog.Debug("logger initialized")
 
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.ConfigTracing)
+	if err != nil {
+		return errors.Wrap(err, "tracing")
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("shut down tracing", slog.Any("error", err))
+		}
+	}()
+	slog.Debug("tracing initialized")
+
 	if err := database.Migrate(cfg.ConnString); err != nil {
 		return errors.Wrap(err, "migrations")
 	}
@@ -42,13 +67,110 @@ func Run() error {
 	metrics.MetricsInit(cfg)
 	slog.Debug("metrics initialized")
 
+	healthRegistry, err := newHealthRegistry(connDB, cfg.ConfigHealth)
+	if err != nil {
+		return errors.Wrap(err, "health checks")
+	}
+
+	accessPolicy, err := policy.Load(cfg.PolicyFile)
+	if err != nil {
+		return errors.Wrap(err, "load access policy")
+	}
+
 	userRepository := repository.NewUserRepository(connDB)
 	userCache := cache.New(userRepository, 5*time.Minute)
 	defer userCache.Stop()
-	uc := usecase.New(userCache)
-	handle := handler.New(uc)
+	uc := usecase.WithTracing(usecase.New(userCache, cfg, accessPolicy))
+	importUC := usecase.NewImport(userRepository, repository.NewImportJobRepository(connDB), cfg, accessPolicy)
+	webhookRepository := repository.NewWebhookRepository(connDB)
+	webhookUC := usecase.NewWebhook(webhookRepository, cfg)
+	apiKeyUC := usecase.NewAPIKey(repository.NewAPIKeyRepository(connDB))
+	idempotencyUC := usecase.NewIdempotency(repository.NewIdempotencyRepository(connDB), cfg)
+	handle := handler.New(uc, importUC, webhookUC, apiKeyUC)
+
+	stopPurge := startPurgeWorker(uc, cfg.PurgeInterval)
+	defer stopPurge()
+	stopIdempotency := startIdempotencyWorker(idempotencyUC, cfg.ConfigIdempotency.CleanupInterval)
+	defer stopIdempotency()
+	stopImportSweeper := startImportSweeper(importUC, cfg.ImportStaleAfter)
+	defer stopImportSweeper()
+
+	publisher, closePublisher, err := outbox.NewPublisher(cfg.ConfigOutbox)
+	if err != nil {
+		return errors.Wrap(err, "outbox publisher")
+	}
+	defer closePublisher()
+	publishers := outbox.MultiPublisher{publisher, webhook.NewFanoutPublisher(webhookRepository)}
+	stopRelay := outbox.NewRelay(repository.NewOutboxRepository(connDB), publishers, cfg.ConfigOutbox).Start()
+	defer stopRelay()
 
-	app := getRouter(handle)
+	sender := webhook.NewSender(&http.Client{Timeout: cfg.ConfigWebhook.Timeout})
+	stopDispatcher := webhook.NewDispatcher(webhookRepository, sender, cfg.ConfigWebhook).Start()
+	defer stopDispatcher()
+
+	var verifier *auth.JWTVerifier
+	if cfg.JWTEnabled {
+		verifier, err = auth.NewJWTVerifier(cfg.ConfigAuth)
+		if err != nil {
+			return errors.Wrap(err, "jwt verifier")
+		}
+	}
+	var apiKeys auth.APIKeyVerifier
+	if cfg.APIKeysEnabled {
+		apiKeys = apiKeyUC
+	}
+	authenticator := auth.NewAuthenticator(verifier, apiKeys)
+	if authenticator == nil {
+		slog.Warn("authentication is disabled, every client has full access")
+	}
+
+	grpcServer := grpcserver.New(uc, authenticator)
+	grpcListener, err := net.Listen("tcp", fmt.Sprintf(":%s", cfg.GrpcPort))
+	if err != nil {
+		return errors.Wrap(err, "grpc listen")
+	}
+	go func() {
+		slog.Info(fmt.Sprintf("starting grpc server on port %s", cfg.GrpcPort))
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			slog.Error("grpc serve", slog.Any("error", err))
+		}
+	}()
+	defer grpcServer.GracefulStop()
+
+	var validator *openapi.Validator
+	if cfg.ValidateRequests {
+		validator, err = openapi.NewValidator()
+		if err != nil {
+			return errors.Wrap(err, "openapi validator")
+		}
+	}
+
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimitEnabled {
+		var store ratelimit.Store
+		switch cfg.ConfigRateLimit.Store {
+		case "memory":
+			store = ratelimit.NewMemoryStore()
+		case "postgres":
+			store = repository.NewRateLimitRepository(connDB)
+		default:
+			return errors.Errorf("unknown rate limit store %q", cfg.ConfigRateLimit.Store)
+		}
+		limiter, err = ratelimit.NewLimiter(cfg.ConfigRateLimit, store)
+		if err != nil {
+			return errors.Wrap(err, "rate limiter")
+		}
+		stopLimiter := limiter.Start()
+		defer stopLimiter()
+	}
+
+	app := getRouter(handle, healthRegistry, idempotencyUC, authenticator, limiter, validator)
+	// The document is the contract for clients, so a route that is missing
+	// from it, or an operation left after its route was removed, stops the start.
+	if err := openapi.CheckRoutes(app.GetRoutes(true)); err != nil {
+		return errors.Wrap(err, "openapi spec out of date")
+	}
+	drainOnSignal(app, healthRegistry, cfg.ShutdownDelay)
 	slog.Info(fmt.Sprintf("starting main server on port %s", cfg.ServicePort))
 	if err := app.Listen(fmt.Sprintf(":%s", cfg.ServicePort)); err != nil {
 		return errors.Wrap(err, "app listening error")
@@ -64,8 +186,8 @@ func loggerInit(cfg *config.Config) {
 		logLevel = slog.LevelInfo
 	}
 
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+	logger := slog.New(logging.NewContextHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
 		Level: logLevel,
-	}))
+	})))
 	slog.SetDefault(logger)
 }
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/krackl1n/golang-project/config"
+	"github.com/krackl1n/golang-project/database"
+	"github.com/krackl1n/golang-project/internal/health"
+	"github.com/pkg/errors"
+)
+
+// shutdownTimeout bounds how long requests in flight may take to finish
+// once the server stops taking new ones.
+const shutdownTimeout = 30 * time.Second
+
+// newHealthRegistry registers the dependency checks behind the probes.
+// Liveness has none: a replica that lost its database needs the database
+// back, not a restart.
+func newHealthRegistry(connDB *pgxpool.Pool, cfg config.ConfigHealth) (*health.Registry, error) {
+	latest, err := database.LatestVersion()
+	if err != nil {
+		return nil, errors.Wrap(err, "latest migration version")
+	}
+
+	registry := health.NewRegistry()
+	registry.Register(health.Check{
+		Name:     "database",
+		Probes:   health.Readiness | health.Startup,
+		Timeout:  cfg.CheckTimeout,
+		CacheFor: cfg.CacheFor,
+		Run:      health.DatabasePing(connDB),
+	})
+	// Schemas are not rolled back under a running service, so the version
+	// is checked less often.
+	registry.Register(health.Check{
+		Name:     "migrations",
+		Probes:   health.Readiness | health.Startup,
+		Timeout:  cfg.CheckTimeout,
+		CacheFor: 30 * time.Second,
+		Run:      health.MigrationVersion(connDB, latest),
+	})
+	if cfg.PoolSaturation > 0 {
+		registry.Register(health.Check{
+			Name:     "pool",
+			Probes:   health.Readiness,
+			Timeout:  cfg.CheckTimeout,
+			CacheFor: cfg.CacheFor,
+			Run:      health.PoolSaturation(connDB, cfg.PoolSaturation),
+		})
+	}
+
+	return registry, nil
+}
+
+// drainOnSignal makes readiness fail on SIGINT or SIGTERM, waits delay for
+// load balancers to stop sending requests and then shuts the server down,
+// which returns from Listen.
+func drainOnSignal(app *fiber.App, registry *health.Registry, delay time.Duration) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		received := <-signals
+		slog.Info("draining before shutdown", slog.String("signal", received.String()), slog.Duration("delay", delay))
+		registry.Drain()
+		time.Sleep(delay)
+
+		if err := app.ShutdownWithTimeout(shutdownTimeout); err != nil {
+			slog.Error("shut down server", slog.Any("error", err))
+		}
+	}()
+}
@@ -0,0 +1,38 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/krackl1n/golang-project/internal/usecase"
+)
+
+// startIdempotencyWorker periodically removes idempotency keys past their
+// replay window. The returned function stops the worker.
+func startIdempotencyWorker(uc usecase.IdempotencyProvider, interval time.Duration) func() {
+	stopChan := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				purged, err := uc.PurgeExpiredKeys(context.Background())
+				if err != nil {
+					slog.Error("purge idempotency keys", slog.Any("error", err))
+					continue
+				}
+				slog.Debug("purged idempotency keys", slog.Int64("count", purged))
+			case <-stopChan:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(stopChan)
+	}
+}
@@ -0,0 +1,131 @@
+package app
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/krackl1n/golang-project/config"
+	"github.com/krackl1n/golang-project/database"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/policy"
+	"github.com/krackl1n/golang-project/internal/repository"
+	"github.com/krackl1n/golang-project/internal/storage"
+	"github.com/krackl1n/golang-project/internal/usecase"
+	"github.com/krackl1n/golang-project/internal/validation"
+	"github.com/pkg/errors"
+)
+
+// Import runs the import subcommand: it streams a local file through the
+// same import job as the HTTP endpoint and prints the finished job.
+func Import(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := flags.String("format", string(models.ImportCSV), "file format: csv or ndjson")
+	file := flags.String("file", "-", "file to import, - for stdin")
+	columns := flags.String("columns", "", "column mapping as source=field pairs, e.g. full_name=name,mail=email")
+	errorsPath := flags.String("errors", "", "write rejected rows as CSV to this file")
+	if err := flags.Parse(args); err != nil {
+		return errors.Wrap(err, "parse flags")
+	}
+
+	createImportJobDTO := models.CreateImportJobDTO{Format: models.ImportFormat(*format)}
+	if *columns != "" {
+		createImportJobDTO.Columns = map[string]string{}
+		for _, pair := range strings.Split(*columns, ",") {
+			from, to, ok := strings.Cut(pair, "=")
+			if !ok {
+				return errors.Errorf("invalid column mapping %q", pair)
+			}
+			createImportJobDTO.Columns[strings.TrimSpace(from)] = strings.TrimSpace(to)
+		}
+	}
+	if err := validation.Struct(createImportJobDTO); err != nil {
+		return errors.New(validation.Describe(err))
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return errors.Wrap(err, "load config")
+	}
+	loggerInit(cfg)
+
+	if err := database.Migrate(cfg.ConnString); err != nil {
+		return errors.Wrap(err, "migrations")
+	}
+
+	connDB, err := storage.GetConnect(cfg.ConnString)
+	if err != nil {
+		return errors.Wrap(err, "connect to database")
+	}
+	defer connDB.Close()
+
+	var input io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return errors.Wrap(err, "open import file")
+		}
+		defer f.Close()
+		input = f
+	}
+
+	accessPolicy, err := policy.Load(cfg.PolicyFile)
+	if err != nil {
+		return errors.Wrap(err, "load access policy")
+	}
+	importUC := usecase.NewImport(repository.NewUserRepository(connDB), repository.NewImportJobRepository(connDB), cfg, accessPolicy)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	job, err := importUC.CreateImportJob(ctx, &createImportJobDTO)
+	if err != nil {
+		return errors.Wrap(err, "create import job")
+	}
+	slog.Info("import started", slog.String("job_id", job.ID.String()))
+
+	job, err = importUC.RunImport(ctx, job.ID, input)
+	if err != nil {
+		return errors.Wrap(err, "run import")
+	}
+
+	if *errorsPath != "" && job.RejectedRows > 0 {
+		if err := writeImportErrors(ctx, importUC, job, *errorsPath); err != nil {
+			return err
+		}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return errors.Wrap(encoder.Encode(job), "print import job")
+}
+
+func writeImportErrors(ctx context.Context, importUC usecase.ImportProvider, job *models.ImportJob, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, "create error report")
+	}
+	defer f.Close()
+
+	report := csv.NewWriter(f)
+	if err := report.Write([]string{"line", "error", "raw"}); err != nil {
+		return errors.Wrap(err, "write error report")
+	}
+	err = importUC.WriteImportErrors(ctx, job.ID, func(rowError *models.ImportRowError) error {
+		return report.Write([]string{strconv.FormatInt(rowError.Line, 10), rowError.Error, rowError.Raw})
+	})
+	if err != nil {
+		return errors.Wrap(err, "write error report")
+	}
+	report.Flush()
+
+	return errors.Wrap(report.Error(), "flush error report")
+}
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/krackl1n/golang-project/internal/usecase"
+)
+
+// startImportSweeper marks import jobs left running by a dead instance as
+// failed, once at startup and then every staleAfter. The returned function
+// stops the sweeper.
+func startImportSweeper(uc usecase.ImportProvider, staleAfter time.Duration) func() {
+	stopChan := make(chan struct{})
+
+	sweep := func() {
+		failed, err := uc.FailStaleImportJobs(context.Background())
+		if err != nil {
+			slog.Error("fail stale import jobs", slog.Any("error", err))
+			return
+		}
+		if failed > 0 {
+			slog.Warn("failed stale import jobs", slog.Int64("count", failed))
+		}
+	}
+
+	go func() {
+		sweep()
+
+		ticker := time.NewTicker(staleAfter)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				sweep()
+			case <-stopChan:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(stopChan)
+	}
+}
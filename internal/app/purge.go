@@ -0,0 +1,38 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/krackl1n/golang-project/internal/usecase"
+)
+
+// startPurgeWorker periodically removes users whose soft deletion is older
+// than the configured retention. The returned function stops the worker.
+func startPurgeWorker(uc usecase.UserProvider, interval time.Duration) func() {
+	stopChan := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				purged, err := uc.PurgeDeletedUsers(context.Background())
+				if err != nil {
+					slog.Error("purge deleted users", slog.Any("error", err))
+					continue
+				}
+				slog.Info("purged deleted users", slog.Int64("count", purged))
+			case <-stopChan:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(stopChan)
+	}
+}
@@ -1,21 +1,116 @@
 package app
 
 import (
+	"regexp"
+
 	"github.com/gofiber/fiber/v3"
+	"github.com/krackl1n/golang-project/internal/auth"
 	"github.com/krackl1n/golang-project/internal/handler"
+	"github.com/krackl1n/golang-project/internal/health"
 	"github.com/krackl1n/golang-project/internal/middleware"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/openapi"
+	"github.com/krackl1n/golang-project/internal/problem"
+	"github.com/krackl1n/golang-project/internal/ratelimit"
+	"github.com/krackl1n/golang-project/internal/usecase"
 )
 
-func getRouter(handler handler.Handler) *fiber.App {
-	app := fiber.New()
+// bodyLimit caps request bodies other than import uploads.
+const bodyLimit = fiber.DefaultBodyLimit
+
+// importUploadPath matches the only route reading its body as a stream.
+var importUploadPath = regexp.MustCompile(`^/user/import/[^/]+/data/?$`)
+
+func isImportUpload(c fiber.Ctx) bool {
+	return c.Method() == fiber.MethodPut && importUploadPath.MatchString(c.Path())
+}
+
+// getRouter builds the HTTP API. The health probes are served ahead of all
+// middleware. Routes registered after the documentation require credentials
+// granting the scope of the route when an authenticator is given, are rate
+// limited per address and per client when a limiter is given, and are
+// checked against the OpenAPI document when a validator is given. Writes
+// that create something new each time they run accept an Idempotency-Key;
+// writes whose responses carry secrets do not, so the secrets are never
+// stored for replay.
+func getRouter(
+	handler handler.Handler,
+	healthRegistry *health.Registry,
+	idempotencyUC usecase.IdempotencyProvider,
+	authenticator *auth.Authenticator,
+	limiter *ratelimit.Limiter,
+	validator *openapi.Validator,
+) *fiber.App {
+	app := fiber.New(fiber.Config{
+		ErrorHandler: problem.ErrorHandler,
+		// Import uploads are read while they arrive instead of being buffered;
+		// the BodyLimit middleware caps the body of every other route.
+		StreamRequestBody: true,
+		BodyLimit:         bodyLimit,
+	})
+
+	// Probes come before any middleware, so they are neither traced, counted
+	// nor authenticated.
+	app.Get("/livez", healthRegistry.Handler(health.Liveness))
+	app.Get("/readyz", healthRegistry.Handler(health.Readiness))
+	app.Get("/startupz", healthRegistry.Handler(health.Startup))
 
+	app.Use(middleware.Tracing)
 	app.Use(middleware.MetricsMiddleware)
+	app.Use(middleware.RequestContext)
+	app.Use(middleware.BodyLimit(bodyLimit, isImportUpload))
+
+	app.Get("/openapi.json", openapi.SpecHandler)
+	app.Get("/docs", openapi.DocsHandler)
+
+	// Requests count against their address before authentication, so
+	// rejected credentials are throttled too, and against their principal
+	// after it.
+	if limiter != nil {
+		app.Use(middleware.RateLimitIP(limiter))
+	}
+	if authenticator != nil {
+		app.Use(middleware.Authenticate(authenticator))
+	}
+	if limiter != nil {
+		app.Use(middleware.RateLimit(limiter))
+	}
+	if validator != nil {
+		app.Use(validator.Middleware)
+	}
+
+	idempotent := middleware.Idempotency(idempotencyUC)
 
 	userRouter := app.Group("/user")
-	userRouter.Post("/", handler.CreateUser)
-	userRouter.Get("/:id", handler.GetUser)
-	userRouter.Delete("/:id", handler.DeleteUser)
-	userRouter.Put("/", handler.UpdateUser)
+	userRouter.Post("/", handler.CreateUser, middleware.RequireScope(models.ScopeUsersWrite), idempotent)
+	userRouter.Post("/batch", handler.CreateUsers, middleware.RequireScope(models.ScopeUsersWrite), idempotent)
+	userRouter.Get("/", handler.ListUsers, middleware.RequireScope(models.ScopeUsersRead))
+	userRouter.Get("/search", handler.SearchUsers, middleware.RequireScope(models.ScopeUsersRead))
+	userRouter.Get("/export", handler.ExportUsers, middleware.RequireScope(models.ScopeUsersRead))
+	userRouter.Post("/purge", handler.PurgeUsers, middleware.RequireScope(models.ScopeUsersDelete))
+	userRouter.Post("/import", handler.CreateImportJob, middleware.RequireScope(models.ScopeUsersWrite), idempotent)
+	userRouter.Get("/import/:id", handler.GetImportJob, middleware.RequireScope(models.ScopeUsersWrite))
+	userRouter.Put("/import/:id/data", handler.UploadImportData, middleware.RequireScope(models.ScopeUsersWrite))
+	userRouter.Get("/import/:id/errors", handler.ImportErrors, middleware.RequireScope(models.ScopeUsersWrite))
+	userRouter.Get("/:id", handler.GetUser, middleware.RequireScope(models.ScopeUsersRead))
+	userRouter.Get("/:id/history", handler.UserHistory, middleware.RequireScope(models.ScopeUsersRead))
+	userRouter.Delete("/:id", handler.DeleteUser, middleware.RequireScope(models.ScopeUsersDelete))
+	userRouter.Post("/:id/restore", handler.RestoreUser, middleware.RequireScope(models.ScopeUsersDelete))
+	userRouter.Put("/", handler.UpdateUser, middleware.RequireScope(models.ScopeUsersWrite))
+	userRouter.Patch("/:id", handler.PatchUser, middleware.RequireScope(models.ScopeUsersWrite), idempotent)
+
+	webhookRouter := app.Group("/webhooks")
+	webhookRouter.Post("/", handler.CreateWebhook, middleware.RequireScope(models.ScopeWebhooksManage))
+	webhookRouter.Get("/", handler.ListWebhooks, middleware.RequireScope(models.ScopeWebhooksManage))
+	webhookRouter.Get("/deliveries/:id", handler.GetWebhookDelivery, middleware.RequireScope(models.ScopeWebhooksManage))
+	webhookRouter.Post("/deliveries/:id/replay", handler.ReplayWebhookDelivery, middleware.RequireScope(models.ScopeWebhooksManage), idempotent)
+	webhookRouter.Delete("/:id", handler.DeleteWebhook, middleware.RequireScope(models.ScopeWebhooksManage))
+	webhookRouter.Get("/:id/deliveries", handler.ListWebhookDeliveries, middleware.RequireScope(models.ScopeWebhooksManage))
+
+	apiKeyRouter := app.Group("/api-keys")
+	apiKeyRouter.Post("/", handler.IssueAPIKey, middleware.RequireScope(models.ScopeAPIKeysManage))
+	apiKeyRouter.Get("/", handler.ListAPIKeys, middleware.RequireScope(models.ScopeAPIKeysManage))
+	apiKeyRouter.Delete("/:id", handler.RevokeAPIKey, middleware.RequireScope(models.ScopeAPIKeysManage))
 
 	return app
 }
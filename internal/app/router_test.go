@@ -0,0 +1,46 @@
+package app
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/krackl1n/golang-project/config"
+	"github.com/krackl1n/golang-project/internal/auth"
+	"github.com/krackl1n/golang-project/internal/handler"
+	"github.com/krackl1n/golang-project/internal/health"
+	"github.com/krackl1n/golang-project/internal/openapi"
+	"github.com/krackl1n/golang-project/internal/ratelimit"
+)
+
+func TestRouterMatchesOpenAPISpec(t *testing.T) {
+	app := getRouter(handler.New(nil, nil, nil, nil), health.NewRegistry(), nil, nil, nil, nil)
+
+	if err := openapi.CheckRoutes(app.GetRoutes(true)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRouterRateLimitsRejectedCredentials(t *testing.T) {
+	verifier, err := auth.NewJWTVerifier(config.ConfigAuth{JWTSecret: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter, err := ratelimit.NewLimiter(config.ConfigRateLimit{IP: "2/1h"}, ratelimit.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := getRouter(handler.New(nil, nil, nil, nil), health.NewRegistry(), nil, auth.NewAuthenticator(verifier, nil), limiter, nil)
+
+	for i, want := range []int{fiber.StatusUnauthorized, fiber.StatusUnauthorized, fiber.StatusTooManyRequests} {
+		req := httptest.NewRequest(fiber.MethodGet, "/user", nil)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer guessed")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != want {
+			t.Fatalf("request %d: status = %d, want %d", i, resp.StatusCode, want)
+		}
+	}
+}
@@ -1,7 +1,160 @@
 package apperr
 
-import "github.com/pkg/errors"
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
 
 var ErrorNotFound = errors.New(
 	"not found",
 )
+
+var ErrorInvalidCursor = errors.New(
+	"invalid cursor",
+)
+
+var ErrorMalformedPatch = errors.New(
+	"malformed patch",
+)
+
+var ErrorInvalidPatch = errors.New(
+	"invalid patch",
+)
+
+var ErrorPreconditionFailed = errors.New(
+	"precondition failed",
+)
+
+var ErrorBatchAborted = errors.New(
+	"batch aborted",
+)
+
+var ErrorConflict = errors.New(
+	"conflict",
+)
+
+var ErrorValidation = errors.New(
+	"validation failed",
+)
+
+var ErrorUnavailable = errors.New(
+	"service unavailable",
+)
+
+var ErrorUnauthenticated = errors.New(
+	"unauthenticated",
+)
+
+var ErrorForbidden = errors.New(
+	"forbidden",
+)
+
+var ErrorRateLimited = errors.New(
+	"rate limited",
+)
+
+var ErrorIdempotencyKeyInUse = errors.New(
+	"idempotency key in use",
+)
+
+var ErrorIdempotencyKeyReused = errors.New(
+	"idempotency key reused",
+)
+
+// ConflictError reports a write that clashes with existing data, such as a
+// duplicate email. It matches ErrorConflict.
+type ConflictError struct {
+	Field  string
+	Reason string
+}
+
+func (e *ConflictError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("conflict: %s", e.Reason)
+	}
+	return fmt.Sprintf("conflict on %s: %s", e.Field, e.Reason)
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrorConflict
+}
+
+// ValidationError reports a value rejected by the domain or the database
+// rules. It matches ErrorValidation.
+type ValidationError struct {
+	Field  string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("validation failed: %s", e.Reason)
+	}
+	return fmt.Sprintf("validation failed on %s: %s", e.Field, e.Reason)
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrorValidation
+}
+
+// ForbiddenError reports an authenticated caller that lacks the permission
+// an operation requires. It matches ErrorForbidden.
+type ForbiddenError struct {
+	Permission string
+}
+
+func (e *ForbiddenError) Error() string {
+	return fmt.Sprintf("forbidden: %s required", e.Permission)
+}
+
+func (e *ForbiddenError) Is(target error) bool {
+	return target == ErrorForbidden
+}
+
+// UnavailableError reports that a dependency could not serve the request
+// and the call may succeed if retried. It matches ErrorUnavailable.
+type UnavailableError struct {
+	Err error
+}
+
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("service unavailable: %v", e.Err)
+}
+
+func (e *UnavailableError) Is(target error) bool {
+	return target == ErrorUnavailable
+}
+
+func (e *UnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// DetailError attaches a client-safe explanation to one of the sentinel
+// errors above. Detail is an i18n message key, so the explanation reaches
+// clients in their language while the cause is only logged. It matches Kind
+// and unwraps to the cause.
+type DetailError struct {
+	Kind   error
+	Detail string
+	Err    error
+}
+
+func WithDetail(kind error, detail string, err error) error {
+	return &DetailError{Kind: kind, Detail: detail, Err: err}
+}
+
+func (e *DetailError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Kind, e.Detail, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Kind, e.Detail)
+}
+
+func (e *DetailError) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *DetailError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/pkg/errors"
+)
+
+// HeaderAPIKey carries API keys on HTTP requests; gRPC calls use the
+// x-api-key metadata.
+const HeaderAPIKey = "X-API-Key"
+
+// APIKeyVerifier resolves an API key to the principal it was issued for.
+type APIKeyVerifier interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*models.Principal, error)
+}
+
+// TokenError is a bearer token that failed verification. It matches
+// apperr.ErrorUnauthenticated and unwraps to the jwt error.
+type TokenError struct {
+	Err error
+}
+
+func (e *TokenError) Error() string {
+	return fmt.Sprintf("invalid bearer token: %v", e.Err)
+}
+
+func (e *TokenError) Is(target error) bool {
+	return target == apperr.ErrorUnauthenticated
+}
+
+func (e *TokenError) Unwrap() error {
+	return e.Err
+}
+
+// Authenticator accepts a bearer token, an API key, or either, depending on
+// which verifiers it was given.
+type Authenticator struct {
+	jwt     *JWTVerifier
+	apiKeys APIKeyVerifier
+}
+
+// NewAuthenticator returns nil when neither verifier is given, which
+// leaves the API open.
+func NewAuthenticator(jwt *JWTVerifier, apiKeys APIKeyVerifier) *Authenticator {
+	if jwt == nil && apiKeys == nil {
+		return nil
+	}
+	return &Authenticator{jwt: jwt, apiKeys: apiKeys}
+}
+
+// Authenticate checks the API key when one is sent and the Authorization
+// value otherwise. Rejections match apperr.ErrorUnauthenticated.
+func (a *Authenticator) Authenticate(ctx context.Context, authorization, apiKey string) (*models.Principal, error) {
+	if apiKey != "" && a.apiKeys != nil {
+		return a.apiKeys.AuthenticateAPIKey(ctx, apiKey)
+	}
+
+	scheme, token, _ := strings.Cut(authorization, " ")
+	token = strings.TrimSpace(token)
+	if a.jwt == nil || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, errors.Wrap(apperr.ErrorUnauthenticated, "no credentials")
+	}
+
+	principal, err := a.jwt.Verify(token)
+	if err != nil {
+		return nil, &TokenError{Err: err}
+	}
+
+	return principal, nil
+}
+
+// Challenge is the WWW-Authenticate value for a request rejected with err:
+// one challenge per accepted scheme, with the reason a bearer token was
+// refused.
+func (a *Authenticator) Challenge(err error) string {
+	var challenges []string
+	if a.jwt != nil {
+		bearer := fmt.Sprintf(`Bearer realm=%q`, Realm)
+		var tokenErr *TokenError
+		if errors.As(err, &tokenErr) {
+			bearer += fmt.Sprintf(`, error="invalid_token", error_description=%q`, Describe(tokenErr.Err))
+		}
+		challenges = append(challenges, bearer)
+	}
+	if a.apiKeys != nil {
+		challenges = append(challenges, fmt.Sprintf(`APIKey realm=%q, header=%q`, Realm, HeaderAPIKey))
+	}
+
+	return strings.Join(challenges, ", ")
+}
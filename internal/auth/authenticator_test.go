@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/pkg/errors"
+)
+
+// fakeAPIKeys knows the single key "good".
+type fakeAPIKeys struct{}
+
+func (fakeAPIKeys) AuthenticateAPIKey(ctx context.Context, key string) (*models.Principal, error) {
+	if key != "good" {
+		return nil, errors.Wrap(apperr.ErrorUnauthenticated, "unknown api key")
+	}
+	return &models.Principal{Subject: "key-1"}, nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	authenticator := NewAuthenticator(newTestVerifier(t), fakeAPIKeys{})
+	token := sign(t, jwt.SigningMethodHS256, []byte(testSecret), validClaims(), "")
+
+	tests := []struct {
+		name          string
+		authorization string
+		apiKey        string
+		subject       string
+	}{
+		{name: "bearer token", authorization: "Bearer " + token, subject: "user-1"},
+		{name: "scheme in any case", authorization: "bearer " + token, subject: "user-1"},
+		{name: "api key", apiKey: "good", subject: "key-1"},
+		{name: "api key wins over a token", authorization: "Bearer " + token, apiKey: "good", subject: "key-1"},
+		{name: "nothing sent"},
+		{name: "other scheme", authorization: "Basic dXNlcjpwYXNz"},
+		{name: "empty token", authorization: "Bearer "},
+		{name: "bad token", authorization: "Bearer not.a.jwt"},
+		{name: "unknown api key", apiKey: "bad"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := authenticator.Authenticate(context.Background(), tt.authorization, tt.apiKey)
+			if tt.subject == "" {
+				if !errors.Is(err, apperr.ErrorUnauthenticated) {
+					t.Fatalf("err = %v, want unauthenticated", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if principal.Subject != tt.subject {
+				t.Fatalf("subject = %q, want %q", principal.Subject, tt.subject)
+			}
+		})
+	}
+}
+
+func TestNewAuthenticatorWithoutVerifiers(t *testing.T) {
+	if got := NewAuthenticator(nil, nil); got != nil {
+		t.Fatalf("NewAuthenticator(nil, nil) = %v, want nil", got)
+	}
+}
+
+func TestChallenge(t *testing.T) {
+	jwtOnly := NewAuthenticator(newTestVerifier(t), nil)
+	keysOnly := NewAuthenticator(nil, fakeAPIKeys{})
+	both := NewAuthenticator(newTestVerifier(t), fakeAPIKeys{})
+
+	_, tokenErr := both.Authenticate(context.Background(), "Bearer not.a.jwt", "")
+	_, missing := both.Authenticate(context.Background(), "", "")
+
+	tests := []struct {
+		name          string
+		authenticator *Authenticator
+		err           error
+		want          string
+	}{
+		{name: "no credentials", authenticator: jwtOnly, err: missing, want: `Bearer realm="users"`},
+		{
+			name:          "rejected token",
+			authenticator: jwtOnly,
+			err:           tokenErr,
+			want:          `Bearer realm="users", error="invalid_token", error_description="the token is malformed or invalid"`,
+		},
+		{name: "api keys only", authenticator: keysOnly, err: missing, want: `APIKey realm="users", header="X-API-Key"`},
+		{name: "both schemes", authenticator: both, err: missing, want: `Bearer realm="users", APIKey realm="users", header="X-API-Key"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.authenticator.Challenge(tt.err); got != tt.want {
+				t.Fatalf("Challenge = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if !strings.Contains(tokenErr.Error(), "invalid bearer token") {
+		t.Fatalf("token error = %q", tokenErr)
+	}
+}
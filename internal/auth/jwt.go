@@ -0,0 +1,163 @@
+// Package auth verifies the credentials requests carry and turns them into
+// a models.Principal.
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/krackl1n/golang-project/config"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/pkg/errors"
+)
+
+// Realm is the protection space named in WWW-Authenticate challenges.
+const Realm = "users"
+
+var supportedMethods = []string{
+	jwt.SigningMethodHS256.Alg(),
+	jwt.SigningMethodRS256.Alg(),
+	jwt.SigningMethodES256.Alg(),
+}
+
+type claims struct {
+	jwt.RegisteredClaims
+	Scope string   `json:"scope"`
+	Roles []string `json:"roles"`
+}
+
+// JWTVerifier checks the signature and the exp, nbf, iss and aud claims of
+// bearer tokens.
+type JWTVerifier struct {
+	keys   []verificationKey
+	parser *jwt.Parser
+}
+
+// NewJWTVerifier loads every key configured in cfg. At least one is required.
+func NewJWTVerifier(cfg config.ConfigAuth) (*JWTVerifier, error) {
+	var keys []verificationKey
+	if cfg.JWTSecret != "" {
+		keys = append(keys, verificationKey{key: []byte(cfg.JWTSecret)})
+	}
+	if cfg.JWTPublicKeyFile != "" {
+		key, err := loadPublicKeyFile(cfg.JWTPublicKeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "load jwt public key")
+		}
+		keys = append(keys, key)
+	}
+	if cfg.JWKSFile != "" {
+		jwks, err := loadJWKSFile(cfg.JWKSFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "load jwks")
+		}
+		keys = append(keys, jwks...)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no jwt verification key configured")
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(supportedMethods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(cfg.JWTLeeway),
+	}
+	if cfg.JWTIssuer != "" {
+		options = append(options, jwt.WithIssuer(cfg.JWTIssuer))
+	}
+	if cfg.JWTAudience != "" {
+		options = append(options, jwt.WithAudience(cfg.JWTAudience))
+	}
+
+	return &JWTVerifier{
+		keys:   keys,
+		parser: jwt.NewParser(options...),
+	}, nil
+}
+
+// Verify returns the principal a token was issued for. The token must carry
+// a subject.
+func (v *JWTVerifier) Verify(token string) (*models.Principal, error) {
+	var tokenClaims claims
+	if _, err := v.parser.ParseWithClaims(token, &tokenClaims, v.keyFor); err != nil {
+		return nil, err
+	}
+	if tokenClaims.Subject == "" {
+		return nil, errors.Wrap(jwt.ErrTokenInvalidClaims, "missing sub")
+	}
+
+	return &models.Principal{
+		Subject: tokenClaims.Subject,
+		Scopes:  strings.Fields(tokenClaims.Scope),
+		Roles:   tokenClaims.Roles,
+	}, nil
+}
+
+// keyFor offers every key that fits the algorithm and key id of the token.
+func (v *JWTVerifier) keyFor(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	alg := token.Method.Alg()
+
+	set := jwt.VerificationKeySet{}
+	for _, key := range v.keys {
+		if kid != "" && key.id != "" && key.id != kid {
+			continue
+		}
+		if key.accepts(alg) {
+			set.Keys = append(set.Keys, key.key)
+		}
+	}
+	if len(set.Keys) == 0 {
+		return nil, errors.Errorf("no key for alg %s and kid %q", alg, kid)
+	}
+
+	return set, nil
+}
+
+// Describe gives the error_description of a rejected token.
+func Describe(err error) string {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return "the token has expired"
+	case errors.Is(err, jwt.ErrTokenNotValidYet):
+		return "the token is not valid yet"
+	case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+		return "the token was issued by an untrusted issuer"
+	case errors.Is(err, jwt.ErrTokenInvalidAudience):
+		return "the token is not meant for this service"
+	case errors.Is(err, jwt.ErrTokenRequiredClaimMissing):
+		return "the token lacks a required claim"
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrTokenUnverifiable):
+		return "the token signature cannot be verified"
+	default:
+		return "the token is malformed or invalid"
+	}
+}
+
+// verificationKey is a key with the id and algorithm it is restricted to,
+// if any.
+type verificationKey struct {
+	id  string
+	alg string
+	key jwt.VerificationKey
+}
+
+func (k verificationKey) accepts(alg string) bool {
+	if k.alg != "" && k.alg != alg {
+		return false
+	}
+
+	switch key := k.key.(type) {
+	case []byte:
+		return alg == jwt.SigningMethodHS256.Alg()
+	case *rsa.PublicKey:
+		return alg == jwt.SigningMethodRS256.Alg()
+	case *ecdsa.PublicKey:
+		return alg == jwt.SigningMethodES256.Alg() && key.Curve == elliptic.P256()
+	default:
+		return false
+	}
+}
@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/krackl1n/golang-project/config"
+	"github.com/pkg/errors"
+)
+
+const testSecret = "test-secret"
+
+func newTestVerifier(t *testing.T) *JWTVerifier {
+	t.Helper()
+	verifier, err := NewJWTVerifier(config.ConfigAuth{
+		JWTSecret:   testSecret,
+		JWTIssuer:   "https://issuer.example.com",
+		JWTAudience: "users-api",
+		JWTLeeway:   time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return verifier
+}
+
+// validClaims are accepted by newTestVerifier.
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":   "user-1",
+		"iss":   "https://issuer.example.com",
+		"aud":   "users-api",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": "users:read users:write",
+		"roles": []string{"admin"},
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, claims jwt.MapClaims, kid string) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestVerifyReturnsThePrincipal(t *testing.T) {
+	principal, err := newTestVerifier(t).Verify(sign(t, jwt.SigningMethodHS256, []byte(testSecret), validClaims(), ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if principal.Subject != "user-1" || !slices.Equal(principal.Scopes, []string{"users:read", "users:write"}) || !slices.Equal(principal.Roles, []string{"admin"}) {
+		t.Fatalf("principal = %+v", principal)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	verifier := newTestVerifier(t)
+
+	tests := []struct {
+		name   string
+		token  func() string
+		want   error
+		reason string
+	}{
+		{
+			name: "expired",
+			token: func() string {
+				claims := validClaims()
+				claims["exp"] = time.Now().Add(-time.Minute).Unix()
+				return sign(t, jwt.SigningMethodHS256, []byte(testSecret), claims, "")
+			},
+			want:   jwt.ErrTokenExpired,
+			reason: "the token has expired",
+		},
+		{
+			name: "not yet valid",
+			token: func() string {
+				claims := validClaims()
+				claims["nbf"] = time.Now().Add(time.Minute).Unix()
+				return sign(t, jwt.SigningMethodHS256, []byte(testSecret), claims, "")
+			},
+			want:   jwt.ErrTokenNotValidYet,
+			reason: "the token is not valid yet",
+		},
+		{
+			name: "without exp",
+			token: func() string {
+				claims := validClaims()
+				delete(claims, "exp")
+				return sign(t, jwt.SigningMethodHS256, []byte(testSecret), claims, "")
+			},
+			want:   jwt.ErrTokenRequiredClaimMissing,
+			reason: "the token lacks a required claim",
+		},
+		{
+			name: "foreign issuer",
+			token: func() string {
+				claims := validClaims()
+				claims["iss"] = "https://evil.example.com"
+				return sign(t, jwt.SigningMethodHS256, []byte(testSecret), claims, "")
+			},
+			want:   jwt.ErrTokenInvalidIssuer,
+			reason: "the token was issued by an untrusted issuer",
+		},
+		{
+			name: "other audience",
+			token: func() string {
+				claims := validClaims()
+				claims["aud"] = "billing-api"
+				return sign(t, jwt.SigningMethodHS256, []byte(testSecret), claims, "")
+			},
+			want:   jwt.ErrTokenInvalidAudience,
+			reason: "the token is not meant for this service",
+		},
+		{
+			name:   "wrong secret",
+			token:  func() string { return sign(t, jwt.SigningMethodHS256, []byte("other"), validClaims(), "") },
+			want:   jwt.ErrTokenSignatureInvalid,
+			reason: "the token signature cannot be verified",
+		},
+		{
+			name: "unsigned",
+			token: func() string {
+				return sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, validClaims(), "")
+			},
+			want:   jwt.ErrTokenSignatureInvalid,
+			reason: "the token signature cannot be verified",
+		},
+		{
+			name: "without sub",
+			token: func() string {
+				claims := validClaims()
+				delete(claims, "sub")
+				return sign(t, jwt.SigningMethodHS256, []byte(testSecret), claims, "")
+			},
+			want:   jwt.ErrTokenInvalidClaims,
+			reason: "the token is malformed or invalid",
+		},
+		{
+			name:   "garbage",
+			token:  func() string { return "not.a.jwt" },
+			want:   jwt.ErrTokenMalformed,
+			reason: "the token is malformed or invalid",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verifier.Verify(tt.token())
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if got := Describe(err); got != tt.reason {
+				t.Fatalf("Describe = %q, want %q", got, tt.reason)
+			}
+		})
+	}
+}
+
+func TestVerifyPicksTheJWKSKeyByKid(t *testing.T) {
+	current := generateES256(t)
+	previous := generateES256(t)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, map[string]*ecdsa.PrivateKey{"current": current, "previous": previous})
+
+	verifier, err := NewJWTVerifier(config.ConfigAuth{JWKSFile: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}
+	for _, kid := range []string{"current", "previous", ""} {
+		key := current
+		if kid == "previous" {
+			key = previous
+		}
+		if _, err := verifier.Verify(sign(t, jwt.SigningMethodES256, key, claims, kid)); err != nil {
+			t.Fatalf("kid %q: %v", kid, err)
+		}
+	}
+
+	if _, err := verifier.Verify(sign(t, jwt.SigningMethodES256, previous, claims, "current")); !errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+		t.Fatalf("token under the wrong kid: err = %v, want an invalid signature", err)
+	}
+	if _, err := verifier.Verify(sign(t, jwt.SigningMethodES256, current, claims, "retired")); !errors.Is(err, jwt.ErrTokenUnverifiable) {
+		t.Fatalf("unknown kid: err = %v, want an unverifiable token", err)
+	}
+	if _, err := verifier.Verify(sign(t, jwt.SigningMethodHS256, []byte("x"), claims, "current")); !errors.Is(err, jwt.ErrTokenUnverifiable) {
+		t.Fatalf("HS256 against an EC key: err = %v, want an unverifiable token", err)
+	}
+}
+
+func TestNewJWTVerifier(t *testing.T) {
+	if _, err := NewJWTVerifier(config.ConfigAuth{}); err == nil {
+		t.Fatal("no key configured: want an error")
+	}
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, []byte(`{"keys":[{"kty":"EC","crv":"P-256","x":"AQ","y":"AQ"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewJWTVerifier(config.ConfigAuth{JWKSFile: path}); err == nil {
+		t.Fatal("point off the curve: want an error")
+	}
+}
+
+func generateES256(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func writeJWKS(t *testing.T, path string, keys map[string]*ecdsa.PrivateKey) {
+	t.Helper()
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	for kid, key := range keys {
+		set.Keys = append(set.Keys, jwk{
+			Kty: "EC",
+			Kid: kid,
+			Alg: "ES256",
+			Crv: "P-256",
+			X:   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+			Y:   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+		})
+	}
+
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
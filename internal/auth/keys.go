@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// loadPublicKeyFile reads an RSA or EC public key, or a certificate holding
+// one, from a PEM file.
+func loadPublicKeyFile(path string) (verificationKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return verificationKey{}, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return verificationKey{}, errors.New("no PEM block found")
+	}
+
+	var key any
+	switch block.Type {
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		cert, err = x509.ParseCertificate(block.Bytes)
+		if err == nil {
+			key = cert.PublicKey
+		}
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return verificationKey{}, errors.Wrap(err, "parse public key")
+	}
+
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		return verificationKey{key: key}, nil
+	default:
+		return verificationKey{}, errors.Errorf("unsupported public key type %T", key)
+	}
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	// oct
+	K string `json:"k"`
+}
+
+// loadJWKSFile reads the signature keys of a JWK set. Encryption keys are
+// skipped.
+func loadJWKSFile(path string) ([]verificationKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, errors.Wrap(err, "parse jwks")
+	}
+
+	keys := make([]verificationKey, 0, len(set.Keys))
+	for i, entry := range set.Keys {
+		if entry.Use == "enc" {
+			continue
+		}
+
+		key, err := entry.parse()
+		if err != nil {
+			return nil, errors.Wrapf(err, "key %d (kid %q)", i, entry.Kid)
+		}
+		keys = append(keys, verificationKey{id: entry.Kid, alg: entry.Alg, key: key})
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwks has no signature keys")
+	}
+
+	return keys, nil
+}
+
+func (k jwk) parse() (any, error) {
+	switch k.Kty {
+	case "oct":
+		return decodeSegment(k.K)
+	case "RSA":
+		n, err := decodeSegment(k.N)
+		if err != nil {
+			return nil, errors.Wrap(err, "decode n")
+		}
+		e, err := decodeSegment(k.E)
+		if err != nil {
+			return nil, errors.Wrap(err, "decode e")
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("exponent out of range")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		return k.parseEC()
+	default:
+		return nil, errors.Errorf("unsupported kty %q", k.Kty)
+	}
+}
+
+// parseEC only accepts points on the named curve.
+func (k jwk) parseEC() (*ecdsa.PublicKey, error) {
+	var (
+		curve elliptic.Curve
+		check ecdh.Curve
+	)
+	switch k.Crv {
+	case "P-256":
+		curve, check = elliptic.P256(), ecdh.P256()
+	case "P-384":
+		curve, check = elliptic.P384(), ecdh.P384()
+	case "P-521":
+		curve, check = elliptic.P521(), ecdh.P521()
+	default:
+		return nil, errors.Errorf("unsupported crv %q", k.Crv)
+	}
+
+	x, err := decodeSegment(k.X)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode x")
+	}
+	y, err := decodeSegment(k.Y)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode y")
+	}
+
+	size := (curve.Params().BitSize + 7) / 8
+	if len(x) != size || len(y) != size {
+		return nil, errors.New("coordinates have the wrong size")
+	}
+	point := append(append([]byte{4}, x...), y...)
+	if _, err := check.NewPublicKey(point); err != nil {
+		return nil, errors.Wrap(err, "invalid point")
+	}
+
+	return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+}
+
+func decodeSegment(s string) ([]byte, error) {
+	if s == "" {
+		return nil, errors.New("missing value")
+	}
+	return base64.RawURLEncoding.DecodeString(s)
+}
@@ -10,6 +10,9 @@ import (
 	"github.com/krackl1n/golang-project/internal/metrics"
 	"github.com/krackl1n/golang-project/internal/models"
 	"github.com/krackl1n/golang-project/internal/repository"
+	"github.com/krackl1n/golang-project/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type CacheDecorator struct {
@@ -51,7 +54,38 @@ func (c *CacheDecorator) Create(ctx context.Context, user *models.User) (uuid.UU
 	return id, nil
 }
 
-func (c *CacheDecorator) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+func (c *CacheDecorator) CreateBatch(ctx context.Context, users []models.User, atomic bool) ([]error, error) {
+	errs, err := c.userRepository.CreateBatch(ctx, users, atomic)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	expiration := time.Now().Add(c.ttl)
+	for i, user := range users {
+		if errs[i] == nil {
+			c.users[user.ID] = user
+			c.ttls[user.ID] = expiration
+		}
+	}
+	metrics.CacheSize.Set(float64(unsafe.Sizeof(c.users)))
+	c.mu.Unlock()
+
+	return errs, nil
+}
+
+// GetByID serves live users from the cache. Its span tells by cache.hit
+// whether the repository was spared.
+func (c *CacheDecorator) GetByID(ctx context.Context, id uuid.UUID, withDeleted bool) (_ *models.User, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "cache.GetByID", trace.WithAttributes(attribute.String("user.id", id.String())))
+	defer func() { tracing.End(span, err) }()
+
+	// Only live users are cached.
+	if withDeleted {
+		span.SetAttributes(attribute.Bool("cache.hit", false))
+		return c.userRepository.GetByID(ctx, id, withDeleted)
+	}
+
 	c.mu.RLock()
 	user, exists := c.users[id]
 	expirationTime, ttlExists := c.ttls[id]
@@ -59,11 +93,13 @@ func (c *CacheDecorator) GetByID(ctx context.Context, id uuid.UUID) (*models.Use
 
 	if exists && ttlExists && time.Now().Before(expirationTime) {
 		metrics.CacheHits.Inc()
+		span.SetAttributes(attribute.Bool("cache.hit", true))
 		return &user, nil
 	}
 
 	metrics.CacheMisses.Inc()
-	userFromRepo, err := c.userRepository.GetByID(ctx, id)
+	span.SetAttributes(attribute.Bool("cache.hit", false))
+	userFromRepo, err := c.userRepository.GetByID(ctx, id, false)
 	if err != nil {
 		return nil, err
 	}
@@ -77,9 +113,25 @@ func (c *CacheDecorator) GetByID(ctx context.Context, id uuid.UUID) (*models.Use
 	return userFromRepo, nil
 }
 
+func (c *CacheDecorator) List(ctx context.Context, filter *models.UserListFilter) ([]models.User, error) {
+	return c.userRepository.List(ctx, filter)
+}
+
+func (c *CacheDecorator) SearchUsers(ctx context.Context, query *models.UserSearchQuery) ([]models.UserSearchResult, error) {
+	return c.userRepository.SearchUsers(ctx, query)
+}
+
+func (c *CacheDecorator) Export(ctx context.Context, filter *models.UserFilter, fn func(user *models.User) error) error {
+	return c.userRepository.Export(ctx, filter, fn)
+}
+
+// Update caches the user as the repository stored it. A failed write,
+// including a version mismatch, evicts the entry, since the cached copy may
+// be the stale one the caller was told about.
 func (c *CacheDecorator) Update(ctx context.Context, user *models.User) error {
 	err := c.userRepository.Update(ctx, user)
 	if err != nil {
+		c.evict(user.ID)
 		return err
 	}
 
@@ -93,19 +145,64 @@ func (c *CacheDecorator) Update(ctx context.Context, user *models.User) error {
 	return nil
 }
 
-func (c *CacheDecorator) Delete(ctx context.Context, id uuid.UUID) error {
-	err := c.userRepository.Delete(ctx, id)
+// UpdateFields caches the returned user and, like Update, evicts the entry
+// when the write fails.
+func (c *CacheDecorator) UpdateFields(ctx context.Context, id uuid.UUID, changes *models.UserChanges) (*models.User, error) {
+	user, err := c.userRepository.UpdateFields(ctx, id, changes)
 	if err != nil {
-		return err
+		c.evict(id)
+		return nil, err
 	}
 
+	c.mu.Lock()
+	c.users[id] = *user
+	c.ttls[id] = time.Now().Add(c.ttl)
+	metrics.CacheSize.Set(float64(unsafe.Sizeof(c.users)))
+	c.mu.Unlock()
+
+	return user, nil
+}
+
+func (c *CacheDecorator) Delete(ctx context.Context, id uuid.UUID, version int64) error {
+	err := c.userRepository.Delete(ctx, id, version)
+	c.evict(id)
+	return err
+}
+
+func (c *CacheDecorator) Restore(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	user, err := c.userRepository.Restore(ctx, id)
+	if err != nil {
+		c.evict(id)
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.users[id] = *user
+	c.ttls[id] = time.Now().Add(c.ttl)
+	metrics.CacheSize.Set(float64(unsafe.Sizeof(c.users)))
+	c.mu.Unlock()
+
+	return user, nil
+}
+
+func (c *CacheDecorator) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	return c.userRepository.Purge(ctx, deletedBefore)
+}
+
+func (c *CacheDecorator) History(ctx context.Context, id uuid.UUID, before int64, limit int) ([]models.UserHistoryEntry, error) {
+	return c.userRepository.History(ctx, id, before, limit)
+}
+
+func (c *CacheDecorator) GetAsOf(ctx context.Context, id uuid.UUID, at time.Time) (*models.User, error) {
+	return c.userRepository.GetAsOf(ctx, id, at)
+}
+
+func (c *CacheDecorator) evict(id uuid.UUID) {
 	c.mu.Lock()
 	delete(c.users, id)
 	delete(c.ttls, id)
 	metrics.CacheSize.Set(float64(unsafe.Sizeof(c.users)))
 	c.mu.Unlock()
-
-	return nil
 }
 
 func (c *CacheDecorator) Stop() {
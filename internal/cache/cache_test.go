@@ -0,0 +1,150 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/repository"
+)
+
+// fakeUsers stores one user the way the database would: writes must carry
+// its current version and return the stored row.
+type fakeUsers struct {
+	repository.UserProvider
+	stored models.User
+	reads  int
+}
+
+func (f *fakeUsers) GetByID(ctx context.Context, id uuid.UUID, withDeleted bool) (*models.User, error) {
+	f.reads++
+	user := f.stored
+	return &user, nil
+}
+
+func (f *fakeUsers) Update(ctx context.Context, user *models.User) error {
+	if user.Version != f.stored.Version {
+		return apperr.ErrorPreconditionFailed
+	}
+	f.stored.Name, f.stored.Version = user.Name, f.stored.Version+1
+	*user = f.stored
+	return nil
+}
+
+func (f *fakeUsers) UpdateFields(ctx context.Context, id uuid.UUID, changes *models.UserChanges) (*models.User, error) {
+	if changes.Version != f.stored.Version {
+		return nil, apperr.ErrorPreconditionFailed
+	}
+	f.stored.Name, f.stored.Version = *changes.Name, f.stored.Version+1
+	user := f.stored
+	return &user, nil
+}
+
+func newTestCache(t *testing.T) (*CacheDecorator, *fakeUsers) {
+	t.Helper()
+	users := &fakeUsers{stored: models.User{ID: uuid.New(), Name: "Ann", Version: 1}}
+	cache := New(users, time.Minute)
+	t.Cleanup(cache.Stop)
+
+	if _, err := cache.GetByID(context.Background(), users.stored.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	return cache, users
+}
+
+func TestUpdateCachesTheStoredUser(t *testing.T) {
+	cache, users := newTestCache(t)
+	deletedAt := time.Now()
+
+	user := &models.User{ID: users.stored.ID, Name: "Bob", Version: 1, DeletedAt: &deletedAt}
+	if err := cache.Update(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+
+	cached, err := cache.GetByID(context.Background(), user.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if users.reads != 1 {
+		t.Fatalf("repository read %d times, want the update to be served from the cache", users.reads)
+	}
+	if cached.Name != "Bob" || cached.Version != 2 || cached.DeletedAt != nil {
+		t.Fatalf("cached %+v, want the stored user", cached)
+	}
+}
+
+func TestFailedWritesEvict(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(cache *CacheDecorator, id uuid.UUID) error
+	}{
+		{
+			name: "update",
+			write: func(cache *CacheDecorator, id uuid.UUID) error {
+				return cache.Update(context.Background(), &models.User{ID: id, Name: "Bob", Version: 1})
+			},
+		},
+		{
+			name: "update fields",
+			write: func(cache *CacheDecorator, id uuid.UUID) error {
+				name := "Bob"
+				_, err := cache.UpdateFields(context.Background(), id, &models.UserChanges{Name: &name, Version: 1})
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache, users := newTestCache(t)
+			// Another instance wrote the user, so the cached copy is stale.
+			users.stored.Name, users.stored.Version = "Eve", 2
+
+			if err := tt.write(cache, users.stored.ID); err != apperr.ErrorPreconditionFailed {
+				t.Fatalf("write error = %v, want %v", err, apperr.ErrorPreconditionFailed)
+			}
+
+			user, err := cache.GetByID(context.Background(), users.stored.ID, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user.Name != "Eve" || user.Version != 2 {
+				t.Fatalf("got %+v after a failed write, want the stored user", user)
+			}
+		})
+	}
+}
+
+func (f *fakeUsers) Delete(ctx context.Context, id uuid.UUID, version int64) error {
+	now := time.Now()
+	f.stored.DeletedAt = &now
+	return nil
+}
+
+func TestDeleteEvicts(t *testing.T) {
+	cache, users := newTestCache(t)
+
+	if err := cache.Delete(context.Background(), users.stored.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.GetByID(context.Background(), users.stored.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	if users.reads != 2 {
+		t.Fatalf("repository read %d times, want the deleted user to be read again", users.reads)
+	}
+}
+
+func TestGetByIDWithDeletedSkipsTheCache(t *testing.T) {
+	cache, users := newTestCache(t)
+
+	if _, err := cache.GetByID(context.Background(), users.stored.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	if users.reads != 2 {
+		t.Fatalf("repository read %d times, want reads of deleted users to bypass the cache", users.reads)
+	}
+}
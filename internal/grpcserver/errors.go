@@ -0,0 +1,91 @@
+package grpcserver
+
+import (
+	"context"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/i18n"
+	"github.com/krackl1n/golang-project/internal/problem"
+	"github.com/pkg/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// toStatus turns an error of the usecase layer into a gRPC status. The
+// message is the localized detail the HTTP API would send, and field
+// failures travel as a BadRequest detail.
+func toStatus(ctx context.Context, err error) *status.Status {
+	if st, ok := status.FromError(err); ok {
+		return st
+	}
+
+	var acceptLanguage string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("accept-language"); len(values) > 0 {
+			acceptLanguage = values[0]
+		}
+	}
+	p := problem.FromError(err, i18n.FromAcceptLanguage(acceptLanguage))
+
+	st := status.New(code(err), p.Detail)
+	if len(p.Errors) == 0 {
+		return st
+	}
+
+	badRequest := &errdetails.BadRequest{}
+	for _, fieldError := range p.Errors {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       fieldError.Field,
+			Description: fieldError.Message,
+		})
+	}
+	if withDetails, detailsErr := st.WithDetails(badRequest); detailsErr == nil {
+		return withDetails
+	}
+	return st
+}
+
+func code(err error) codes.Code {
+	var (
+		validationErrs validator.ValidationErrors
+		conflictErr    *apperr.ConflictError
+	)
+	switch {
+	case errors.As(err, &validationErrs):
+		return codes.InvalidArgument
+	case errors.As(err, &conflictErr):
+		// Without a field the conflict is a serialization failure worth retrying.
+		if conflictErr.Field != "" {
+			return codes.AlreadyExists
+		}
+		return codes.Aborted
+	case errors.Is(err, apperr.ErrorNotFound):
+		return codes.NotFound
+	case errors.Is(err, apperr.ErrorValidation),
+		errors.Is(err, apperr.ErrorInvalidCursor),
+		errors.Is(err, apperr.ErrorMalformedPatch),
+		errors.Is(err, apperr.ErrorInvalidPatch):
+		return codes.InvalidArgument
+	case errors.Is(err, apperr.ErrorPreconditionFailed), errors.Is(err, apperr.ErrorIdempotencyKeyReused):
+		return codes.FailedPrecondition
+	case errors.Is(err, apperr.ErrorBatchAborted), errors.Is(err, apperr.ErrorConflict), errors.Is(err, apperr.ErrorIdempotencyKeyInUse):
+		return codes.Aborted
+	case errors.Is(err, apperr.ErrorUnavailable):
+		return codes.Unavailable
+	case errors.Is(err, apperr.ErrorUnauthenticated):
+		return codes.Unauthenticated
+	case errors.Is(err, apperr.ErrorForbidden):
+		return codes.PermissionDenied
+	case errors.Is(err, apperr.ErrorRateLimited):
+		return codes.ResourceExhausted
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	default:
+		return codes.Internal
+	}
+}
@@ -0,0 +1,150 @@
+package grpcserver
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/auth"
+	"github.com/krackl1n/golang-project/internal/metrics"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/requestctx"
+	"github.com/krackl1n/golang-project/internal/tracing"
+	"github.com/krackl1n/golang-project/pkg/userpb"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// unaryInterceptor is the gRPC side of Tracing, MetricsMiddleware,
+// RequestContext and respondError: it continues the trace of the
+// traceparent metadata with a server span, passes the x-request-id
+// metadata, or a new id, down and back in the response header, converts
+// errors to statuses, logs failures and records metrics per method and
+// code.
+func unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+	name := strings.TrimPrefix(info.FullMethod, "/")
+	service, method, _ := strings.Cut(name, "/")
+	ctx, span := tracing.Tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(semconv.RPCSystemGRPC, semconv.RPCService(service), semconv.RPCMethod(method)),
+	)
+	defer span.End()
+
+	var requestID string
+	if values := md.Get("x-request-id"); len(values) > 0 {
+		requestID = values[0]
+	}
+	requestID = requestctx.EnsureRequestID(requestID)
+	ctx = requestctx.WithRequestID(ctx, requestID)
+	ctx = requestctx.WithRoute(ctx, info.FullMethod)
+	if err := grpc.SetHeader(ctx, metadata.Pairs("x-request-id", requestID)); err != nil {
+		slog.DebugContext(ctx, "set x-request-id header", slog.Any("error", err))
+	}
+
+	resp, err := handler(ctx, req)
+
+	statusCode := codes.OK
+	if err != nil {
+		st := toStatus(ctx, err)
+		statusCode = st.Code()
+		if isServerError(statusCode) {
+			span.SetStatus(otelcodes.Error, st.Message())
+			slog.ErrorContext(ctx, info.FullMethod, slog.Any("error", err))
+		} else {
+			slog.DebugContext(ctx, info.FullMethod, slog.Any("error", err))
+		}
+		err = st.Err()
+	}
+
+	duration := time.Since(start).Seconds()
+	metrics.GrpcRequestsTotal.WithLabelValues(info.FullMethod, statusCode.String()).Inc()
+	metrics.ObserveWithTrace(ctx, metrics.GrpcRequestDuration.WithLabelValues(info.FullMethod, statusCode.String()), duration)
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(statusCode)))
+
+	return resp, err
+}
+
+func isServerError(code codes.Code) bool {
+	switch code {
+	case codes.Internal, codes.Unknown, codes.Unavailable, codes.DataLoss, codes.Unimplemented:
+		return true
+	default:
+		return false
+	}
+}
+
+// methodScopes is the scope each method requires, as router.go does for
+// the HTTP routes.
+var methodScopes = map[string]string{
+	userpb.UserService_CreateUser_FullMethodName: models.ScopeUsersWrite,
+	userpb.UserService_GetUser_FullMethodName:    models.ScopeUsersRead,
+	userpb.UserService_ListUsers_FullMethodName:  models.ScopeUsersRead,
+	userpb.UserService_UpdateUser_FullMethodName: models.ScopeUsersWrite,
+	userpb.UserService_DeleteUser_FullMethodName: models.ScopeUsersDelete,
+}
+
+// authInterceptor is the gRPC side of middleware.Authenticate and
+// middleware.RequireScope. It runs inside unaryInterceptor, so rejected
+// calls are still logged and counted. Methods without a scope are denied.
+func authInterceptor(authenticator *auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var authorization, apiKey string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get("authorization"); len(values) > 0 {
+				authorization = values[0]
+			}
+			if values := md.Get("x-api-key"); len(values) > 0 {
+				apiKey = values[0]
+			}
+		}
+
+		principal, err := authenticator.Authenticate(ctx, authorization, apiKey)
+		if err != nil {
+			return nil, err
+		}
+
+		scope, ok := methodScopes[info.FullMethod]
+		if !ok {
+			return nil, errors.Wrap(apperr.ErrorForbidden, info.FullMethod)
+		}
+		if !principal.HasScope(scope) {
+			return nil, &apperr.ForbiddenError{Permission: scope}
+		}
+
+		return handler(requestctx.WithPrincipal(ctx, principal), req)
+	}
+}
+
+// metadataCarrier reads trace context from incoming gRPC metadata.
+type metadataCarrier metadata.MD
+
+func (mc metadataCarrier) Get(key string) string {
+	if values := metadata.MD(mc).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (mc metadataCarrier) Set(key, value string) {
+	metadata.MD(mc).Set(key, value)
+}
+
+func (mc metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(mc))
+	for key := range mc {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,237 @@
+// Package grpcserver serves usecase.UserProvider over gRPC, next to the
+// Fiber HTTP API and with the same validation and error semantics.
+package grpcserver
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/auth"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/usecase"
+	"github.com/krackl1n/golang-project/internal/validation"
+	"github.com/krackl1n/golang-project/pkg/userpb"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type Server struct {
+	userpb.UnimplementedUserServiceServer
+	userUC usecase.UserProvider
+}
+
+// New returns a gRPC server with the user service registered and the
+// metrics and logging interceptor installed. Calls need a bearer token in
+// the authorization metadata or an API key in x-api-key, granting the scope
+// of the method, when an authenticator is given.
+func New(userUsecase usecase.UserProvider, authenticator *auth.Authenticator) *grpc.Server {
+	interceptors := []grpc.UnaryServerInterceptor{unaryInterceptor}
+	if authenticator != nil {
+		interceptors = append(interceptors, authInterceptor(authenticator))
+	}
+
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
+	userpb.RegisterUserServiceServer(server, &Server{userUC: userUsecase})
+
+	return server
+}
+
+func (s *Server) CreateUser(ctx context.Context, req *userpb.CreateUserRequest) (*userpb.CreateUserResponse, error) {
+	age, err := toAge(req.GetAge())
+	if err != nil {
+		return nil, err
+	}
+
+	createUserDTO := models.CreateUserDTO{
+		Name:   req.GetName(),
+		Age:    age,
+		Gender: req.GetGender(),
+		Email:  req.GetEmail(),
+	}
+	if err := validation.Struct(createUserDTO); err != nil {
+		return nil, err
+	}
+
+	id, err := s.userUC.CreateUser(ctx, &createUserDTO)
+	if err != nil {
+		return nil, err
+	}
+
+	return &userpb.CreateUserResponse{Id: id.String()}, nil
+}
+
+func (s *Server) GetUser(ctx context.Context, req *userpb.GetUserRequest) (*userpb.User, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userUC.GetUserById(ctx, id, req.GetWithDeleted())
+	if err != nil {
+		return nil, err
+	}
+
+	return toProtoUser(user), nil
+}
+
+func (s *Server) ListUsers(ctx context.Context, req *userpb.ListUsersRequest) (*userpb.ListUsersResponse, error) {
+	listUsersDTO := models.ListUsersDTO{
+		Cursor:      req.GetCursor(),
+		Limit:       int(req.GetLimit()),
+		Gender:      req.GetGender(),
+		EmailDomain: req.GetEmailDomain(),
+		Sort:        req.GetSort(),
+		WithDeleted: req.GetWithDeleted(),
+	}
+	for _, bound := range []struct {
+		value *uint32
+		dest  **uint8
+		field string
+	}{
+		{req.AgeMin, &listUsersDTO.AgeMin, "age_min"},
+		{req.AgeMax, &listUsersDTO.AgeMax, "age_max"},
+	} {
+		if bound.value == nil {
+			continue
+		}
+		if *bound.value > math.MaxUint8 {
+			return nil, &apperr.ValidationError{Field: bound.field, Reason: "out of range"}
+		}
+		age := uint8(*bound.value)
+		*bound.dest = &age
+	}
+
+	if err := validation.Struct(listUsersDTO); err != nil {
+		return nil, err
+	}
+	if listUsersDTO.AgeMin != nil && listUsersDTO.AgeMax != nil && *listUsersDTO.AgeMin > *listUsersDTO.AgeMax {
+		return nil, &apperr.ValidationError{Field: "age_min", Reason: "greater than age_max"}
+	}
+
+	criteria := &models.UserCriteria{
+		UserFilter: models.UserFilter{
+			Gender:        listUsersDTO.Gender,
+			AgeMin:        listUsersDTO.AgeMin,
+			AgeMax:        listUsersDTO.AgeMax,
+			EmailDomain:   strings.ToLower(listUsersDTO.EmailDomain),
+			CreatedAfter:  fromTimestamp(req.GetCreatedAfter()),
+			CreatedBefore: fromTimestamp(req.GetCreatedBefore()),
+			UpdatedAfter:  fromTimestamp(req.GetUpdatedAfter()),
+			UpdatedBefore: fromTimestamp(req.GetUpdatedBefore()),
+			WithDeleted:   listUsersDTO.WithDeleted,
+		},
+		UserSort: models.UserSort{
+			Field: models.UserSortByID,
+		},
+		Cursor: listUsersDTO.Cursor,
+		Limit:  listUsersDTO.Limit,
+	}
+	if listUsersDTO.Sort != "" {
+		criteria.Desc = strings.HasPrefix(listUsersDTO.Sort, "-")
+		criteria.Field = models.UserSortField(strings.TrimPrefix(listUsersDTO.Sort, "-"))
+	}
+
+	page, err := s.userUC.ListUsers(ctx, criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &userpb.ListUsersResponse{
+		Users:      make([]*userpb.User, 0, len(page.Users)),
+		NextCursor: page.NextCursor,
+	}
+	for i := range page.Users {
+		resp.Users = append(resp.Users, toProtoUser(&page.Users[i]))
+	}
+
+	return resp, nil
+}
+
+func (s *Server) UpdateUser(ctx context.Context, req *userpb.UpdateUserRequest) (*userpb.User, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	age, err := toAge(req.GetAge())
+	if err != nil {
+		return nil, err
+	}
+
+	user := models.User{
+		ID:      id,
+		Name:    req.GetName(),
+		Age:     age,
+		Gender:  req.GetGender(),
+		Email:   req.GetEmail(),
+		Version: req.GetVersion(),
+	}
+	if err := validation.Struct(user); err != nil {
+		return nil, err
+	}
+
+	if err := s.userUC.UpdateUser(ctx, &user); err != nil {
+		return nil, err
+	}
+
+	return toProtoUser(&user), nil
+}
+
+func (s *Server) DeleteUser(ctx context.Context, req *userpb.DeleteUserRequest) (*emptypb.Empty, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userUC.DeleteUser(ctx, id, req.GetVersion()); err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func parseID(value string) (uuid.UUID, error) {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, &apperr.ValidationError{Field: "id", Reason: "not a valid UUID"}
+	}
+	return id, nil
+}
+
+// toAge rejects ages a uint8 cannot hold instead of wrapping them around.
+func toAge(value uint32) (uint8, error) {
+	if value > math.MaxUint8 {
+		return 0, &apperr.ValidationError{Field: "age", Reason: "out of range"}
+	}
+	return uint8(value), nil
+}
+
+func toProtoUser(user *models.User) *userpb.User {
+	pbUser := &userpb.User{
+		Id:        user.ID.String(),
+		Name:      user.Name,
+		Age:       uint32(user.Age),
+		Gender:    user.Gender,
+		Email:     user.Email,
+		CreatedAt: timestamppb.New(user.CreatedAt),
+		UpdatedAt: timestamppb.New(user.UpdatedAt),
+		Version:   user.Version,
+	}
+	if user.DeletedAt != nil {
+		pbUser.DeletedAt = timestamppb.New(*user.DeletedAt)
+	}
+
+	return pbUser
+}
+
+func fromTimestamp(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
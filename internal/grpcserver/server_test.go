@@ -0,0 +1,185 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/config"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/auth"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/usecase"
+	"github.com/krackl1n/golang-project/pkg/userpb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeUserUC creates users unless their email is taken and knows one user.
+type fakeUserUC struct {
+	usecase.UserProvider
+	user  models.User
+	taken string
+}
+
+func (f *fakeUserUC) CreateUser(ctx context.Context, dto *models.CreateUserDTO) (uuid.UUID, error) {
+	if dto.Email == f.taken {
+		return uuid.Nil, &apperr.ConflictError{Field: "email", Reason: "already exists"}
+	}
+	return f.user.ID, nil
+}
+
+func (f *fakeUserUC) GetUserById(ctx context.Context, id uuid.UUID, withDeleted bool) (*models.User, error) {
+	if id != f.user.ID {
+		return nil, apperr.ErrorNotFound
+	}
+	user := f.user
+	return &user, nil
+}
+
+// newTestClient serves uc over an in-memory listener.
+func newTestClient(t *testing.T, uc usecase.UserProvider, authenticator *auth.Authenticator) userpb.UserServiceClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := New(uc, authenticator)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return userpb.NewUserServiceClient(conn)
+}
+
+func TestServerStatuses(t *testing.T) {
+	uc := &fakeUserUC{user: models.User{ID: uuid.New(), Name: "Ann", Age: 30, Gender: "female", Email: "ann@example.com"}, taken: "taken@example.com"}
+	client := newTestClient(t, uc, nil)
+	ctx := context.Background()
+
+	var header metadata.MD
+	user, err := client.GetUser(ctx, &userpb.GetUserRequest{Id: uc.user.ID.String()}, grpc.Header(&header))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.GetEmail() != "ann@example.com" || len(header.Get("x-request-id")) != 1 {
+		t.Fatalf("user = %v with header %v, want Ann and a request id", user, header)
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+		code codes.Code
+	}{
+		{
+			name: "malformed id",
+			call: func() error { _, err := client.GetUser(ctx, &userpb.GetUserRequest{Id: "42"}); return err },
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "unknown user",
+			call: func() error { _, err := client.GetUser(ctx, &userpb.GetUserRequest{Id: uuid.NewString()}); return err },
+			code: codes.NotFound,
+		},
+		{
+			name: "age out of range",
+			call: func() error {
+				_, err := client.CreateUser(ctx, &userpb.CreateUserRequest{Name: "Bob", Age: 300, Gender: "male", Email: "bob@example.com"})
+				return err
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "taken email",
+			call: func() error {
+				_, err := client.CreateUser(ctx, &userpb.CreateUserRequest{Name: "Bob", Age: 30, Gender: "male", Email: "taken@example.com"})
+				return err
+			},
+			code: codes.AlreadyExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := status.Code(tt.call()); got != tt.code {
+				t.Fatalf("code = %s, want %s", got, tt.code)
+			}
+		})
+	}
+}
+
+func TestServerReportsFieldViolationsInTheCallerLanguage(t *testing.T) {
+	client := newTestClient(t, &fakeUserUC{}, nil)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "accept-language", "ru")
+
+	_, err := client.CreateUser(ctx, &userpb.CreateUserRequest{Name: "Bob", Age: 30, Gender: "other", Email: "bob@example.com"})
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument || st.Message() != "одно или несколько полей заполнены неверно" {
+		t.Fatalf("status = %s %q, want a Russian InvalidArgument", st.Code(), st.Message())
+	}
+
+	var violations []*errdetails.BadRequest_FieldViolation
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			violations = badRequest.GetFieldViolations()
+		}
+	}
+	if len(violations) != 1 || violations[0].GetField() != "gender" || violations[0].GetDescription() == "" {
+		t.Fatalf("violations = %v, want one for gender", violations)
+	}
+}
+
+func TestServerAuthenticatesCalls(t *testing.T) {
+	const secret = "test-secret"
+	verifier, err := auth.NewJWTVerifier(config.ConfigAuth{JWTSecret: secret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	uc := &fakeUserUC{user: models.User{ID: uuid.New()}}
+	client := newTestClient(t, uc, auth.NewAuthenticator(verifier, nil))
+
+	withToken := func(scope string) context.Context {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub":   "user-1",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"scope": scope,
+		}).SignedString([]byte(secret))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		code codes.Code
+	}{
+		{name: "granted", ctx: withToken(models.ScopeUsersRead), code: codes.OK},
+		{name: "no credentials", ctx: context.Background(), code: codes.Unauthenticated},
+		{name: "invalid token", ctx: metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer x"), code: codes.Unauthenticated},
+		{name: "missing scope", ctx: withToken(models.ScopeUsersWrite), code: codes.PermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.GetUser(tt.ctx, &userpb.GetUserRequest{Id: uc.user.ID.String()})
+			if got := status.Code(err); got != tt.code {
+				t.Fatalf("code = %s, want %s", got, tt.code)
+			}
+		})
+	}
+}
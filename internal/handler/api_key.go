@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/validation"
+)
+
+// IssueAPIKey responds with the only copy of the key the server ever
+// hands out.
+func (h *Handle) IssueAPIKey(c fiber.Ctx) error {
+	createAPIKeyDTO := models.CreateAPIKeyDTO{}
+	if err := c.Bind().Body(&createAPIKeyDTO); err != nil {
+		return badRequest(c, "invalid request body", err)
+	}
+
+	if err := validation.Struct(createAPIKeyDTO); err != nil {
+		return badRequest(c, "validate createAPIKeyDTO", err)
+	}
+
+	key, err := h.apiKeyUC.IssueAPIKey(c.Context(), &createAPIKeyDTO)
+	if err != nil {
+		return respondError(c, "issue api key", err)
+	}
+
+	c.Location(fmt.Sprintf("/api-keys/%s", key.ID))
+	return c.Status(http.StatusCreated).JSON(key)
+}
+
+func (h *Handle) ListAPIKeys(c fiber.Ctx) error {
+	keys, err := h.apiKeyUC.ListAPIKeys(c.Context())
+	if err != nil {
+		return respondError(c, "list api keys", err)
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"api_keys": keys,
+	})
+}
+
+// RevokeAPIKey keeps the key listed, with its revocation time, so its use
+// can still be traced.
+func (h *Handle) RevokeAPIKey(c fiber.Ctx) error {
+	uuidKey, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return badRequest(c, "parse id", invalidParam("id", "uuid", err))
+	}
+
+	key, err := h.apiKeyUC.RevokeAPIKey(c.Context(), uuidKey)
+	if err != nil {
+		return respondError(c, "revoke api key", err)
+	}
+
+	return c.Status(http.StatusOK).JSON(key)
+}
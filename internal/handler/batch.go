@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/problem"
+)
+
+type batchItemResponse struct {
+	Index  int              `json:"index"`
+	Status int              `json:"status"`
+	ID     *uuid.UUID       `json:"id,omitempty"`
+	Error  *problem.Problem `json:"error,omitempty"`
+}
+
+type batchResponse struct {
+	Mode    models.BatchMode    `json:"mode"`
+	Created int                 `json:"created"`
+	Failed  int                 `json:"failed"`
+	Results []batchItemResponse `json:"results"`
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/problem"
+	"github.com/krackl1n/golang-project/internal/usecase"
+)
+
+// batchUC fails the items whose email is taken.
+type batchUC struct {
+	usecase.UserProvider
+	taken string
+	mode  models.BatchMode
+}
+
+func (f *batchUC) CreateUsers(ctx context.Context, dtos []models.CreateUserDTO, mode models.BatchMode) ([]models.BatchItemResult, error) {
+	f.mode = mode
+	results := make([]models.BatchItemResult, len(dtos))
+	for i, dto := range dtos {
+		results[i].Index = i
+		if dto.Email == f.taken {
+			results[i].Err = &apperr.ConflictError{Field: "email"}
+			continue
+		}
+		results[i].User = &models.User{ID: uuid.New(), Email: dto.Email}
+	}
+	return results, nil
+}
+
+func TestCreateUsersStatus(t *testing.T) {
+	body := `[{"name":"Ann","age":30,"gender":"female","email":"ann@example.com"},{"name":"Eve","age":40,"gender":"female","email":"eve@example.com"}]`
+
+	tests := []struct {
+		name     string
+		query    string
+		taken    string
+		mode     models.BatchMode
+		status   int
+		created  int
+		failures []int
+	}{
+		{name: "all created", taken: "", mode: models.BatchAtomic, status: http.StatusCreated, created: 2},
+		{name: "atomic failure", taken: "eve@example.com", mode: models.BatchAtomic, status: http.StatusUnprocessableEntity, created: 1, failures: []int{http.StatusConflict}},
+		{name: "best effort failure", query: "?mode=best_effort", taken: "eve@example.com", mode: models.BatchBestEffort, status: http.StatusMultiStatus, created: 1, failures: []int{http.StatusConflict}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &batchUC{taken: tt.taken}
+			app := fiber.New()
+			app.Post("/users", (&Handle{userUC: uc}).CreateUsers)
+
+			req := httptest.NewRequest(http.MethodPost, "/users"+tt.query, strings.NewReader(body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			var got struct {
+				Mode    models.BatchMode `json:"mode"`
+				Created int              `json:"created"`
+				Failed  int              `json:"failed"`
+				Results []struct {
+					Index  int              `json:"index"`
+					Status int              `json:"status"`
+					Error  *problem.Problem `json:"error"`
+				} `json:"results"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.StatusCode != tt.status || uc.mode != tt.mode || got.Mode != tt.mode {
+				t.Fatalf("status %d in mode %s, want %d in mode %s", resp.StatusCode, uc.mode, tt.status, tt.mode)
+			}
+			if got.Created != tt.created || got.Failed != len(tt.failures) {
+				t.Fatalf("created %d and failed %d, want %d and %d", got.Created, got.Failed, tt.created, len(tt.failures))
+			}
+			var failures []int
+			for _, result := range got.Results {
+				if result.Error != nil {
+					failures = append(failures, result.Status)
+				}
+			}
+			if len(failures) != len(tt.failures) || (len(failures) > 0 && failures[0] != tt.failures[0]) {
+				t.Fatalf("failed items %v, want %v", failures, tt.failures)
+			}
+		})
+	}
+}
+
+func TestCreateUsersRejectsUnknownMode(t *testing.T) {
+	app := fiber.New()
+	app.Post("/users", (&Handle{userUC: &batchUC{}}).CreateUsers)
+
+	req := httptest.NewRequest(http.MethodPost, "/users?mode=sometimes", strings.NewReader(`[]`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/schema"
+	"github.com/krackl1n/golang-project/internal/i18n"
+	"github.com/krackl1n/golang-project/internal/problem"
+	"github.com/pkg/errors"
+)
+
+// paramError names the request parameter a parse failure belongs to. The
+// client is told which parameter to fix and which rule it broke, never the
+// parser's own message.
+type paramError struct {
+	field string
+	rule  string
+	param string
+	err   error
+}
+
+func invalidParam(field, rule string, err error) error {
+	return &paramError{field: field, rule: rule, err: err}
+}
+
+func (e *paramError) Error() string {
+	return fmt.Sprintf("%s: %s: %v", e.field, e.rule, e.err)
+}
+
+func (e *paramError) Unwrap() error {
+	return e.err
+}
+
+// badRequest reports input the handler could not parse or validate.
+// Validator failures are listed per field, and so are parse and bind
+// failures, with a fixed message for the rule they broke in the language of
+// the request.
+func badRequest(c fiber.Ctx, op string, err error) error {
+	slog.DebugContext(c.Context(), op, slog.Any("error", err))
+
+	trans := i18n.FromContext(c)
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return problem.Write(c, problem.Validation(validationErrs, trans))
+	}
+
+	return problem.Write(c, problem.Fields(inputErrors(err, trans), trans))
+}
+
+// inputErrors maps the errors of uuid parsing, the JSON body decoder and the
+// query string binder onto the fields they concern. An error it does not
+// recognize is reported without field details.
+func inputErrors(err error, trans ut.Translator) []problem.FieldError {
+	var (
+		paramErr     *paramError
+		syntaxErr    *json.SyntaxError
+		typeErr      *json.UnmarshalTypeError
+		multiErr     schema.MultiError
+		conversion   schema.ConversionError
+		unknownKey   schema.UnknownKeyError
+		missingField schema.EmptyFieldError
+	)
+	switch {
+	case errors.As(err, &paramErr):
+		return []problem.FieldError{fieldError(trans, paramErr.field, paramErr.rule, paramErr.param)}
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return []problem.FieldError{fieldError(trans, "body", "json", "")}
+	case errors.As(err, &typeErr):
+		field := typeErr.Field
+		if field == "" {
+			field = "body"
+		}
+		return []problem.FieldError{fieldError(trans, field, "type", "")}
+	case errors.As(err, &multiErr):
+		keys := make([]string, 0, len(multiErr))
+		for key := range multiErr {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+
+		fieldErrs := make([]problem.FieldError, 0, len(keys))
+		for _, key := range keys {
+			fieldErrs = append(fieldErrs, inputErrors(multiErr[key], trans)...)
+		}
+		return fieldErrs
+	case errors.As(err, &conversion):
+		return []problem.FieldError{fieldError(trans, conversion.Key, "type", "")}
+	case errors.As(err, &unknownKey):
+		return []problem.FieldError{fieldError(trans, unknownKey.Key, "unknown", "")}
+	case errors.As(err, &missingField):
+		return []problem.FieldError{fieldError(trans, missingField.Key, "required", "")}
+	default:
+		return nil
+	}
+}
+
+func fieldError(trans ut.Translator, field, rule, param string) problem.FieldError {
+	return problem.FieldError{
+		Field:   field,
+		Rule:    rule,
+		Param:   param,
+		Message: fieldMessage(trans, field, rule, param),
+	}
+}
+
+func fieldMessage(trans ut.Translator, field, rule, param string) string {
+	switch rule {
+	case "uuid":
+		return i18n.T(trans, i18n.DetailInvalidUUID, field)
+	case "json":
+		return i18n.T(trans, i18n.DetailMalformedBody)
+	case "integer":
+		return i18n.T(trans, i18n.DetailInvalidInteger, field)
+	case "type":
+		return i18n.T(trans, i18n.DetailWrongType, field)
+	case "unknown":
+		return i18n.T(trans, i18n.DetailUnknownParam, field)
+	case "required":
+		return i18n.T(trans, i18n.DetailRequiredField, field)
+	case "ltefield":
+		return i18n.T(trans, i18n.DetailFieldGreaterThan, field, param)
+	default:
+		return i18n.T(trans, i18n.DetailInvalidField, field)
+	}
+}
+
+// respondError writes the error returned by a usecase call. Client errors
+// are logged at debug level, server errors at error level.
+func respondError(c fiber.Ctx, op string, err error) error {
+	p := problem.FromError(err, i18n.FromContext(c))
+	if p.Status < http.StatusInternalServerError {
+		slog.DebugContext(c.Context(), op, slog.Any("error", err))
+	} else {
+		slog.ErrorContext(c.Context(), op, slog.Any("error", err))
+	}
+
+	return problem.Write(c, p)
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/krackl1n/golang-project/internal/problem"
+)
+
+func TestBadRequestNamesTheField(t *testing.T) {
+	h := &Handle{userUC: &fakeUserUC{}}
+	app := fiber.New()
+	app.Post("/user", h.CreateUser)
+	app.Get("/user", h.ListUsers)
+	app.Get("/user/:id", h.GetUser)
+	app.Get("/webhooks/deliveries/:id", h.GetWebhookDelivery)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		want   []problem.FieldError
+	}{
+		{
+			name: "malformed body", method: http.MethodPost, target: "/user", body: `{"name":`,
+			want: []problem.FieldError{{Field: "body", Rule: "json", Message: "the request body is not valid JSON"}},
+		},
+		{
+			name: "wrong type in body", method: http.MethodPost, target: "/user", body: `{"age":"old"}`,
+			want: []problem.FieldError{{Field: "age", Rule: "type", Message: "age has the wrong type"}},
+		},
+		{
+			name: "malformed id", method: http.MethodGet, target: "/user/42",
+			want: []problem.FieldError{{Field: "id", Rule: "uuid", Message: "id must be a UUID"}},
+		},
+		{
+			name: "malformed delivery id", method: http.MethodGet, target: "/webhooks/deliveries/abc",
+			want: []problem.FieldError{{Field: "id", Rule: "integer", Message: "id must be an integer"}},
+		},
+		{
+			name: "wrong type in query", method: http.MethodGet, target: "/user?limit=ten",
+			want: []problem.FieldError{{Field: "limit", Rule: "type", Message: "limit has the wrong type"}},
+		},
+		{
+			name: "unknown query param", method: http.MethodGet, target: "/user?page=2",
+			want: []problem.FieldError{{Field: "page", Rule: "unknown", Message: "page is not a supported parameter"}},
+		},
+		{
+			name: "inverted age range", method: http.MethodGet, target: "/user?age_min=50&age_max=20",
+			want: []problem.FieldError{{Field: "age_min", Rule: "ltefield", Param: "age_max", Message: "age_min must not be greater than age_max"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+			}
+			if ct := resp.Header.Get(fiber.HeaderContentType); ct != problem.ContentType {
+				t.Fatalf("content type = %q, want %q", ct, problem.ContentType)
+			}
+
+			var p problem.Problem
+			if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+				t.Fatal(err)
+			}
+			if p.Type != problem.TypeValidation {
+				t.Errorf("type = %q, want %q", p.Type, problem.TypeValidation)
+			}
+			if !reflect.DeepEqual(p.Errors, tt.want) {
+				t.Errorf("errors = %+v, want %+v", p.Errors, tt.want)
+			}
+		})
+	}
+}
+
+func TestBadRequestIsLocalized(t *testing.T) {
+	h := &Handle{userUC: &fakeUserUC{}}
+	app := fiber.New()
+	app.Get("/user", h.ListUsers)
+	app.Get("/user/:id", h.GetUser)
+
+	tests := []struct {
+		target string
+		want   problem.FieldError
+	}{
+		{target: "/user/42", want: problem.FieldError{Field: "id", Rule: "uuid", Message: "поле id должно быть UUID"}},
+		{
+			target: "/user?age_min=50&age_max=20",
+			want:   problem.FieldError{Field: "age_min", Rule: "ltefield", Param: "age_max", Message: "поле age_min не должно быть больше age_max"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			req.Header.Set(fiber.HeaderAcceptLanguage, "ru-RU,ru;q=0.9,en;q=0.5")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			var p problem.Problem
+			if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+				t.Fatal(err)
+			}
+			if p.Title != "Ошибка валидации" {
+				t.Errorf("title = %q, want the Russian title", p.Title)
+			}
+			if len(p.Errors) != 1 || p.Errors[0] != tt.want {
+				t.Errorf("errors = %+v, want %+v", p.Errors, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/internal/apperr"
+)
+
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// parseIfMatch returns the user versions an If-Match header accepts. An
+// absent header or "*" puts no requirement on the version and yields none.
+// Weak or malformed tags can never match and are left out, so ok is false
+// when no tag is left.
+func parseIfMatch(header string) (versions []int64, ok bool) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil, true
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+
+		version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+		if err != nil || version <= 0 {
+			continue
+		}
+		versions = append(versions, version)
+	}
+
+	return versions, len(versions) > 0
+}
+
+// ifMatchVersion returns the version a conditional write on the user id is
+// made against, or zero when the request sets no condition. Of several
+// listed tags the one naming the stored version is taken; the write checks
+// it again, so a change in between still fails the request.
+func (h *Handle) ifMatchVersion(c fiber.Ctx, id uuid.UUID) (int64, error) {
+	versions, ok := parseIfMatch(c.Get(fiber.HeaderIfMatch))
+	if !ok {
+		return 0, apperr.ErrorPreconditionFailed
+	}
+	switch len(versions) {
+	case 0:
+		return 0, nil
+	case 1:
+		return versions[0], nil
+	}
+
+	user, err := h.userUC.GetUserById(c.Context(), id, false)
+	if err != nil {
+		return 0, err
+	}
+	if !slices.Contains(versions, user.Version) {
+		return 0, apperr.ErrorPreconditionFailed
+	}
+
+	return user.Version, nil
+}
+
+// matchesIfNoneMatch uses the weak comparison required for If-None-Match.
+func matchesIfNoneMatch(header string, version int64) bool {
+	current := etag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+
+	return false
+}
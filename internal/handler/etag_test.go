@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/usecase"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		header   string
+		versions []int64
+		ok       bool
+	}{
+		{header: "", ok: true},
+		{header: "*", ok: true},
+		{header: `"7"`, versions: []int64{7}, ok: true},
+		{header: ` "7" `, versions: []int64{7}, ok: true},
+		{header: `"7", "9"`, versions: []int64{7, 9}, ok: true},
+		{header: `W/"7", "9"`, versions: []int64{9}, ok: true},
+		{header: `W/"7"`, ok: false},
+		{header: `W/"7", 9`, ok: false},
+		{header: `7`, ok: false},
+		{header: `"0"`, ok: false},
+		{header: `"seven"`, ok: false},
+		{header: `"`, ok: false},
+	}
+
+	for _, tt := range tests {
+		versions, ok := parseIfMatch(tt.header)
+		if !slices.Equal(versions, tt.versions) || ok != tt.ok {
+			t.Errorf("parseIfMatch(%q) = %v, %t, want %v, %t", tt.header, versions, ok, tt.versions, tt.ok)
+		}
+	}
+}
+
+func TestMatchesIfNoneMatch(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{header: `"3"`, want: true},
+		{header: `W/"3"`, want: true},
+		{header: `"1", "3"`, want: true},
+		{header: `*`, want: true},
+		{header: `"4"`, want: false},
+		{header: `3`, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := matchesIfNoneMatch(tt.header, 3); got != tt.want {
+			t.Errorf("matchesIfNoneMatch(%q, 3) = %t, want %t", tt.header, got, tt.want)
+		}
+	}
+}
+
+// versionedUC keeps one user at a version and refuses writes at any other.
+type versionedUC struct {
+	usecase.UserProvider
+	user models.User
+}
+
+func (f *versionedUC) GetUserById(ctx context.Context, id uuid.UUID, withDeleted bool) (*models.User, error) {
+	user := f.user
+	return &user, nil
+}
+
+func (f *versionedUC) UpdateUser(ctx context.Context, user *models.User) error {
+	if user.Version != 0 && user.Version != f.user.Version {
+		return apperr.ErrorPreconditionFailed
+	}
+	user.Version = f.user.Version + 1
+	return nil
+}
+
+func (f *versionedUC) DeleteUser(ctx context.Context, id uuid.UUID, version int64) error {
+	if version != 0 && version != f.user.Version {
+		return apperr.ErrorPreconditionFailed
+	}
+	return nil
+}
+
+func TestConditionalRequests(t *testing.T) {
+	id := uuid.New()
+	h := &Handle{userUC: &versionedUC{user: models.User{ID: id, Name: "Ann", Version: 3}}}
+	app := fiber.New()
+	app.Get("/user/:id", h.GetUser)
+	app.Put("/user", h.UpdateUser)
+	app.Delete("/user/:id", h.DeleteUser)
+
+	body := `{"id":"` + id.String() + `","name":"Bob","age":30,"gender":"male","email":"bob@example.com"}`
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		body     string
+		header   string
+		value    string
+		status   int
+		wantETag string
+	}{
+		{name: "get", method: http.MethodGet, target: "/user/" + id.String(), status: http.StatusOK, wantETag: `"3"`},
+		{name: "get not modified", method: http.MethodGet, target: "/user/" + id.String(), header: fiber.HeaderIfNoneMatch, value: `W/"3"`, status: http.StatusNotModified, wantETag: `"3"`},
+		{name: "get modified", method: http.MethodGet, target: "/user/" + id.String(), header: fiber.HeaderIfNoneMatch, value: `"2"`, status: http.StatusOK, wantETag: `"3"`},
+		{name: "put current", method: http.MethodPut, target: "/user", body: body, header: fiber.HeaderIfMatch, value: `"3"`, status: http.StatusOK, wantETag: `"4"`},
+		{name: "put stale", method: http.MethodPut, target: "/user", body: body, header: fiber.HeaderIfMatch, value: `"2"`, status: http.StatusPreconditionFailed},
+		{name: "put weak", method: http.MethodPut, target: "/user", body: body, header: fiber.HeaderIfMatch, value: `W/"3"`, status: http.StatusPreconditionFailed},
+		{name: "put listed", method: http.MethodPut, target: "/user", body: body, header: fiber.HeaderIfMatch, value: `"2", "3"`, status: http.StatusOK, wantETag: `"4"`},
+		{name: "put none listed", method: http.MethodPut, target: "/user", body: body, header: fiber.HeaderIfMatch, value: `"1", "2"`, status: http.StatusPreconditionFailed},
+		{name: "delete stale", method: http.MethodDelete, target: "/user/" + id.String(), header: fiber.HeaderIfMatch, value: `"2"`, status: http.StatusPreconditionFailed},
+		{name: "delete listed", method: http.MethodDelete, target: "/user/" + id.String(), header: fiber.HeaderIfMatch, value: `"1", "3"`, status: http.StatusOK},
+		{name: "delete unconditional", method: http.MethodDelete, target: "/user/" + id.String(), status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			}
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if got := resp.Header.Get(fiber.HeaderETag); got != tt.wantETag {
+				t.Fatalf("ETag = %q, want %q", got, tt.wantETag)
+			}
+		})
+	}
+}
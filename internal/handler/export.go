@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/krackl1n/golang-project/internal/i18n"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/problem"
+	"github.com/krackl1n/golang-project/internal/validation"
+)
+
+const mimeNDJSON = "application/x-ndjson"
+
+var exportContentTypes = map[models.ExportFormat]string{
+	models.ExportCSV:    "text/csv; charset=utf-8",
+	models.ExportNDJSON: mimeNDJSON,
+	models.ExportJSON:   fiber.MIMEApplicationJSONCharsetUTF8,
+}
+
+var exportFormatsByMIME = map[string]models.ExportFormat{
+	fiber.MIMEApplicationJSON: models.ExportJSON,
+	mimeNDJSON:                models.ExportNDJSON,
+	"text/csv":                models.ExportCSV,
+}
+
+// ExportUsers streams all users matching the list filters. The format
+// query parameter wins over the Accept header.
+func (h *Handle) ExportUsers(c fiber.Ctx) error {
+	exportUsersDTO := models.ExportUsersDTO{}
+	if err := c.Bind().Query(&exportUsersDTO); err != nil {
+		return badRequest(c, "invalid query params", err)
+	}
+
+	if err := validation.Struct(exportUsersDTO); err != nil {
+		return badRequest(c, "validate exportUsersDTO", err)
+	}
+
+	filter, err := parseUserFilter(c)
+	if err != nil {
+		return badRequest(c, "parse user filter", err)
+	}
+
+	format := exportUsersDTO.Format
+	if format == "" {
+		format = exportFormatsByMIME[c.Accepts(fiber.MIMEApplicationJSON, mimeNDJSON, "text/csv")]
+	}
+	if format == "" {
+		slog.DebugContext(c.Context(), "unacceptable export format", slog.String("accept", c.Get(fiber.HeaderAccept)))
+		return problem.Write(c, problem.New(http.StatusNotAcceptable, i18n.T(i18n.FromContext(c), i18n.DetailUnsupportedExport)))
+	}
+
+	// The body is written after the handler returns, so keep the context now.
+	ctx := c.Context()
+	if err := h.userUC.AuthorizeExport(ctx); err != nil {
+		return respondError(c, "authorize export", err)
+	}
+	c.Set(fiber.HeaderContentType, exportContentTypes[format])
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="users.%s"`, format))
+	c.Status(http.StatusOK)
+
+	return c.SendStreamWriter(func(w *bufio.Writer) {
+		encoder := newUserEncoder(format, w)
+		err := h.userUC.ExportUsers(ctx, filter, encoder.write)
+		if err == nil {
+			err = encoder.close()
+		}
+		if err != nil {
+			// Headers are gone already; the client sees a truncated body.
+			slog.ErrorContext(ctx, "export users", slog.Any("error", err))
+		}
+	})
+}
+
+var exportCSVHeader = []string{"id", "name", "age", "gender", "email", "created_at", "updated_at", "deleted_at"}
+
+// userEncoder writes exported users one at a time in a single format.
+type userEncoder struct {
+	format models.ExportFormat
+	w      *bufio.Writer
+	csv    *csv.Writer
+	count  int
+}
+
+// newUserEncoder starts the document: the CSV header or the opening
+// bracket of the JSON array.
+func newUserEncoder(format models.ExportFormat, w *bufio.Writer) *userEncoder {
+	encoder := &userEncoder{format: format, w: w}
+	switch format {
+	case models.ExportCSV:
+		encoder.csv = csv.NewWriter(w)
+		_ = encoder.csv.Write(exportCSVHeader)
+	case models.ExportJSON:
+		// An error stays in w and is returned by the first write.
+		_ = w.WriteByte('[')
+	}
+	return encoder
+}
+
+// write fails once the client is gone: bufio keeps the first error of the
+// connection, which stops the export and ends its transaction.
+func (e *userEncoder) write(user *models.User) error {
+	e.count++
+	if e.format == models.ExportCSV {
+		deletedAt := ""
+		if user.DeletedAt != nil {
+			deletedAt = user.DeletedAt.Format(time.RFC3339Nano)
+		}
+		return e.csv.Write([]string{
+			user.ID.String(), user.Name, strconv.Itoa(int(user.Age)), user.Gender, user.Email,
+			user.CreatedAt.Format(time.RFC3339Nano), user.UpdatedAt.Format(time.RFC3339Nano), deletedAt,
+		})
+	}
+
+	data, err := json.Marshal(user)
+	if err != nil {
+		return err
+	}
+	if e.format == models.ExportJSON && e.count > 1 {
+		if err := e.w.WriteByte(','); err != nil {
+			return err
+		}
+	}
+	if _, err := e.w.Write(data); err != nil {
+		return err
+	}
+	if e.format == models.ExportNDJSON {
+		return e.w.WriteByte('\n')
+	}
+	return nil
+}
+
+func (e *userEncoder) close() error {
+	switch e.format {
+	case models.ExportCSV:
+		e.csv.Flush()
+		return e.csv.Error()
+	case models.ExportJSON:
+		if err := e.w.WriteByte(']'); err != nil {
+			return err
+		}
+	}
+	return e.w.Flush()
+}
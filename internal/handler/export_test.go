@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/problem"
+	"github.com/krackl1n/golang-project/internal/requestctx"
+	"github.com/krackl1n/golang-project/internal/usecase"
+	"github.com/pkg/errors"
+)
+
+// fakeUserUC serves the calls a test sets up; any other call panics on the
+// nil embedded interface.
+type fakeUserUC struct {
+	usecase.UserProvider
+	users     []models.User
+	exportErr error
+}
+
+func (f *fakeUserUC) AuthorizeExport(ctx context.Context) error {
+	return nil
+}
+
+func (f *fakeUserUC) ExportUsers(ctx context.Context, filter *models.UserFilter, fn func(user *models.User) error) error {
+	for i := range f.users {
+		if err := fn(&f.users[i]); err != nil {
+			return err
+		}
+	}
+	return f.exportErr
+}
+
+func newTestApp(h *Handle) *fiber.App {
+	app := fiber.New()
+	app.Use(func(c fiber.Ctx) error {
+		c.SetContext(requestctx.WithRequestID(c.Context(), "test-request"))
+		return c.Next()
+	})
+	app.Get("/user/export", h.ExportUsers)
+	return app
+}
+
+func testUsers() []models.User {
+	createdAt := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	return []models.User{
+		{ID: uuid.MustParse("0196a3c4-0000-7000-8000-000000000001"), Name: "Ann", Age: 30, Gender: "female", Email: "ann@example.com", CreatedAt: createdAt, UpdatedAt: createdAt},
+		{ID: uuid.MustParse("0196a3c4-0000-7000-8000-000000000002"), Name: "Bob", Age: 40, Gender: "male", Email: "bob@example.com", CreatedAt: createdAt, UpdatedAt: createdAt},
+	}
+}
+
+func TestExportUsersFormats(t *testing.T) {
+	h := &Handle{userUC: &fakeUserUC{users: testUsers()}}
+	app := newTestApp(h)
+
+	tests := []struct {
+		name            string
+		target          string
+		accept          string
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "csv",
+			target:          "/user/export?format=csv",
+			wantContentType: "text/csv; charset=utf-8",
+			wantBody: "id,name,age,gender,email,created_at,updated_at,deleted_at\n" +
+				"0196a3c4-0000-7000-8000-000000000001,Ann,30,female,ann@example.com,2025-05-01T12:00:00Z,2025-05-01T12:00:00Z,\n" +
+				"0196a3c4-0000-7000-8000-000000000002,Bob,40,male,bob@example.com,2025-05-01T12:00:00Z,2025-05-01T12:00:00Z,\n",
+		},
+		{
+			name:            "ndjson from Accept",
+			target:          "/user/export",
+			accept:          mimeNDJSON,
+			wantContentType: mimeNDJSON,
+			wantBody: `{"id":"0196a3c4-0000-7000-8000-000000000001","name":"Ann","age":30,"gender":"female","email":"ann@example.com","created_at":"2025-05-01T12:00:00Z","updated_at":"2025-05-01T12:00:00Z"}` + "\n" +
+				`{"id":"0196a3c4-0000-7000-8000-000000000002","name":"Bob","age":40,"gender":"male","email":"bob@example.com","created_at":"2025-05-01T12:00:00Z","updated_at":"2025-05-01T12:00:00Z"}` + "\n",
+		},
+		{
+			name:            "json",
+			target:          "/user/export?format=json",
+			wantContentType: fiber.MIMEApplicationJSONCharsetUTF8,
+			wantBody: `[{"id":"0196a3c4-0000-7000-8000-000000000001","name":"Ann","age":30,"gender":"female","email":"ann@example.com","created_at":"2025-05-01T12:00:00Z","updated_at":"2025-05-01T12:00:00Z"},` +
+				`{"id":"0196a3c4-0000-7000-8000-000000000002","name":"Bob","age":40,"gender":"male","email":"bob@example.com","created_at":"2025-05-01T12:00:00Z","updated_at":"2025-05-01T12:00:00Z"}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, tt.target, nil)
+			if tt.accept != "" {
+				req.Header.Set(fiber.HeaderAccept, tt.accept)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if contentType := resp.Header.Get(fiber.HeaderContentType); contentType != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", contentType, tt.wantContentType)
+			}
+			if string(body) != tt.wantBody {
+				t.Errorf("body = %s\nwant %s", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestExportUsersNotAcceptable(t *testing.T) {
+	h := &Handle{userUC: &fakeUserUC{}}
+	req := httptest.NewRequest(fiber.MethodGet, "/user/export", nil)
+	req.Header.Set(fiber.HeaderAccept, "application/xml")
+	req.Header.Set(fiber.HeaderAcceptLanguage, "ru")
+
+	resp, err := newTestApp(h).Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusNotAcceptable {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, fiber.StatusNotAcceptable)
+	}
+
+	var p problem.Problem
+	if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+	if want := "экспорт доступен в форматах application/json, application/x-ndjson и text/csv"; p.Detail != want {
+		t.Fatalf("detail = %q, want %q", p.Detail, want)
+	}
+}
+
+// brokenConn fails like a connection the client has closed.
+type brokenConn struct{}
+
+func (brokenConn) Write(p []byte) (int, error) {
+	return 0, errors.New("connection reset by peer")
+}
+
+func TestUserEncoderStopsOnWriteError(t *testing.T) {
+	for _, format := range []models.ExportFormat{models.ExportCSV, models.ExportNDJSON, models.ExportJSON} {
+		t.Run(string(format), func(t *testing.T) {
+			encoder := newUserEncoder(format, bufio.NewWriterSize(brokenConn{}, 64))
+			user := testUsers()[0]
+
+			var err error
+			for i := 0; i < 1000 && err == nil; i++ {
+				err = encoder.write(&user)
+			}
+			if err == nil {
+				t.Fatal("write kept succeeding on a broken connection")
+			}
+		})
+	}
+}
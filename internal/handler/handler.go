@@ -2,50 +2,52 @@ package handler
 
 import (
 	"log/slog"
+	"mime"
 	"net/http"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v3"
 	"github.com/google/uuid"
-	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/i18n"
 	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/problem"
 	"github.com/krackl1n/golang-project/internal/usecase"
-	"github.com/pkg/errors"
+	"github.com/krackl1n/golang-project/internal/validation"
 )
 
 type Handle struct {
-	userUC usecase.UserProvider
+	userUC    usecase.UserProvider
+	importUC  usecase.ImportProvider
+	webhookUC usecase.WebhookProvider
+	apiKeyUC  usecase.APIKeyProvider
 }
 
-func New(userUsecase usecase.UserProvider) Handler {
+func New(
+	userUsecase usecase.UserProvider,
+	importUsecase usecase.ImportProvider,
+	webhookUsecase usecase.WebhookProvider,
+	apiKeyUsecase usecase.APIKeyProvider,
+) Handler {
 	return &Handle{
-		userUC: userUsecase,
+		userUC:    userUsecase,
+		importUC:  importUsecase,
+		webhookUC: webhookUsecase,
+		apiKeyUC:  apiKeyUsecase,
 	}
 }
 
 func (h *Handle) CreateUser(c fiber.Ctx) error {
 	createUserDTO := models.CreateUserDTO{}
 	if err := c.Bind().Body(&createUserDTO); err != nil {
-		slog.Debug("invalid request body", slog.Any("error", err))
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": errors.Wrap(err, "invalid request body").Error(),
-		})
+		return badRequest(c, "invalid request body", err)
 	}
 
-	validate := validator.New()
-	if err := validate.Struct(createUserDTO); err != nil {
-		slog.Debug("validate createUserDTO", slog.Any("error", err))
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": errors.Wrap(err, "invalid request body").Error(),
-		})
+	if err := validation.Struct(createUserDTO); err != nil {
+		return badRequest(c, "validate createUserDTO", err)
 	}
 
 	idUser, err := h.userUC.CreateUser(c.Context(), &createUserDTO)
 	if err != nil {
-		slog.Error("create user", slog.Any("error", err))
-		c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return respondError(c, "create user", err)
 	}
 
 	return c.Status(http.StatusCreated).JSON(fiber.Map{
@@ -53,87 +55,249 @@ func (h *Handle) CreateUser(c fiber.Ctx) error {
 	})
 }
 
+func (h *Handle) CreateUsers(c fiber.Ctx) error {
+	batchDTO := models.CreateUsersBatchDTO{}
+	if err := c.Bind().Query(&batchDTO); err != nil {
+		return badRequest(c, "invalid query params", err)
+	}
+
+	if err := validation.Struct(batchDTO); err != nil {
+		return badRequest(c, "validate createUsersBatchDTO", err)
+	}
+	if batchDTO.Mode == "" {
+		batchDTO.Mode = models.BatchAtomic
+	}
+
+	createUserDTOs := []models.CreateUserDTO{}
+	if err := c.Bind().Body(&createUserDTOs); err != nil {
+		return badRequest(c, "invalid request body", err)
+	}
+
+	results, err := h.userUC.CreateUsers(c.Context(), createUserDTOs, batchDTO.Mode)
+	if err != nil {
+		return respondError(c, "create users", err)
+	}
+
+	trans := i18n.FromContext(c)
+	resp := batchResponse{
+		Mode:    batchDTO.Mode,
+		Results: make([]batchItemResponse, 0, len(results)),
+	}
+	for _, result := range results {
+		item := batchItemResponse{
+			Index:  result.Index,
+			Status: http.StatusCreated,
+		}
+		if result.Err != nil {
+			item.Error = problem.FromError(result.Err, trans)
+			item.Status = item.Error.Status
+			resp.Failed++
+		} else {
+			item.ID = &result.User.ID
+			resp.Created++
+		}
+		resp.Results = append(resp.Results, item)
+	}
+
+	switch {
+	case resp.Failed == 0:
+		return c.Status(http.StatusCreated).JSON(resp)
+	case batchDTO.Mode == models.BatchAtomic:
+		return c.Status(http.StatusUnprocessableEntity).JSON(resp)
+	default:
+		return c.Status(http.StatusMultiStatus).JSON(resp)
+	}
+}
+
 func (h *Handle) GetUser(c fiber.Ctx) error {
 
 	uuidUser, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		slog.Debug("parse id", slog.Any("error", err))
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": errors.Wrap(err, "parse id").Error(),
-		})
+		return badRequest(c, "parse id", invalidParam("id", "uuid", err))
+	}
+
+	getUserDTO := models.GetUserDTO{}
+	if err := c.Bind().Query(&getUserDTO); err != nil {
+		return badRequest(c, "invalid query params", err)
+	}
+
+	if err := validation.Struct(getUserDTO); err != nil {
+		return badRequest(c, "validate getUserDTO", err)
 	}
 
-	user, err := h.userUC.GetUserById(c.Context(), uuidUser)
+	var user *models.User
+	if asOf := parseTime(getUserDTO.AsOf); asOf != nil {
+		user, err = h.userUC.GetUserAsOf(c.Context(), uuidUser, *asOf, getUserDTO.WithDeleted)
+	} else {
+		user, err = h.userUC.GetUserById(c.Context(), uuidUser, getUserDTO.WithDeleted)
+	}
 	if err != nil {
-		if errors.Is(err, apperr.ErrorNotFound) {
-			slog.Debug("get by id", slog.Any("error", err))
-			return c.Status(http.StatusNotFound).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		}
-		slog.Error("get user", slog.Any("error", err))
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": errors.Wrap(err, "get user").Error(),
-		})
+		return respondError(c, "get user", err)
+	}
+
+	c.Set(fiber.HeaderETag, etag(user.Version))
+	if ifNoneMatch := c.Get(fiber.HeaderIfNoneMatch); ifNoneMatch != "" && matchesIfNoneMatch(ifNoneMatch, user.Version) {
+		return c.SendStatus(http.StatusNotModified)
 	}
 
 	return c.Status(http.StatusOK).JSON(user)
 }
 
+func (h *Handle) UserHistory(c fiber.Ctx) error {
+	uuidUser, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return badRequest(c, "parse id", invalidParam("id", "uuid", err))
+	}
+
+	userHistoryDTO := models.UserHistoryDTO{}
+	if err := c.Bind().Query(&userHistoryDTO); err != nil {
+		return badRequest(c, "invalid query params", err)
+	}
+
+	if err := validation.Struct(userHistoryDTO); err != nil {
+		return badRequest(c, "validate userHistoryDTO", err)
+	}
+
+	page, err := h.userUC.UserHistory(c.Context(), uuidUser, &userHistoryDTO)
+	if err != nil {
+		return respondError(c, "user history", err)
+	}
+
+	return c.Status(http.StatusOK).JSON(page)
+}
+
+func (h *Handle) ListUsers(c fiber.Ctx) error {
+	criteria, err := parseUserCriteria(c)
+	if err != nil {
+		return badRequest(c, "parse user criteria", err)
+	}
+
+	page, err := h.userUC.ListUsers(c.Context(), criteria)
+	if err != nil {
+		return respondError(c, "list users", err)
+	}
+
+	return c.Status(http.StatusOK).JSON(page)
+}
+
+func (h *Handle) SearchUsers(c fiber.Ctx) error {
+	searchUsersDTO := models.SearchUsersDTO{}
+	if err := c.Bind().Query(&searchUsersDTO); err != nil {
+		return badRequest(c, "invalid query params", err)
+	}
+
+	if err := validation.Struct(searchUsersDTO); err != nil {
+		return badRequest(c, "validate searchUsersDTO", err)
+	}
+
+	results, err := h.userUC.SearchUsers(c.Context(), &searchUsersDTO)
+	if err != nil {
+		return respondError(c, "search users", err)
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"results": results,
+	})
+}
+
 func (h *Handle) UpdateUser(c fiber.Ctx) error {
 	user := models.User{}
 	if err := c.Bind().Body(&user); err != nil {
-		slog.Debug("invalid request body", slog.Any("error", err))
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": errors.Wrap(err, "invalid request body").Error(),
-		})
+		return badRequest(c, "invalid request body", err)
+	}
+
+	if err := validation.Struct(user); err != nil {
+		return badRequest(c, "validate user", err)
 	}
 
-	validate := validator.New()
-	if err := validate.Struct(user); err != nil {
-		slog.Debug("validate user", slog.Any("error", err))
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": errors.Wrap(err, "invalid request body").Error(),
-		})
+	version, err := h.ifMatchVersion(c, user.ID)
+	if err != nil {
+		return respondError(c, "check if-match", err)
 	}
+	user.Version = version
 
 	if err := h.userUC.UpdateUser(c.Context(), &user); err != nil {
-		if errors.Is(err, apperr.ErrorNotFound) {
-			slog.Debug("update user", slog.Any("error", err))
-			return c.Status(http.StatusNotFound).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		}
-		slog.Error("update user", slog.Any("error", err))
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": errors.Wrap(err, "update user").Error(),
-		})
+		return respondError(c, "update user", err)
 	}
 
+	c.Set(fiber.HeaderETag, etag(user.Version))
 	return c.SendStatus(http.StatusOK)
 }
 
+func (h *Handle) PatchUser(c fiber.Ctx) error {
+	uuidUser, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return badRequest(c, "parse id", invalidParam("id", "uuid", err))
+	}
+
+	mediaType, _, err := mime.ParseMediaType(c.Get(fiber.HeaderContentType))
+	patchType := models.UserPatchType(mediaType)
+	if err != nil || (patchType != models.MergePatch && patchType != models.JSONPatch) {
+		slog.DebugContext(c.Context(), "unsupported patch media type", slog.String("content_type", c.Get(fiber.HeaderContentType)))
+		c.Set("Accept-Patch", string(models.MergePatch)+", "+string(models.JSONPatch))
+		return problem.Write(c, problem.New(http.StatusUnsupportedMediaType, i18n.T(i18n.FromContext(c), i18n.DetailUnsupportedPatch)))
+	}
+
+	version, err := h.ifMatchVersion(c, uuidUser)
+	if err != nil {
+		return respondError(c, "check if-match", err)
+	}
+
+	patch := &models.UserPatch{
+		Type:     patchType,
+		Document: c.Body(),
+		Version:  version,
+	}
+
+	user, err := h.userUC.PatchUser(c.Context(), uuidUser, patch)
+	if err != nil {
+		return respondError(c, "patch user", err)
+	}
+
+	c.Set(fiber.HeaderETag, etag(user.Version))
+	return c.Status(http.StatusOK).JSON(user)
+}
+
 func (h *Handle) DeleteUser(c fiber.Ctx) error {
 	uuidUser, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		slog.Debug("delete user", slog.Any("error", err))
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": errors.Wrap(err, "parse id").Error(),
-		})
+		return badRequest(c, "delete user", invalidParam("id", "uuid", err))
 	}
 
-	if err = h.userUC.DeleteUser(c.Context(), uuidUser); err != nil {
-		if errors.Is(err, apperr.ErrorNotFound) {
-			slog.Debug("update user", slog.Any("error", err))
-			return c.Status(http.StatusNotFound).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		}
-		slog.Error("delete user", slog.Any("error", err))
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": errors.Wrap(err, "delete user").Error(),
-		})
+	version, err := h.ifMatchVersion(c, uuidUser)
+	if err != nil {
+		return respondError(c, "check if-match", err)
+	}
+
+	if err = h.userUC.DeleteUser(c.Context(), uuidUser, version); err != nil {
+		return respondError(c, "delete user", err)
 	}
 
 	return c.SendStatus(http.StatusOK)
 }
+
+func (h *Handle) RestoreUser(c fiber.Ctx) error {
+	uuidUser, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return badRequest(c, "parse id", invalidParam("id", "uuid", err))
+	}
+
+	user, err := h.userUC.RestoreUser(c.Context(), uuidUser)
+	if err != nil {
+		return respondError(c, "restore user", err)
+	}
+
+	c.Set(fiber.HeaderETag, etag(user.Version))
+	return c.Status(http.StatusOK).JSON(user)
+}
+
+func (h *Handle) PurgeUsers(c fiber.Ctx) error {
+	purged, err := h.userUC.PurgeDeletedUsers(c.Context())
+	if err != nil {
+		return respondError(c, "purge users", err)
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"purged": purged,
+	})
+}
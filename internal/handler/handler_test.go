@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/problem"
+	"github.com/krackl1n/golang-project/internal/usecase"
+)
+
+func TestPatchUserRejectsOtherMediaTypes(t *testing.T) {
+	app := fiber.New()
+	app.Patch("/user/:id", (&Handle{userUC: &fakeUserUC{}}).PatchUser)
+
+	req := httptest.NewRequest(http.MethodPatch, "/user/"+uuid.NewString(), strings.NewReader(`{"name":"Bob"}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusUnsupportedMediaType)
+	}
+	if got := resp.Header.Get("Accept-Patch"); got != "application/merge-patch+json, application/json-patch+json" {
+		t.Fatalf("Accept-Patch = %q", got)
+	}
+}
+
+// createUC fails every create with err.
+type createUC struct {
+	usecase.UserProvider
+	err error
+}
+
+func (f *createUC) CreateUser(ctx context.Context, createUserDTO *models.CreateUserDTO) (uuid.UUID, error) {
+	return uuid.Nil, f.err
+}
+
+func TestCreateUserReportsUsecaseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		typ    string
+	}{
+		{name: "duplicate email", err: &apperr.ConflictError{Field: "email", Reason: "already exists"}, status: http.StatusConflict, typ: problem.TypeConflict},
+		{name: "check violation", err: &apperr.ValidationError{Field: "age", Reason: "violates users_age_check"}, status: http.StatusBadRequest, typ: problem.TypeValidation},
+		{name: "database down", err: &apperr.UnavailableError{Err: context.DeadlineExceeded}, status: http.StatusServiceUnavailable, typ: problem.TypeUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Post("/user", (&Handle{userUC: &createUC{err: tt.err}}).CreateUser)
+
+			req := httptest.NewRequest(http.MethodPost, "/user", strings.NewReader(`{"name":"Ann","age":30,"gender":"female","email":"ann@example.com"}`))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			var p problem.Problem
+			if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.status || p.Type != tt.typ {
+				t.Fatalf("got %d %s, want %d %s", resp.StatusCode, p.Type, tt.status, tt.typ)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/validation"
+)
+
+func (h *Handle) CreateImportJob(c fiber.Ctx) error {
+	createImportJobDTO := models.CreateImportJobDTO{}
+	if err := c.Bind().Body(&createImportJobDTO); err != nil {
+		return badRequest(c, "invalid request body", err)
+	}
+
+	if err := validation.Struct(createImportJobDTO); err != nil {
+		return badRequest(c, "validate createImportJobDTO", err)
+	}
+
+	job, err := h.importUC.CreateImportJob(c.Context(), &createImportJobDTO)
+	if err != nil {
+		return respondError(c, "create import job", err)
+	}
+
+	c.Location(fmt.Sprintf("/user/import/%s", job.ID))
+	return c.Status(http.StatusCreated).JSON(job)
+}
+
+func (h *Handle) GetImportJob(c fiber.Ctx) error {
+	uuidJob, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return badRequest(c, "parse id", invalidParam("id", "uuid", err))
+	}
+
+	job, err := h.importUC.GetImportJob(c.Context(), uuidJob)
+	if err != nil {
+		return respondError(c, "get import job", err)
+	}
+
+	return c.Status(http.StatusOK).JSON(job)
+}
+
+// UploadImportData runs the import while the body is still arriving, so
+// the file is never held in memory as a whole.
+func (h *Handle) UploadImportData(c fiber.Ctx) error {
+	uuidJob, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return badRequest(c, "parse id", invalidParam("id", "uuid", err))
+	}
+
+	var body io.Reader = c.Request().BodyStream()
+	if body == nil {
+		body = bytes.NewReader(c.Body())
+	}
+
+	job, err := h.importUC.RunImport(c.Context(), uuidJob, body)
+	if err != nil {
+		return respondError(c, "run import", err)
+	}
+
+	return c.Status(http.StatusOK).JSON(job)
+}
+
+// ImportErrors downloads the rejected rows of a job as CSV.
+func (h *Handle) ImportErrors(c fiber.Ctx) error {
+	uuidJob, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return badRequest(c, "parse id", invalidParam("id", "uuid", err))
+	}
+
+	if _, err := h.importUC.GetImportJob(c.Context(), uuidJob); err != nil {
+		return respondError(c, "get import job", err)
+	}
+
+	ctx := c.Context()
+	c.Attachment(fmt.Sprintf("import-%s-errors.csv", uuidJob))
+	return c.SendStreamWriter(func(w *bufio.Writer) {
+		report := csv.NewWriter(w)
+		_ = report.Write([]string{"line", "error", "raw"})
+
+		err := h.importUC.WriteImportErrors(ctx, uuidJob, func(rowError *models.ImportRowError) error {
+			return report.Write([]string{strconv.FormatInt(rowError.Line, 10), rowError.Error, rowError.Raw})
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "write import errors", slog.Any("error", err))
+		}
+		report.Flush()
+	})
+}
@@ -4,7 +4,28 @@ import "github.com/gofiber/fiber/v3"
 
 type Handler interface {
 	CreateUser(c fiber.Ctx) error
+	CreateUsers(c fiber.Ctx) error
 	GetUser(c fiber.Ctx) error
+	UserHistory(c fiber.Ctx) error
+	ListUsers(c fiber.Ctx) error
+	SearchUsers(c fiber.Ctx) error
+	ExportUsers(c fiber.Ctx) error
 	UpdateUser(c fiber.Ctx) error
+	PatchUser(c fiber.Ctx) error
 	DeleteUser(c fiber.Ctx) error
+	RestoreUser(c fiber.Ctx) error
+	PurgeUsers(c fiber.Ctx) error
+	CreateImportJob(c fiber.Ctx) error
+	GetImportJob(c fiber.Ctx) error
+	UploadImportData(c fiber.Ctx) error
+	ImportErrors(c fiber.Ctx) error
+	CreateWebhook(c fiber.Ctx) error
+	ListWebhooks(c fiber.Ctx) error
+	DeleteWebhook(c fiber.Ctx) error
+	ListWebhookDeliveries(c fiber.Ctx) error
+	GetWebhookDelivery(c fiber.Ctx) error
+	ReplayWebhookDelivery(c fiber.Ctx) error
+	IssueAPIKey(c fiber.Ctx) error
+	ListAPIKeys(c fiber.Ctx) error
+	RevokeAPIKey(c fiber.Ctx) error
 }
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/validation"
+	"github.com/pkg/errors"
+)
+
+var listUsersParams = map[string]struct{}{
+	"cursor":         {},
+	"limit":          {},
+	"gender":         {},
+	"age_min":        {},
+	"age_max":        {},
+	"email_domain":   {},
+	"created_after":  {},
+	"created_before": {},
+	"updated_after":  {},
+	"updated_before": {},
+	"sort":           {},
+	"with_deleted":   {},
+}
+
+// exportUsersParams are the list filters plus the export format; an export
+// is never paginated or sorted.
+var exportUsersParams = map[string]struct{}{
+	"format":         {},
+	"gender":         {},
+	"age_min":        {},
+	"age_max":        {},
+	"email_domain":   {},
+	"created_after":  {},
+	"created_before": {},
+	"updated_after":  {},
+	"updated_before": {},
+	"with_deleted":   {},
+}
+
+// parseUserCriteria turns the list query string into usecase criteria.
+// Only the parameters in listUsersParams are accepted, and sort must be one
+// of the whitelisted fields, optionally prefixed with "-" for descending order.
+func parseUserCriteria(c fiber.Ctx) (*models.UserCriteria, error) {
+	listUsersDTO, err := bindListUsersDTO(c, listUsersParams)
+	if err != nil {
+		return nil, err
+	}
+
+	criteria := &models.UserCriteria{
+		UserFilter: userFilter(listUsersDTO),
+		UserSort: models.UserSort{
+			Field: models.UserSortByID,
+		},
+		Cursor: listUsersDTO.Cursor,
+		Limit:  listUsersDTO.Limit,
+	}
+
+	if listUsersDTO.Sort != "" {
+		criteria.Desc = strings.HasPrefix(listUsersDTO.Sort, "-")
+		criteria.Field = models.UserSortField(strings.TrimPrefix(listUsersDTO.Sort, "-"))
+	}
+
+	return criteria, nil
+}
+
+// parseUserFilter reads the filters of an export query string. The format
+// parameter is left to the caller.
+func parseUserFilter(c fiber.Ctx) (*models.UserFilter, error) {
+	listUsersDTO, err := bindListUsersDTO(c, exportUsersParams)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := userFilter(listUsersDTO)
+	return &filter, nil
+}
+
+func bindListUsersDTO(c fiber.Ctx, allowed map[string]struct{}) (*models.ListUsersDTO, error) {
+	for key := range c.Queries() {
+		if _, ok := allowed[key]; !ok {
+			return nil, invalidParam(key, "unknown", errors.New("unknown query param"))
+		}
+	}
+
+	listUsersDTO := models.ListUsersDTO{}
+	if err := c.Bind().Query(&listUsersDTO); err != nil {
+		return nil, err
+	}
+
+	if err := validation.Struct(listUsersDTO); err != nil {
+		return nil, err
+	}
+
+	if listUsersDTO.AgeMin != nil && listUsersDTO.AgeMax != nil && *listUsersDTO.AgeMin > *listUsersDTO.AgeMax {
+		return nil, &paramError{field: "age_min", rule: "ltefield", param: "age_max", err: errors.New("age_min is greater than age_max")}
+	}
+
+	return &listUsersDTO, nil
+}
+
+func userFilter(listUsersDTO *models.ListUsersDTO) models.UserFilter {
+	return models.UserFilter{
+		Gender:        listUsersDTO.Gender,
+		AgeMin:        listUsersDTO.AgeMin,
+		AgeMax:        listUsersDTO.AgeMax,
+		EmailDomain:   strings.ToLower(listUsersDTO.EmailDomain),
+		CreatedAfter:  parseTime(listUsersDTO.CreatedAfter),
+		CreatedBefore: parseTime(listUsersDTO.CreatedBefore),
+		UpdatedAfter:  parseTime(listUsersDTO.UpdatedAfter),
+		UpdatedBefore: parseTime(listUsersDTO.UpdatedBefore),
+		WithDeleted:   listUsersDTO.WithDeleted,
+	}
+}
+
+// parseTime expects a value that already passed the datetime validation.
+func parseTime(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+
+	return &t
+}
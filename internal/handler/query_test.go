@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/usecase"
+)
+
+// listUC records the criteria the handler passes on.
+type listUC struct {
+	usecase.UserProvider
+	criteria *models.UserCriteria
+}
+
+func (f *listUC) ListUsers(ctx context.Context, criteria *models.UserCriteria) (*models.UserPage, error) {
+	f.criteria = criteria
+	return &models.UserPage{Users: []models.User{}}, nil
+}
+
+func TestListUsersParsesFiltersAndSort(t *testing.T) {
+	uc := &listUC{}
+	app := fiber.New()
+	app.Get("/user", (&Handle{userUC: uc}).ListUsers)
+
+	target := "/user?sort=-age&gender=female&age_min=20&age_max=40&email_domain=Example.COM&created_after=2025-01-01T00:00:00Z&limit=5&cursor=abc"
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, target, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	got := uc.criteria
+	if got.Field != models.UserSortByAge || !got.Desc {
+		t.Errorf("sort = %+v, want age descending", got.UserSort)
+	}
+	if got.Gender != "female" || *got.AgeMin != 20 || *got.AgeMax != 40 || got.EmailDomain != "example.com" {
+		t.Errorf("filter = %+v", got.UserFilter)
+	}
+	if got.CreatedAfter == nil || got.CreatedAfter.Year() != 2025 {
+		t.Errorf("created_after = %v, want 2025-01-01", got.CreatedAfter)
+	}
+	if got.Limit != 5 || got.Cursor != "abc" {
+		t.Errorf("limit and cursor = %d %q, want 5 and abc", got.Limit, got.Cursor)
+	}
+}
+
+func TestListUsersDefaultsToIDOrder(t *testing.T) {
+	uc := &listUC{}
+	app := fiber.New()
+	app.Get("/user", (&Handle{userUC: uc}).ListUsers)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/user", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if uc.criteria.Field != models.UserSortByID || uc.criteria.Desc {
+		t.Errorf("sort = %+v, want id ascending", uc.criteria.UserSort)
+	}
+}
+
+func TestListUsersRejectsUnknownSort(t *testing.T) {
+	uc := &listUC{}
+	app := fiber.New()
+	app.Get("/user", (&Handle{userUC: uc}).ListUsers)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/user?sort=email", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest || uc.criteria != nil {
+		t.Fatalf("status = %d, want %d before the usecase is called", resp.StatusCode, http.StatusBadRequest)
+	}
+}
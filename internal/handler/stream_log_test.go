@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/krackl1n/golang-project/internal/logging"
+	"github.com/pkg/errors"
+)
+
+// syncBuffer collects log output written from the stream writer goroutine.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func captureLogs(t *testing.T) *syncBuffer {
+	t.Helper()
+	logs := &syncBuffer{}
+	previous := slog.Default()
+	slog.SetDefault(slog.New(logging.NewContextHandler(slog.NewJSONHandler(logs, nil))))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return logs
+}
+
+func TestExportUsersLogsStreamErrorWithRequestContext(t *testing.T) {
+	logs := captureLogs(t)
+	h := &Handle{userUC: &fakeUserUC{exportErr: errors.New("cursor closed")}}
+
+	resp, err := newTestApp(h).Test(httptest.NewRequest(fiber.MethodGet, "/user/export?format=ndjson", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatal(err)
+	}
+
+	output := logs.String()
+	if !strings.Contains(output, `"msg":"export users"`) || !strings.Contains(output, `"request_id":"test-request"`) {
+		t.Fatalf("log output = %s, want export error with request id", output)
+	}
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/validation"
+)
+
+func (h *Handle) CreateWebhook(c fiber.Ctx) error {
+	createWebhookDTO := models.CreateWebhookDTO{}
+	if err := c.Bind().Body(&createWebhookDTO); err != nil {
+		return badRequest(c, "invalid request body", err)
+	}
+
+	if err := validation.Struct(createWebhookDTO); err != nil {
+		return badRequest(c, "validate createWebhookDTO", err)
+	}
+
+	subscription, err := h.webhookUC.CreateWebhook(c.Context(), &createWebhookDTO)
+	if err != nil {
+		return respondError(c, "create webhook", err)
+	}
+
+	c.Location(fmt.Sprintf("/webhooks/%s", subscription.ID))
+	return c.Status(http.StatusCreated).JSON(subscription)
+}
+
+func (h *Handle) ListWebhooks(c fiber.Ctx) error {
+	subscriptions, err := h.webhookUC.ListWebhooks(c.Context())
+	if err != nil {
+		return respondError(c, "list webhooks", err)
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"webhooks": subscriptions,
+	})
+}
+
+func (h *Handle) DeleteWebhook(c fiber.Ctx) error {
+	uuidWebhook, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return badRequest(c, "parse id", invalidParam("id", "uuid", err))
+	}
+
+	if err := h.webhookUC.DeleteWebhook(c.Context(), uuidWebhook); err != nil {
+		return respondError(c, "delete webhook", err)
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+func (h *Handle) ListWebhookDeliveries(c fiber.Ctx) error {
+	uuidWebhook, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return badRequest(c, "parse id", invalidParam("id", "uuid", err))
+	}
+
+	deliveriesDTO := models.WebhookDeliveriesDTO{}
+	if err := c.Bind().Query(&deliveriesDTO); err != nil {
+		return badRequest(c, "invalid query params", err)
+	}
+
+	if err := validation.Struct(deliveriesDTO); err != nil {
+		return badRequest(c, "validate webhookDeliveriesDTO", err)
+	}
+
+	page, err := h.webhookUC.ListDeliveries(c.Context(), uuidWebhook, &deliveriesDTO)
+	if err != nil {
+		return respondError(c, "list webhook deliveries", err)
+	}
+
+	return c.Status(http.StatusOK).JSON(page)
+}
+
+func (h *Handle) GetWebhookDelivery(c fiber.Ctx) error {
+	deliveryId, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return badRequest(c, "parse id", invalidParam("id", "integer", err))
+	}
+
+	delivery, err := h.webhookUC.GetDelivery(c.Context(), deliveryId)
+	if err != nil {
+		return respondError(c, "get webhook delivery", err)
+	}
+
+	return c.Status(http.StatusOK).JSON(delivery)
+}
+
+// ReplayWebhookDelivery queues the delivery again as a new log entry.
+func (h *Handle) ReplayWebhookDelivery(c fiber.Ctx) error {
+	deliveryId, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return badRequest(c, "parse id", invalidParam("id", "integer", err))
+	}
+
+	delivery, err := h.webhookUC.ReplayDelivery(c.Context(), deliveryId)
+	if err != nil {
+		return respondError(c, "replay webhook delivery", err)
+	}
+
+	c.Location(fmt.Sprintf("/webhooks/deliveries/%d", delivery.ID))
+	return c.Status(http.StatusAccepted).JSON(delivery)
+}
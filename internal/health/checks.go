@@ -0,0 +1,54 @@
+package health
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+// DatabasePing fails when no connection of the pool reaches Postgres.
+func DatabasePing(pool *pgxpool.Pool) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return errors.Wrap(pool.Ping(ctx), "ping database")
+	}
+}
+
+// MigrationVersion fails while the schema is behind want, the version of
+// the newest migration the service was built with.
+func MigrationVersion(pool *pgxpool.Pool, want int64) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		query := `
+			SELECT coalesce(max(version_id), 0)
+			FROM goose_db_version
+			WHERE is_applied
+		`
+
+		var version int64
+		if err := pool.QueryRow(ctx, query).Scan(&version); err != nil {
+			return errors.Wrap(err, "get migration version")
+		}
+		if version < want {
+			return errors.Errorf("schema at version %d, want %d", version, want)
+		}
+
+		return nil
+	}
+}
+
+// PoolSaturation fails once the share of connections in use reaches limit,
+// so a replica whose requests queue for connections gets no more of them.
+func PoolSaturation(pool *pgxpool.Pool, limit float64) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		stat := pool.Stat()
+		if stat.MaxConns() == 0 {
+			return nil
+		}
+
+		if float64(stat.AcquiredConns())/float64(stat.MaxConns()) >= limit {
+			return errors.Errorf("%d of %d connections in use", stat.AcquiredConns(), stat.MaxConns())
+		}
+
+		return nil
+	}
+}
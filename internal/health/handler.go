@@ -0,0 +1,59 @@
+package health
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/pkg/errors"
+)
+
+// endpoints names the probes as Kubernetes components do.
+var endpoints = map[Probe]string{
+	Liveness:  "livez",
+	Readiness: "readyz",
+	Startup:   "startupz",
+}
+
+// Handler answers probe the way Kubernetes components do: ok, or 503 when
+// a check fails. With ?verbose the status of every check is listed. While
+// draining, readiness fails with a shutdown entry of its own.
+func (r *Registry) Handler(probe Probe) fiber.Handler {
+	name := endpoints[probe]
+	return func(c fiber.Ctx) error {
+		results := r.Run(c.Context(), probe)
+		if probe&Readiness != 0 && r.Draining() {
+			results = append(results, Result{Name: "shutdown", Err: errors.New("shutting down")})
+		}
+
+		healthy := true
+		var report strings.Builder
+		for _, result := range results {
+			if result.Err != nil {
+				healthy = false
+				fmt.Fprintf(&report, "[-]%s failed: %v\n", result.Name, result.Err)
+			} else {
+				fmt.Fprintf(&report, "[+]%s ok\n", result.Name)
+			}
+		}
+
+		c.Set(fiber.HeaderCacheControl, "no-store")
+		c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+		if !healthy {
+			c.Status(fiber.StatusServiceUnavailable)
+		}
+		if !c.Request().URI().QueryArgs().Has("verbose") {
+			if !healthy {
+				return c.SendString(name + " check failed\n")
+			}
+			return c.SendString("ok\n")
+		}
+
+		if healthy {
+			fmt.Fprintf(&report, "%s check passed\n", name)
+		} else {
+			fmt.Fprintf(&report, "%s check failed\n", name)
+		}
+		return c.SendString(report.String())
+	}
+}
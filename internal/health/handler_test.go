@@ -0,0 +1,68 @@
+package health
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/pkg/errors"
+)
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.Register(Check{Name: "process", Probes: Liveness, Run: func(ctx context.Context) error { return nil }})
+	r.Register(Check{Name: "database", Probes: Readiness, Run: func(ctx context.Context) error { return nil }})
+	r.Register(Check{Name: "migrations", Probes: Startup, Run: func(ctx context.Context) error { return errors.New("schema at version 1, want 2") }})
+
+	app := fiber.New()
+	app.Get("/livez", r.Handler(Liveness))
+	app.Get("/readyz", r.Handler(Readiness))
+	app.Get("/startupz", r.Handler(Startup))
+
+	get := func(target string) (int, string) {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, target, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Header.Get(fiber.HeaderCacheControl) != "no-store" {
+			t.Fatalf("%s: Cache-Control = %q, want no-store", target, resp.Header.Get(fiber.HeaderCacheControl))
+		}
+		return resp.StatusCode, string(body)
+	}
+
+	tests := []struct {
+		target string
+		status int
+		body   string
+	}{
+		{target: "/livez", status: fiber.StatusOK, body: "ok\n"},
+		{target: "/livez?verbose", status: fiber.StatusOK, body: "[+]process ok\nlivez check passed\n"},
+		{target: "/startupz", status: fiber.StatusServiceUnavailable, body: "startupz check failed\n"},
+		{
+			target: "/startupz?verbose",
+			status: fiber.StatusServiceUnavailable,
+			body:   "[-]migrations failed: schema at version 1, want 2\nstartupz check failed\n",
+		},
+		{target: "/readyz", status: fiber.StatusOK, body: "ok\n"},
+	}
+	for _, tt := range tests {
+		if status, body := get(tt.target); status != tt.status || body != tt.body {
+			t.Errorf("GET %s = %d %q, want %d %q", tt.target, status, body, tt.status, tt.body)
+		}
+	}
+
+	r.Drain()
+	if status, body := get("/readyz?verbose"); status != fiber.StatusServiceUnavailable || body != "[+]database ok\n[-]shutdown failed: shutting down\nreadyz check failed\n" {
+		t.Fatalf("GET /readyz?verbose while draining = %d %q", status, body)
+	}
+	if status, _ := get("/livez"); status != fiber.StatusOK {
+		t.Fatalf("GET /livez while draining = %d, want liveness unaffected", status)
+	}
+}
@@ -0,0 +1,137 @@
+// Package health answers liveness, readiness and startup probes from a
+// registry of dependency checks.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Probe is a health endpoint. A check can back several, e.g.
+// Readiness | Startup.
+type Probe int
+
+const (
+	// Liveness fails only when the process must be restarted, so it should
+	// not depend on anything outside of it.
+	Liveness Probe = 1 << iota
+	// Readiness fails while the service should not receive traffic.
+	Readiness
+	// Startup fails until the service has finished starting.
+	Startup
+)
+
+const defaultTimeout = time.Second
+
+// Check is a named test of a dependency. Run gets Timeout to finish, and
+// its result is reused for CacheFor, so frequent probes do not turn into
+// load on the dependency.
+type Check struct {
+	Name     string
+	Probes   Probe
+	Timeout  time.Duration
+	CacheFor time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Result is the outcome of a check at CheckedAt.
+type Result struct {
+	Name      string
+	Err       error
+	CheckedAt time.Time
+}
+
+type entry struct {
+	Check
+
+	mu     sync.Mutex
+	result Result
+}
+
+// Registry holds the checks of the service. It is safe for concurrent use.
+type Registry struct {
+	mu       sync.RWMutex
+	entries  []*entry
+	draining atomic.Bool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a check. Checks are reported in the order they were added.
+// A check without a timeout gets defaultTimeout.
+func (r *Registry) Register(check Check) {
+	if check.Timeout <= 0 {
+		check.Timeout = defaultTimeout
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = append(r.entries, &entry{Check: check})
+}
+
+// Drain makes readiness fail from now on, so the service is taken out of
+// rotation before it shuts down.
+func (r *Registry) Drain() {
+	r.draining.Store(true)
+}
+
+// Draining reports whether Drain was called.
+func (r *Registry) Draining() bool {
+	return r.draining.Load()
+}
+
+// Run runs the checks backing probe in parallel and returns their results
+// in registration order.
+func (r *Registry) Run(ctx context.Context, probe Probe) []Result {
+	r.mu.RLock()
+	var entries []*entry
+	for _, e := range r.entries {
+		if e.Probes&probe != 0 {
+			entries = append(entries, e)
+		}
+	}
+	r.mu.RUnlock()
+
+	results := make([]Result, len(entries))
+	var wg sync.WaitGroup
+	for i, e := range entries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = e.run(ctx)
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// run returns the cached result while it is fresh. Concurrent probes wait
+// for the same run instead of starting their own.
+func (e *entry) run(ctx context.Context) Result {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.result.CheckedAt.IsZero() && time.Since(e.result.CheckedAt) < e.CacheFor {
+		return e.result
+	}
+
+	// The result is shared, so a probe that gives up must not fail it.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), e.Timeout)
+	defer cancel()
+
+	err := e.Run(ctx)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = errors.Errorf("timed out after %s", e.Timeout)
+	}
+	e.result = Result{Name: e.Name, Err: err, CheckedAt: time.Now()}
+
+	return e.result
+}
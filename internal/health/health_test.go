@@ -0,0 +1,129 @@
+package health
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestRunPicksTheChecksOfTheProbe(t *testing.T) {
+	r := NewRegistry()
+	ok := func(ctx context.Context) error { return nil }
+	r.Register(Check{Name: "process", Probes: Liveness, Run: ok})
+	r.Register(Check{Name: "database", Probes: Readiness | Startup, Run: ok})
+	r.Register(Check{Name: "migrations", Probes: Startup, Run: func(ctx context.Context) error { return errors.New("behind") }})
+
+	tests := []struct {
+		probe Probe
+		want  []string
+	}{
+		{probe: Liveness, want: []string{"process"}},
+		{probe: Readiness, want: []string{"database"}},
+		{probe: Startup, want: []string{"database", "migrations"}},
+	}
+
+	for _, tt := range tests {
+		results := r.Run(context.Background(), tt.probe)
+		var names []string
+		for _, result := range results {
+			names = append(names, result.Name)
+		}
+		if strings.Join(names, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("probe %d ran %v, want %v", tt.probe, names, tt.want)
+		}
+	}
+
+	results := r.Run(context.Background(), Startup)
+	if results[0].Err != nil || results[1].Err == nil || results[1].CheckedAt.IsZero() {
+		t.Fatalf("results = %+v, want database ok and migrations failed", results)
+	}
+}
+
+func TestRunCachesResults(t *testing.T) {
+	r := NewRegistry()
+	var cachedRuns, uncachedRuns atomic.Int32
+	r.Register(Check{Name: "cached", Probes: Readiness, CacheFor: time.Hour, Run: func(ctx context.Context) error {
+		cachedRuns.Add(1)
+		return nil
+	}})
+	r.Register(Check{Name: "uncached", Probes: Readiness, Run: func(ctx context.Context) error {
+		uncachedRuns.Add(1)
+		return nil
+	}})
+
+	for range 3 {
+		r.Run(context.Background(), Readiness)
+	}
+
+	if cachedRuns.Load() != 1 || uncachedRuns.Load() != 3 {
+		t.Fatalf("cached check ran %d times and uncached %d, want 1 and 3", cachedRuns.Load(), uncachedRuns.Load())
+	}
+}
+
+func TestRunSharesAnOngoingCheck(t *testing.T) {
+	r := NewRegistry()
+	var runs atomic.Int32
+	release := make(chan struct{})
+	r.Register(Check{Name: "slow", Probes: Readiness, CacheFor: time.Hour, Run: func(ctx context.Context) error {
+		runs.Add(1)
+		<-release
+		return nil
+	}})
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Run(context.Background(), Readiness)
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if runs.Load() != 1 {
+		t.Fatalf("check ran %d times, want concurrent probes to wait for one run", runs.Load())
+	}
+}
+
+func TestRunTimesOut(t *testing.T) {
+	r := NewRegistry()
+	r.Register(Check{Name: "hanging", Probes: Readiness, Timeout: 10 * time.Millisecond, Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+
+	results := r.Run(context.Background(), Readiness)
+	if results[0].Err == nil || results[0].Err.Error() != "timed out after 10ms" {
+		t.Fatalf("err = %v, want a timeout", results[0].Err)
+	}
+}
+
+func TestRunOutlivesTheProbe(t *testing.T) {
+	r := NewRegistry()
+	r.Register(Check{Name: "database", Probes: Readiness, Run: func(ctx context.Context) error {
+		return ctx.Err()
+	}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if results := r.Run(ctx, Readiness); results[0].Err != nil {
+		t.Fatalf("err = %v, want the shared check unaffected by a probe that gave up", results[0].Err)
+	}
+}
+
+func TestRegisterDefaultsTheTimeout(t *testing.T) {
+	r := NewRegistry()
+	r.Register(Check{Name: "database", Probes: Readiness, Run: func(ctx context.Context) error { return nil }})
+
+	if got := r.entries[0].Timeout; got != defaultTimeout {
+		t.Fatalf("timeout = %s, want %s", got, defaultTimeout)
+	}
+}
@@ -0,0 +1,68 @@
+package i18n
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/ru"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/gofiber/fiber/v3"
+)
+
+const DefaultLanguage = "en"
+
+// Languages lists the supported languages in order of preference.
+var Languages = []string{"en", "ru"}
+
+var uni = ut.New(en.New(), en.New(), ru.New())
+
+func init() {
+	for lang, messages := range catalog {
+		trans, _ := uni.GetTranslator(lang)
+		for key, text := range messages {
+			if err := trans.Add(key, text, false); err != nil {
+				slog.Error("add translation", slog.String("lang", lang), slog.String("key", key), slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// Translator returns the translator for lang, or the default one when the
+// language is not supported.
+func Translator(lang string) ut.Translator {
+	trans, found := uni.GetTranslator(lang)
+	if !found {
+		return uni.GetFallback()
+	}
+	return trans
+}
+
+// FromContext picks the translator matching the request Accept-Language.
+func FromContext(c fiber.Ctx) ut.Translator {
+	return Translator(c.AcceptsLanguages(Languages...))
+}
+
+// T translates key, falling back to the key itself if it is missing.
+func T(trans ut.Translator, key string, params ...string) string {
+	text, err := trans.T(key, params...)
+	if err != nil {
+		return key
+	}
+	return text
+}
+
+// FromAcceptLanguage picks the translator for an Accept-Language value
+// outside of Fiber, e.g. from gRPC metadata. Quality values are ignored;
+// the first supported language wins.
+func FromAcceptLanguage(header string) ut.Translator {
+	for _, tag := range strings.Split(header, ",") {
+		tag, _, _ = strings.Cut(strings.TrimSpace(tag), ";")
+		base, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if slices.Contains(Languages, base) {
+			return Translator(base)
+		}
+	}
+	return Translator(DefaultLanguage)
+}
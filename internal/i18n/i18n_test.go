@@ -0,0 +1,41 @@
+package i18n
+
+import "testing"
+
+func TestCatalogCoversEveryLanguage(t *testing.T) {
+	for key := range catalog[DefaultLanguage] {
+		for _, lang := range Languages {
+			if _, ok := catalog[lang][key]; !ok {
+				t.Errorf("%s has no %s translation", key, lang)
+			}
+		}
+	}
+}
+
+func TestFromAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{header: "ru-RU,ru;q=0.9", want: "ru"},
+		{header: "de-DE, en;q=0.5", want: "en"},
+		{header: "fr", want: DefaultLanguage},
+		{header: "", want: DefaultLanguage},
+	}
+
+	for _, tt := range tests {
+		if got := FromAcceptLanguage(tt.header).Locale(); got != tt.want {
+			t.Errorf("FromAcceptLanguage(%q) = %s, want %s", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestTFallsBackToKey(t *testing.T) {
+	trans := Translator("ru")
+	if got := T(trans, DetailConflictField, "email"); got != "значение поля email уже занято" {
+		t.Errorf("T() = %q", got)
+	}
+	if got := T(trans, "detail.missing"); got != "detail.missing" {
+		t.Errorf("T() of a missing key = %q, want the key", got)
+	}
+}
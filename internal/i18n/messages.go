@@ -0,0 +1,174 @@
+package i18n
+
+// Message keys of domain errors rendered to clients.
+const (
+	TitleValidation         = "title.validation"
+	TitleNotFound           = "title.not_found"
+	TitleInvalidCursor      = "title.invalid_cursor"
+	TitleMalformedPatch     = "title.malformed_patch"
+	TitleInvalidPatch       = "title.invalid_patch"
+	TitleBatchAborted       = "title.batch_aborted"
+	TitleConflict           = "title.conflict"
+	TitlePreconditionFailed = "title.precondition_failed"
+	TitleUnavailable        = "title.unavailable"
+	TitleUnauthenticated    = "title.unauthenticated"
+	TitleForbidden          = "title.forbidden"
+	TitleRateLimited        = "title.rate_limited"
+	TitleIdempotencyInUse   = "title.idempotency_in_use"
+	TitleIdempotencyReused  = "title.idempotency_reused"
+	TitleInternal           = "title.internal"
+
+	DetailValidation           = "detail.validation"
+	DetailNotFound             = "detail.not_found"
+	DetailInvalidCursor        = "detail.invalid_cursor"
+	DetailBatchAborted         = "detail.batch_aborted"
+	DetailConflict             = "detail.conflict"
+	DetailConflictField        = "detail.conflict_field"
+	DetailInvalidField         = "detail.invalid_field"
+	DetailInvalidUUID          = "detail.invalid_uuid"
+	DetailInvalidInteger       = "detail.invalid_integer"
+	DetailMalformedBody        = "detail.malformed_body"
+	DetailBodyTooLarge         = "detail.body_too_large"
+	DetailWrongType            = "detail.wrong_type"
+	DetailUnknownParam         = "detail.unknown_param"
+	DetailUnknownField         = "detail.unknown_field"
+	DetailOneOf                = "detail.one_of"
+	DetailMinimum              = "detail.minimum"
+	DetailMaximum              = "detail.maximum"
+	DetailMinLength            = "detail.min_length"
+	DetailMaxLength            = "detail.max_length"
+	DetailInvalidFormat        = "detail.invalid_format"
+	DetailUnsupportedMediaType = "detail.unsupported_media_type"
+	DetailRequiredField        = "detail.required_field"
+	DetailFieldGreaterThan     = "detail.field_greater_than"
+	DetailUnsupportedPatch     = "detail.unsupported_patch"
+	DetailMalformedPatch       = "detail.malformed_patch"
+	DetailUnsupportedExport    = "detail.unsupported_export"
+	DetailInvalidPatch         = "detail.invalid_patch"
+	DetailPatchReadOnlyID      = "detail.patch_read_only_id"
+	DetailPatchInvalid         = "detail.patch_invalid"
+	DetailPreconditionFailed   = "detail.precondition_failed"
+	DetailUnavailable          = "detail.unavailable"
+	DetailUnauthenticated      = "detail.unauthenticated"
+	DetailForbidden            = "detail.forbidden"
+	DetailForbiddenPermission  = "detail.forbidden_permission"
+	DetailRateLimited          = "detail.rate_limited"
+	DetailIdempotencyInUse     = "detail.idempotency_in_use"
+	DetailIdempotencyReused    = "detail.idempotency_reused"
+	DetailInternal             = "detail.internal"
+)
+
+var catalog = map[string]map[string]string{
+	"en": {
+		TitleValidation:         "Validation failed",
+		TitleNotFound:           "Resource not found",
+		TitleInvalidCursor:      "Invalid cursor",
+		TitleMalformedPatch:     "Malformed patch",
+		TitleInvalidPatch:       "Patch cannot be applied",
+		TitleBatchAborted:       "Batch aborted",
+		TitleConflict:           "Conflict",
+		TitlePreconditionFailed: "Precondition failed",
+		TitleUnavailable:        "Service unavailable",
+		TitleUnauthenticated:    "Unauthenticated",
+		TitleForbidden:          "Forbidden",
+		TitleRateLimited:        "Too many requests",
+		TitleIdempotencyInUse:   "Request in progress",
+		TitleIdempotencyReused:  "Idempotency key reused",
+		TitleInternal:           "Internal server error",
+
+		DetailValidation:           "one or more fields are invalid",
+		DetailNotFound:             "the requested resource does not exist",
+		DetailInvalidCursor:        "the cursor is malformed or was issued for a different query",
+		DetailBatchAborted:         "the item was not created because another item of the batch failed",
+		DetailConflict:             "the request conflicts with the current state of the resource",
+		DetailConflictField:        "{0} is already taken",
+		DetailInvalidField:         "{0} has an invalid value",
+		DetailInvalidUUID:          "{0} must be a UUID",
+		DetailInvalidInteger:       "{0} must be an integer",
+		DetailMalformedBody:        "the request body is not valid JSON",
+		DetailBodyTooLarge:         "the request body is larger than {0} bytes",
+		DetailWrongType:            "{0} has the wrong type",
+		DetailUnknownParam:         "{0} is not a supported parameter",
+		DetailUnknownField:         "{0} is not a supported field",
+		DetailOneOf:                "{0} must be one of {1}",
+		DetailMinimum:              "{0} must be at least {1}",
+		DetailMaximum:              "{0} must be at most {1}",
+		DetailMinLength:            "{0} must be at least {1} characters long",
+		DetailMaxLength:            "{0} must be at most {1} characters long",
+		DetailInvalidFormat:        "{0} must be a valid {1}",
+		DetailUnsupportedMediaType: "the request body must be {0}",
+		DetailRequiredField:        "{0} is required",
+		DetailFieldGreaterThan:     "{0} must not be greater than {1}",
+		DetailUnsupportedPatch:     "patch must be application/merge-patch+json or application/json-patch+json",
+		DetailMalformedPatch:       "the patch document is not a valid patch",
+		DetailUnsupportedExport:    "export is available as application/json, application/x-ndjson or text/csv",
+		DetailInvalidPatch:         "the patch cannot be applied to the current resource",
+		DetailPatchReadOnlyID:      "id is read-only",
+		DetailPatchInvalid:         "the patched resource is not valid",
+		DetailPreconditionFailed:   "the resource was modified since it was read",
+		DetailUnavailable:          "a dependency is temporarily unavailable, retry later",
+		DetailUnauthenticated:      "valid credentials are required to access this resource",
+		DetailForbidden:            "the credentials do not allow this operation",
+		DetailForbiddenPermission:  "the {0} permission is required",
+		DetailRateLimited:          "the request rate limit was exceeded, retry after the time given in Retry-After",
+		DetailIdempotencyInUse:     "a request with this Idempotency-Key is still being processed, retry later",
+		DetailIdempotencyReused:    "the Idempotency-Key was already used for a different request",
+		DetailInternal:             "an unexpected error occurred",
+	},
+	"ru": {
+		TitleValidation:         "Ошибка валидации",
+		TitleNotFound:           "Ресурс не найден",
+		TitleInvalidCursor:      "Некорректный курсор",
+		TitleMalformedPatch:     "Некорректный патч",
+		TitleInvalidPatch:       "Патч не может быть применён",
+		TitleBatchAborted:       "Пакет отменён",
+		TitleConflict:           "Конфликт",
+		TitlePreconditionFailed: "Предусловие не выполнено",
+		TitleUnavailable:        "Сервис недоступен",
+		TitleUnauthenticated:    "Требуется аутентификация",
+		TitleForbidden:          "Доступ запрещён",
+		TitleRateLimited:        "Слишком много запросов",
+		TitleIdempotencyInUse:   "Запрос выполняется",
+		TitleIdempotencyReused:  "Ключ идемпотентности уже использован",
+		TitleInternal:           "Внутренняя ошибка сервера",
+
+		DetailValidation:           "одно или несколько полей заполнены неверно",
+		DetailNotFound:             "запрошенный ресурс не существует",
+		DetailInvalidCursor:        "курсор повреждён или выдан для другого запроса",
+		DetailBatchAborted:         "элемент не создан, так как другой элемент пакета не прошёл проверку",
+		DetailConflict:             "запрос противоречит текущему состоянию ресурса",
+		DetailConflictField:        "значение поля {0} уже занято",
+		DetailInvalidField:         "поле {0} содержит недопустимое значение",
+		DetailInvalidUUID:          "поле {0} должно быть UUID",
+		DetailInvalidInteger:       "поле {0} должно быть целым числом",
+		DetailMalformedBody:        "тело запроса не является корректным JSON",
+		DetailBodyTooLarge:         "тело запроса больше {0} байт",
+		DetailWrongType:            "поле {0} имеет неверный тип",
+		DetailUnknownParam:         "параметр {0} не поддерживается",
+		DetailUnknownField:         "поле {0} не поддерживается",
+		DetailOneOf:                "поле {0} должно быть одним из значений: {1}",
+		DetailMinimum:              "поле {0} должно быть не меньше {1}",
+		DetailMaximum:              "поле {0} должно быть не больше {1}",
+		DetailMinLength:            "поле {0} должно содержать не меньше {1} символов",
+		DetailMaxLength:            "поле {0} должно содержать не больше {1} символов",
+		DetailInvalidFormat:        "поле {0} должно быть корректным значением формата {1}",
+		DetailUnsupportedMediaType: "тело запроса должно иметь тип {0}",
+		DetailRequiredField:        "поле {0} обязательно",
+		DetailFieldGreaterThan:     "поле {0} не должно быть больше {1}",
+		DetailUnsupportedPatch:     "патч должен иметь тип application/merge-patch+json или application/json-patch+json",
+		DetailMalformedPatch:       "документ патча некорректен",
+		DetailUnsupportedExport:    "экспорт доступен в форматах application/json, application/x-ndjson и text/csv",
+		DetailInvalidPatch:         "патч нельзя применить к текущему состоянию ресурса",
+		DetailPatchReadOnlyID:      "поле id доступно только для чтения",
+		DetailPatchInvalid:         "ресурс после применения патча некорректен",
+		DetailPreconditionFailed:   "ресурс был изменён после того, как его прочитали",
+		DetailUnavailable:          "зависимость временно недоступна, повторите запрос позже",
+		DetailUnauthenticated:      "для доступа к ресурсу нужны действительные учётные данные",
+		DetailForbidden:            "учётные данные не позволяют выполнить эту операцию",
+		DetailForbiddenPermission:  "требуется разрешение {0}",
+		DetailRateLimited:          "превышен лимит запросов, повторите попытку через время из Retry-After",
+		DetailIdempotencyInUse:     "запрос с этим Idempotency-Key ещё выполняется, повторите попытку позже",
+		DetailIdempotencyReused:    "этот Idempotency-Key уже использован для другого запроса",
+		DetailInternal:             "произошла непредвиденная ошибка",
+	},
+}
@@ -0,0 +1,50 @@
+// Package logging ties log records to the request they were written for.
+package logging
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/krackl1n/golang-project/internal/requestctx"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ContextHandler adds the request id, the RPC route or HTTP path, the
+// principal and the span found in the context of a record to it, so every
+// line logged while serving a request can be found by them. Records logged
+// without a request context pass through unchanged.
+type ContextHandler struct {
+	slog.Handler
+}
+
+func NewContextHandler(handler slog.Handler) *ContextHandler {
+	return &ContextHandler{Handler: handler}
+}
+
+func (h *ContextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := requestctx.RequestID(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	if route := requestctx.Route(ctx); route != "" {
+		record.AddAttrs(slog.String("route", route))
+	}
+	if method, path := requestctx.Path(ctx); path != "" {
+		record.AddAttrs(slog.String("method", method), slog.String("path", path))
+	}
+	if principal := requestctx.Principal(ctx); principal != nil {
+		record.AddAttrs(slog.String("principal", principal.Subject))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(slog.String("trace_id", spanContext.TraceID().String()), slog.String("span_id", spanContext.SpanID().String()))
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/requestctx"
+)
+
+func logRecord(t *testing.T, ctx context.Context) map[string]any {
+	t.Helper()
+	var buf bytes.Buffer
+	logger := slog.New(NewContextHandler(slog.NewJSONHandler(&buf, nil))).With(slog.String("component", "test"))
+	logger.InfoContext(ctx, "hello")
+
+	record := map[string]any{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	return record
+}
+
+func TestContextHandlerAddsRequestFields(t *testing.T) {
+	ctx := requestctx.WithRequestID(context.Background(), "req-1")
+	ctx = requestctx.WithRoute(ctx, "/user.v1.UserService/GetUser")
+	ctx = requestctx.WithPrincipal(ctx, &models.Principal{Subject: "user-1"})
+
+	record := logRecord(t, ctx)
+	want := map[string]string{
+		"request_id": "req-1",
+		"route":      "/user.v1.UserService/GetUser",
+		"principal":  "user-1",
+		"component":  "test",
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("%s = %v, want %q", key, record[key], value)
+		}
+	}
+}
+
+func TestContextHandlerAddsHTTPPath(t *testing.T) {
+	ctx := requestctx.WithPath(context.Background(), "GET", "/user/1")
+
+	record := logRecord(t, ctx)
+	if record["method"] != "GET" || record["path"] != "/user/1" {
+		t.Errorf("method, path = %v %v, want GET /user/1", record["method"], record["path"])
+	}
+	if _, ok := record["route"]; ok {
+		t.Error("route is set for an HTTP request")
+	}
+}
+
+func TestContextHandlerWithoutRequest(t *testing.T) {
+	record := logRecord(t, context.Background())
+	for _, key := range []string{"request_id", "route", "method", "path", "principal", "trace_id", "span_id"} {
+		if _, ok := record[key]; ok {
+			t.Errorf("%s is set outside of a request", key)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -8,6 +9,7 @@ import (
 	"github.com/krackl1n/golang-project/config"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
@@ -28,6 +30,38 @@ var (
 		[]string{"method", "endpoint", "status"},
 	)
 
+	GrpcRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "grpc_requests_total",
+			Help: "Total number of gRPC requests",
+		},
+		[]string{"method", "code"},
+	)
+
+	GrpcRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "grpc_request_duration_seconds",
+			Help:    "gRPC request latencies in seconds",
+			Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		},
+		[]string{"method", "code"},
+	)
+
+	RateLimitRejectedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rate_limit_rejected_total",
+			Help: "Total number of requests rejected by a rate limit rule",
+		},
+		[]string{"rule", "method"},
+	)
+
+	RateLimitErrorsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "rate_limit_errors_total",
+			Help: "Total number of requests let through because the rate limit store failed",
+		},
+	)
+
 	CacheHits = prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Name: "cache_hits_total",
@@ -53,6 +87,10 @@ var (
 func MetricsInit(cfg *config.Config) {
 	prometheus.MustRegister(HttpRequestsTotal)
 	prometheus.MustRegister(HttpRequestDuration)
+	prometheus.MustRegister(GrpcRequestsTotal)
+	prometheus.MustRegister(GrpcRequestDuration)
+	prometheus.MustRegister(RateLimitRejectedTotal)
+	prometheus.MustRegister(RateLimitErrorsTotal)
 	prometheus.MustRegister(CacheHits)
 	prometheus.MustRegister(CacheMisses)
 	prometheus.MustRegister(CacheSize)
@@ -60,8 +98,23 @@ func MetricsInit(cfg *config.Config) {
 	go startMetricsServer(cfg.MetricsPort)
 }
 
+// ObserveWithTrace records v, with the trace of ctx as its exemplar when
+// the trace is sampled, so a slow bucket leads to a trace showing why.
+func ObserveWithTrace(ctx context.Context, observer prometheus.Observer, v float64) {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok && spanContext.IsSampled() {
+		exemplarObserver.ObserveWithExemplar(v, prometheus.Labels{"trace_id": spanContext.TraceID().String()})
+		return
+	}
+	observer.Observe(v)
+}
+
 func startMetricsServer(port string) {
-	http.Handle("/metrics", promhttp.Handler())
+	// Exemplars are only exposed in the OpenMetrics format.
+	http.Handle("/metrics", promhttp.InstrumentMetricHandler(
+		prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}),
+	))
 
 	slog.Info(fmt.Sprintf("starting metrics server on port %s", port))
 	if err := http.ListenAndServe(fmt.Sprintf(":%s", port), nil); err != nil {
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/krackl1n/golang-project/config"
+	"github.com/krackl1n/golang-project/internal/auth"
+	"github.com/krackl1n/golang-project/internal/requestctx"
+)
+
+const testSecret = "test-secret"
+
+func newTestAuthenticator(t *testing.T) *auth.Authenticator {
+	t.Helper()
+	verifier, err := auth.NewJWTVerifier(config.ConfigAuth{JWTSecret: testSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return auth.NewAuthenticator(verifier, nil)
+}
+
+func bearer(t *testing.T, scope string) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":   "user-1",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": scope,
+	}).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + token
+}
+
+func TestAuthenticateAndRequireScope(t *testing.T) {
+	app := fiber.New()
+	app.Use(Authenticate(newTestAuthenticator(t)))
+	app.Get("/users", func(c fiber.Ctx) error {
+		return c.SendString(requestctx.Principal(c.Context()).Subject)
+	}, RequireScope("users:read"))
+
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+		challenge     string
+	}{
+		{name: "granted", authorization: bearer(t, "users:read users:write"), status: fiber.StatusOK},
+		{name: "no credentials", status: fiber.StatusUnauthorized, challenge: `Bearer realm="users"`},
+		{
+			name:          "invalid token",
+			authorization: "Bearer not.a.jwt",
+			status:        fiber.StatusUnauthorized,
+			challenge:     `Bearer realm="users", error="invalid_token", error_description="the token is malformed or invalid"`,
+		},
+		{
+			name:          "missing scope",
+			authorization: bearer(t, "users:write"),
+			status:        fiber.StatusForbidden,
+			challenge:     `Bearer realm="users", error="insufficient_scope", scope="users:read"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, "/users", nil)
+			if tt.authorization != "" {
+				req.Header.Set(fiber.HeaderAuthorization, tt.authorization)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if got := resp.Header.Get(fiber.HeaderWWWAuthenticate); got != tt.challenge {
+				t.Fatalf("WWW-Authenticate = %q, want %q", got, tt.challenge)
+			}
+			if tt.status != fiber.StatusOK && !strings.HasPrefix(resp.Header.Get(fiber.HeaderContentType), "application/problem+json") {
+				t.Fatalf("Content-Type = %q, want problem details", resp.Header.Get(fiber.HeaderContentType))
+			}
+		})
+	}
+}
+
+func TestRequireScopeWithoutAuthentication(t *testing.T) {
+	app := fiber.New()
+	app.Get("/users", func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	}, RequireScope("users:read"))
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/users", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusNoContent {
+		t.Fatalf("status = %d, want requests through while authentication is off", resp.StatusCode)
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestBodyLimit(t *testing.T) {
+	const limit = 16
+
+	app := fiber.New(fiber.Config{StreamRequestBody: true, BodyLimit: limit})
+	app.Use(BodyLimit(limit, func(c fiber.Ctx) bool { return c.Path() == "/stream" }))
+	app.Post("/buffered", func(c fiber.Ctx) error {
+		return c.SendString(strconv.Itoa(len(c.Body())))
+	})
+	app.Post("/stream", func(c fiber.Ctx) error {
+		n, err := io.Copy(io.Discard, c.Request().BodyStream())
+		if err != nil {
+			return err
+		}
+		return c.SendString(strconv.FormatInt(n, 10))
+	})
+
+	tests := []struct {
+		name       string
+		path       string
+		size       int
+		chunked    bool
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "within limit", path: "/buffered", size: limit, wantStatus: fiber.StatusOK, wantBody: "16"},
+		{name: "over limit", path: "/buffered", size: limit + 1, wantStatus: fiber.StatusRequestEntityTooLarge},
+		{name: "chunked over limit", path: "/buffered", size: limit * 4, chunked: true, wantStatus: fiber.StatusRequestEntityTooLarge},
+		{name: "chunked within limit", path: "/buffered", size: limit, chunked: true, wantStatus: fiber.StatusOK, wantBody: "16"},
+		{name: "streamed route", path: "/stream", size: limit * 4, wantStatus: fiber.StatusOK, wantBody: "64"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodPost, tt.path, strings.NewReader(strings.Repeat("x", tt.size)))
+			if tt.chunked {
+				req.ContentLength = -1
+				req.TransferEncoding = []string{"chunked"}
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := readBody(t, resp)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.wantStatus, body)
+			}
+			if tt.wantBody != "" && body != tt.wantBody {
+				t.Fatalf("body = %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestBodyLimitDetailFollowsTheRequestLanguage(t *testing.T) {
+	app := fiber.New(fiber.Config{StreamRequestBody: true})
+	app.Use(BodyLimit(4, func(c fiber.Ctx) bool { return false }))
+
+	req := httptest.NewRequest(fiber.MethodPost, "/", strings.NewReader("too large"))
+	req.Header.Set(fiber.HeaderAcceptLanguage, "ru")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if body := readBody(t, resp); !strings.Contains(body, `"detail":"тело запроса больше 4 байт"`) {
+		t.Fatalf("body = %s, want the detail in Russian", body)
+	}
+}
@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/usecase"
+)
+
+// fakeIdempotencyUC keeps records by key. A record without a status is
+// still in flight. Keys are copied, as Fiber reuses the buffers behind
+// header values once the request is done.
+type fakeIdempotencyUC struct {
+	usecase.IdempotencyProvider
+	records map[string]*models.IdempotencyRecord
+}
+
+func (uc *fakeIdempotencyUC) BeginRequest(ctx context.Context, request *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	stored, ok := uc.records[request.Key]
+	switch {
+	case !ok:
+		key := strings.Clone(request.Key)
+		uc.records[key] = &models.IdempotencyRecord{Key: key, Fingerprint: request.Fingerprint}
+		return nil, nil
+	case !bytes.Equal(stored.Fingerprint, request.Fingerprint):
+		return nil, apperr.ErrorIdempotencyKeyReused
+	case stored.StatusCode == 0:
+		return nil, apperr.ErrorIdempotencyKeyInUse
+	default:
+		return stored, nil
+	}
+}
+
+func (uc *fakeIdempotencyUC) CompleteRequest(ctx context.Context, request *models.IdempotencyRecord) error {
+	stored := *request
+	stored.Key = strings.Clone(request.Key)
+	uc.records[stored.Key] = &stored
+	return nil
+}
+
+func (uc *fakeIdempotencyUC) AbandonRequest(ctx context.Context, request *models.IdempotencyRecord) error {
+	delete(uc.records, request.Key)
+	return nil
+}
+
+type idempotencyResponse struct {
+	status   int
+	body     string
+	location string
+	replayed string
+	retry    string
+}
+
+func TestIdempotency(t *testing.T) {
+	uc := &fakeIdempotencyUC{records: map[string]*models.IdempotencyRecord{}}
+	var created, failed int
+
+	app := fiber.New()
+	app.Use(Idempotency(uc))
+	app.Post("/user", func(c fiber.Ctx) error {
+		created++
+		c.Location("/user/1")
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{"created": created})
+	})
+	app.Post("/fail", func(c fiber.Ctx) error {
+		failed++
+		return c.SendStatus(fiber.StatusServiceUnavailable)
+	})
+
+	post := func(path, key, body string) idempotencyResponse {
+		t.Helper()
+		req := httptest.NewRequest(fiber.MethodPost, path, strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		if key != "" {
+			req.Header.Set(HeaderIdempotencyKey, key)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return readIdempotencyResponse(t, resp)
+	}
+
+	first := post("/user", "k1", `{"name":"Ann"}`)
+	if first.status != fiber.StatusCreated || first.replayed != "" {
+		t.Fatalf("first request: %+v", first)
+	}
+
+	retry := post("/user", "k1", `{"name":"Ann"}`)
+	if created != 1 {
+		t.Fatalf("handler ran %d times, want once", created)
+	}
+	if retry.status != first.status || retry.body != first.body || retry.location != "/user/1" || retry.replayed != "true" {
+		t.Fatalf("retry = %+v, want the replayed %+v", retry, first)
+	}
+
+	if reused := post("/user", "k1", `{"name":"Bob"}`); reused.status != fiber.StatusUnprocessableEntity {
+		t.Fatalf("key reused for another body: status %d, want 422", reused.status)
+	}
+
+	uc.records["k2"] = &models.IdempotencyRecord{Key: "k2", Fingerprint: models.IdempotencyFingerprint(fiber.MethodPost, "/user", []byte(`{}`))}
+	if inFlight := post("/user", "k2", `{}`); inFlight.status != fiber.StatusConflict || inFlight.retry != "1" {
+		t.Fatalf("key in flight: %+v, want 409 with Retry-After", inFlight)
+	}
+
+	post("/fail", "k3", `{}`)
+	post("/fail", "k3", `{}`)
+	if failed != 2 {
+		t.Fatalf("failing handler ran %d times, want server errors to be retried", failed)
+	}
+
+	post("/user", "", `{}`)
+	post("/user", "", `{}`)
+	if created != 3 {
+		t.Fatalf("handler ran %d times, want requests without a key to run every time", created)
+	}
+}
+
+func readIdempotencyResponse(t *testing.T, resp *http.Response) idempotencyResponse {
+	t.Helper()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return idempotencyResponse{
+		status:   resp.StatusCode,
+		body:     string(body),
+		location: resp.Header.Get(fiber.HeaderLocation),
+		replayed: resp.Header.Get("Idempotent-Replayed"),
+		retry:    resp.Header.Get(fiber.HeaderRetryAfter),
+	}
+}
@@ -1,11 +1,33 @@
 package middleware
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v3"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/auth"
+	"github.com/krackl1n/golang-project/internal/i18n"
 	"github.com/krackl1n/golang-project/internal/metrics"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/problem"
+	"github.com/krackl1n/golang-project/internal/ratelimit"
+	"github.com/krackl1n/golang-project/internal/requestctx"
+	"github.com/krackl1n/golang-project/internal/tracing"
+	"github.com/krackl1n/golang-project/internal/usecase"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func MetricsMiddleware(c fiber.Ctx) error {
@@ -19,7 +41,317 @@ func MetricsMiddleware(c fiber.Ctx) error {
 	statusCode := strconv.Itoa(c.Response().StatusCode())
 
 	metrics.HttpRequestsTotal.WithLabelValues(method, endpoint, statusCode).Inc()
-	metrics.HttpRequestDuration.WithLabelValues(method, endpoint, statusCode).Observe(duration)
+	metrics.ObserveWithTrace(c.Context(), metrics.HttpRequestDuration.WithLabelValues(method, endpoint, statusCode), duration)
 
 	return err
 }
+
+// Tracing continues the trace of a traceparent header, or starts a new one,
+// with a server span per request, and returns the trace context in the
+// response headers. The span is named after the route once it has matched.
+func Tracing(c fiber.Ctx) error {
+	propagator := otel.GetTextMapPropagator()
+	ctx := propagator.Extract(c.Context(), requestCarrier{c})
+	ctx, span := tracing.Tracer().Start(ctx, c.Method(),
+		trace.WithSpanKind(trace.SpanKindServer),
+		// Fiber reuses the buffer behind Path once the request is done, while
+		// the span is exported later.
+		trace.WithAttributes(semconv.HTTPRequestMethodKey.String(c.Method()), semconv.URLPath(strings.Clone(c.Path()))),
+	)
+	defer span.End()
+	c.SetContext(ctx)
+	propagator.Inject(ctx, responseCarrier{c})
+
+	err := c.Next()
+
+	status := c.Response().StatusCode()
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		status = fiberErr.Code
+	} else if err != nil {
+		status = fiber.StatusInternalServerError
+	}
+	// Without a matching route Route is the last middleware that ran.
+	if status != fiber.StatusNotFound || err == nil {
+		route := c.Route().Path
+		span.SetName(c.Method() + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route))
+	}
+	span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+	if status >= fiber.StatusInternalServerError {
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+
+	return err
+}
+
+// requestCarrier reads trace context from the request headers.
+type requestCarrier struct {
+	c fiber.Ctx
+}
+
+func (rc requestCarrier) Get(key string) string {
+	return rc.c.Get(key)
+}
+
+func (rc requestCarrier) Set(key, value string) {
+	rc.c.Request().Header.Set(key, value)
+}
+
+func (rc requestCarrier) Keys() []string {
+	keys := make([]string, 0, len(rc.c.GetReqHeaders()))
+	for key := range rc.c.GetReqHeaders() {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// responseCarrier writes trace context to the response headers.
+type responseCarrier struct {
+	c fiber.Ctx
+}
+
+func (rc responseCarrier) Get(key string) string {
+	return rc.c.GetRespHeader(key)
+}
+
+func (rc responseCarrier) Set(key, value string) {
+	rc.c.Set(key, value)
+}
+
+func (rc responseCarrier) Keys() []string {
+	keys := make([]string, 0, len(rc.c.GetRespHeaders()))
+	for key := range rc.c.GetRespHeaders() {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// RequestContext passes the X-Request-ID of the request, or a new one when
+// the client sent none, down to the usecase and repository layers and
+// echoes it in the response. The method and path go along, so logs can be
+// tied to the request. They are copied, as Fiber reuses its buffers once
+// the request is done and streamed responses log after that.
+func RequestContext(c fiber.Ctx) error {
+	requestID := requestctx.EnsureRequestID(c.Get(fiber.HeaderXRequestID))
+	c.Set(fiber.HeaderXRequestID, requestID)
+
+	ctx := requestctx.WithRequestID(c.Context(), requestID)
+	c.SetContext(requestctx.WithPath(ctx, strings.Clone(c.Method()), strings.Clone(c.Path())))
+
+	return c.Next()
+}
+
+// BodyLimit answers 413 to requests with a body over limit bytes. Request
+// bodies are streamed app-wide so the import upload can be read as it
+// arrives, which leaves Fiber's own limit unenforced; every other route
+// gets its body buffered here instead. Routes for which streamed returns
+// true read the stream themselves.
+func BodyLimit(limit int, streamed func(c fiber.Ctx) bool) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if streamed(c) {
+			return c.Next()
+		}
+
+		request := c.Request()
+		if request.Header.ContentLength() > limit {
+			return bodyTooLarge(c, limit)
+		}
+		stream := request.BodyStream()
+		if stream == nil {
+			return c.Next()
+		}
+
+		body, err := io.ReadAll(io.LimitReader(stream, int64(limit)+1))
+		if err != nil {
+			return errors.Wrap(err, "read request body")
+		}
+		if len(body) > limit {
+			return bodyTooLarge(c, limit)
+		}
+		request.SetBody(body)
+
+		return c.Next()
+	}
+}
+
+// bodyTooLarge closes the connection after answering, as the rest of the
+// body is never read.
+func bodyTooLarge(c fiber.Ctx, limit int) error {
+	c.RequestCtx().SetConnectionClose()
+	return problem.Write(c, problem.New(fiber.StatusRequestEntityTooLarge, i18n.T(i18n.FromContext(c), i18n.DetailBodyTooLarge, strconv.Itoa(limit))))
+}
+
+// Authenticate lets a request through only with a valid bearer token or
+// API key and puts the principal it belongs to into the request context.
+func Authenticate(authenticator *auth.Authenticator) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		principal, err := authenticator.Authenticate(c.Context(), c.Get(fiber.HeaderAuthorization), c.Get(auth.HeaderAPIKey))
+		if err != nil {
+			if !errors.Is(err, apperr.ErrorUnauthenticated) {
+				slog.ErrorContext(c.Context(), "authenticate", slog.Any("error", err))
+				return problem.Write(c, problem.FromError(err, i18n.FromContext(c)))
+			}
+			slog.DebugContext(c.Context(), "authenticate", slog.Any("error", err))
+			c.Set(fiber.HeaderWWWAuthenticate, authenticator.Challenge(err))
+			return problem.Write(c, problem.FromError(apperr.ErrorUnauthenticated, i18n.FromContext(c)))
+		}
+
+		c.SetContext(requestctx.WithPrincipal(c.Context(), principal))
+		return c.Next()
+	}
+}
+
+// RequireScope rejects callers whose credentials were not granted scope.
+// Requests without a principal pass, as they only occur when
+// authentication is disabled.
+func RequireScope(scope string) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		principal := requestctx.Principal(c.Context())
+		if principal == nil || principal.HasScope(scope) {
+			return c.Next()
+		}
+
+		if c.Get(auth.HeaderAPIKey) == "" {
+			c.Set(fiber.HeaderWWWAuthenticate, fmt.Sprintf(`Bearer realm=%q, error="insufficient_scope", scope=%q`, auth.Realm, scope))
+		}
+		return problem.Write(c, problem.FromError(&apperr.ForbiddenError{Permission: scope}, i18n.FromContext(c)))
+	}
+}
+
+// RateLimitIP takes a token from the bucket of the client address before
+// the credentials of the request are checked, so floods of missing or
+// guessed credentials are throttled before they cost a key lookup each.
+func RateLimitIP(limiter *ratelimit.Limiter) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		rule := limiter.IPRule()
+		if rule == nil {
+			return c.Next()
+		}
+
+		return takeToken(c, limiter, rule, "ip:"+c.IP())
+	}
+}
+
+// RateLimit takes a token from the bucket the client has for the rule
+// matching the request, answering 429 when it is empty. Clients are told
+// apart by principal once authenticated and by IP otherwise.
+func RateLimit(limiter *ratelimit.Limiter) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		rule := limiter.Match(c.Method(), c.Path())
+		if rule == nil {
+			return c.Next()
+		}
+
+		client := "ip:" + c.IP()
+		if principal := requestctx.Principal(c.Context()); principal != nil {
+			client = "principal:" + principal.Subject
+		}
+
+		return takeToken(c, limiter, rule, client)
+	}
+}
+
+// takeToken answers 429 when the bucket of client for rule is empty and
+// passes the request on otherwise. A failing store lets requests through
+// rather than taking the API down with it.
+func takeToken(c fiber.Ctx, limiter *ratelimit.Limiter, rule *ratelimit.Rule, client string) error {
+	decision, err := limiter.Take(c.Context(), rule, client)
+	if err != nil {
+		metrics.RateLimitErrorsTotal.Inc()
+		slog.ErrorContext(c.Context(), "take rate limit token", slog.Any("error", err))
+		return c.Next()
+	}
+
+	c.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d;burst=%d", rule.Limit.Requests, seconds(rule.Limit.Period), rule.Limit.Burst))
+	c.Set("RateLimit-Limit", strconv.Itoa(rule.Limit.Burst))
+	c.Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+	c.Set("RateLimit-Reset", strconv.Itoa(seconds(decision.Reset)))
+	if !decision.Allowed {
+		metrics.RateLimitRejectedTotal.WithLabelValues(rule.Name, c.Method()).Inc()
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(max(seconds(decision.RetryAfter), 1)))
+		return problem.Write(c, problem.FromError(apperr.ErrorRateLimited, i18n.FromContext(c)))
+	}
+
+	return c.Next()
+}
+
+// seconds rounds d up to whole seconds, as the rate limit headers take.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// HeaderIdempotencyKey lets clients retry a write without repeating it.
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// replayedHeaders are the response headers stored for replay along with the
+// status and body.
+var replayedHeaders = []string{fiber.HeaderContentType, fiber.HeaderContentLanguage, fiber.HeaderLocation, fiber.HeaderETag}
+
+// Idempotency runs a write sent with an Idempotency-Key once and answers
+// retries with the same key with the stored response, marked by an
+// Idempotent-Replayed header. A retry arriving while the first request is
+// still running gets 409, and a key reused for a different request 422.
+// Server errors are not stored, so the request can be retried. Requests
+// without the header run as usual.
+func Idempotency(idempotencyUC usecase.IdempotencyProvider) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		key := c.Get(HeaderIdempotencyKey)
+		if key == "" {
+			return c.Next()
+		}
+
+		request := &models.IdempotencyRecord{
+			Key:         key,
+			Fingerprint: models.IdempotencyFingerprint(c.Method(), c.OriginalURL(), c.Body()),
+		}
+		stored, err := idempotencyUC.BeginRequest(c.Context(), request)
+		if err != nil {
+			if errors.Is(err, apperr.ErrorIdempotencyKeyInUse) {
+				c.Set(fiber.HeaderRetryAfter, "1")
+			}
+			p := problem.FromError(err, i18n.FromContext(c))
+			if p.Status >= fiber.StatusInternalServerError {
+				slog.ErrorContext(c.Context(), "begin idempotent request", slog.Any("error", err))
+			}
+			return problem.Write(c, p)
+		}
+		if stored != nil {
+			for name, value := range stored.Headers {
+				c.Set(name, value)
+			}
+			c.Set("Idempotent-Replayed", "true")
+			return c.Status(stored.StatusCode).Send(stored.Body)
+		}
+
+		// The key must be settled even when the client has gone away.
+		ctx := context.WithoutCancel(c.Context())
+		err = c.Next()
+		response := c.Response()
+		if err != nil || response.StatusCode() >= fiber.StatusInternalServerError || response.IsBodyStream() {
+			if abandonErr := idempotencyUC.AbandonRequest(ctx, request); abandonErr != nil {
+				slog.ErrorContext(ctx, "abandon idempotent request", slog.Any("error", abandonErr))
+			}
+			return err
+		}
+
+		request.StatusCode = response.StatusCode()
+		request.Headers = make(map[string]string, len(replayedHeaders))
+		for _, name := range replayedHeaders {
+			if value := response.Header.Peek(name); len(value) > 0 {
+				request.Headers[name] = string(value)
+			}
+		}
+		request.Body = bytes.Clone(response.Body())
+		// The response is already made; a retry finds the key in flight until
+		// the lock times out.
+		if err := idempotencyUC.CompleteRequest(ctx, request); err != nil {
+			slog.ErrorContext(ctx, "complete idempotent request", slog.Any("error", err))
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/krackl1n/golang-project/config"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/ratelimit"
+	"github.com/krackl1n/golang-project/internal/requestctx"
+)
+
+// failingStore stands in for an unreachable rate limit store.
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, limit models.RateLimit) (*models.RateLimitDecision, error) {
+	return nil, errors.New("store down")
+}
+
+func (failingStore) Cleanup(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+// newRateLimitApp limits GET /user/:id to two requests an hour. A
+// X-Subject header authenticates the request as that subject.
+func newRateLimitApp(t *testing.T, store ratelimit.Store) *fiber.App {
+	t.Helper()
+	limiter, err := ratelimit.NewLimiter(config.ConfigRateLimit{Routes: "GET /user/:id=2/1h"}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	app.Use(func(c fiber.Ctx) error {
+		if subject := c.Get("X-Subject"); subject != "" {
+			c.SetContext(requestctx.WithPrincipal(c.Context(), &models.Principal{Subject: subject}))
+		}
+		return c.Next()
+	})
+	app.Use(RateLimit(limiter))
+	app.Get("/user/:id", func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+	app.Get("/health", func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+	return app
+}
+
+func getAs(t *testing.T, app *fiber.App, path, subject string) (int, map[string]string) {
+	t.Helper()
+	req := httptest.NewRequest(fiber.MethodGet, path, nil)
+	if subject != "" {
+		req.Header.Set("X-Subject", subject)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	headers := map[string]string{}
+	for _, name := range []string{"RateLimit-Policy", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", fiber.HeaderRetryAfter} {
+		headers[name] = resp.Header.Get(name)
+	}
+	return resp.StatusCode, headers
+}
+
+func TestRateLimit(t *testing.T) {
+	app := newRateLimitApp(t, ratelimit.NewMemoryStore())
+
+	status, headers := getAs(t, app, "/user/1", "alice")
+	if status != fiber.StatusNoContent || headers["RateLimit-Policy"] != "2;w=3600;burst=2" || headers["RateLimit-Limit"] != "2" || headers["RateLimit-Remaining"] != "1" {
+		t.Fatalf("first request: %d %v", status, headers)
+	}
+
+	// The bucket is per client and rule, not per path.
+	if status, headers = getAs(t, app, "/user/2", "alice"); status != fiber.StatusNoContent || headers["RateLimit-Remaining"] != "0" {
+		t.Fatalf("second request: %d %v", status, headers)
+	}
+
+	status, headers = getAs(t, app, "/user/1", "alice")
+	if status != fiber.StatusTooManyRequests || headers[fiber.HeaderRetryAfter] == "" || headers[fiber.HeaderRetryAfter] == "0" {
+		t.Fatalf("third request: %d %v, want 429 with Retry-After", status, headers)
+	}
+
+	if status, _ = getAs(t, app, "/user/1", "bob"); status != fiber.StatusNoContent {
+		t.Fatalf("other principal: status %d, want a bucket of its own", status)
+	}
+	if status, _ = getAs(t, app, "/user/1", ""); status != fiber.StatusNoContent {
+		t.Fatalf("anonymous client: status %d, want a bucket by IP", status)
+	}
+
+	if status, headers = getAs(t, app, "/health", "alice"); status != fiber.StatusNoContent || headers["RateLimit-Policy"] != "" {
+		t.Fatalf("unlimited route: %d %v, want no rate limit headers", status, headers)
+	}
+}
+
+func TestRateLimitLetsRequestsThroughWhenTheStoreFails(t *testing.T) {
+	app := newRateLimitApp(t, failingStore{})
+
+	for range 3 {
+		if status, _ := getAs(t, app, "/user/1", "alice"); status != fiber.StatusNoContent {
+			t.Fatalf("status = %d, want requests through", status)
+		}
+	}
+}
+
+func TestRateLimitIP(t *testing.T) {
+	limiter, err := ratelimit.NewLimiter(config.ConfigRateLimit{IP: "1/1h"}, ratelimit.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := fiber.New()
+	app.Use(RateLimitIP(limiter))
+	app.Get("/user/:id", func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	for i, want := range []int{fiber.StatusNoContent, fiber.StatusTooManyRequests, fiber.StatusTooManyRequests} {
+		if status, _ := getAs(t, app, "/user/1", "alice"); status != want {
+			t.Fatalf("request %d: status = %d, want %d", i+1, status, want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/internal/requestctx"
+)
+
+func TestRequestContext(t *testing.T) {
+	app := fiber.New()
+	app.Use(RequestContext)
+	app.Get("/user/:id", func(c fiber.Ctx) error {
+		method, path := requestctx.Path(c.Context())
+		return c.SendString(requestctx.RequestID(c.Context()) + " " + method + " " + path)
+	})
+
+	tests := []struct {
+		name      string
+		requestID string
+		keep      bool
+	}{
+		{name: "echoes client id", requestID: "abc-123", keep: true},
+		{name: "generates missing id"},
+		{name: "replaces invalid id", requestID: "a b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, "/user/1", nil)
+			if tt.requestID != "" {
+				req.Header.Set(fiber.HeaderXRequestID, tt.requestID)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := resp.Header.Get(fiber.HeaderXRequestID)
+			if tt.keep && got != tt.requestID {
+				t.Fatalf("X-Request-ID = %q, want %q", got, tt.requestID)
+			}
+			if !tt.keep {
+				if _, err := uuid.Parse(got); err != nil {
+					t.Fatalf("X-Request-ID = %q, want a new UUID", got)
+				}
+			}
+
+			body := readBody(t, resp)
+			if body != got+" GET /user/1" {
+				t.Fatalf("context = %q, want id %q and path GET /user/1", body, got)
+			}
+		})
+	}
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	app := fiber.New()
+	app.Use(Tracing)
+	app.Get("/user/:id", func(c fiber.Ctx) error {
+		if c.Params("id") == "broken" {
+			return c.SendStatus(fiber.StatusInternalServerError)
+		}
+		return c.SendString(trace.SpanContextFromContext(c.Context()).TraceID().String())
+	})
+
+	const (
+		traceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentID    = "00f067aa0ba902b7"
+		traceparent = "00-" + traceID + "-" + parentID + "-01"
+	)
+
+	tests := []struct {
+		name        string
+		path        string
+		traceparent string
+		span        string
+		status      int
+		failed      bool
+	}{
+		{name: "continues the caller trace", path: "/user/1", traceparent: traceparent, span: "GET /user/:id", status: fiber.StatusOK},
+		{name: "starts a trace", path: "/user/1", span: "GET /user/:id", status: fiber.StatusOK},
+		{name: "server error", path: "/user/broken", span: "GET /user/:id", status: fiber.StatusInternalServerError, failed: true},
+		{name: "no route", path: "/missing", span: "GET", status: fiber.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder.Reset()
+			req := httptest.NewRequest(fiber.MethodGet, tt.path, nil)
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("got %d spans, want 1", len(spans))
+			}
+			span := spans[0]
+			if span.Name() != tt.span || span.SpanKind() != trace.SpanKindServer {
+				t.Fatalf("span %q of kind %s, want server span %q", span.Name(), span.SpanKind(), tt.span)
+			}
+			if !slices.Contains(span.Attributes(), semconv.HTTPResponseStatusCode(tt.status)) {
+				t.Fatalf("attributes %v, want status %d", span.Attributes(), tt.status)
+			}
+			if failed := span.Status().Code == codes.Error; failed != tt.failed {
+				t.Fatalf("span status %v, want failed %v", span.Status(), tt.failed)
+			}
+
+			if tt.traceparent != "" {
+				if span.SpanContext().TraceID().String() != traceID || span.Parent().SpanID().String() != parentID || !span.Parent().IsRemote() {
+					t.Fatalf("span %s under %s, want it to continue trace %s", span.SpanContext().TraceID(), span.Parent().SpanID(), traceID)
+				}
+			}
+			want := "00-" + span.SpanContext().TraceID().String() + "-" + span.SpanContext().SpanID().String() + "-01"
+			if got := resp.Header.Get("traceparent"); got != want {
+				t.Fatalf("traceparent = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -1,22 +1,477 @@
 package models
 
 import (
+	"crypto/sha256"
+	"encoding/json"
+	"math"
+	"slices"
+	"time"
+
 	"github.com/google/uuid"
 )
 
 type User struct {
-	ID     uuid.UUID `json:"id" validate:"required,uuid"`
-	Name   string    `json:"name" validate:"required"`
-	Age    uint8     `json:"age" validate:"required,gte=0,lte=120"`
-	Gender string    `json:"gender" validate:"required,oneof=male female"`
-	Email  string    `json:"email" validate:"required,email"`
+	ID        uuid.UUID  `json:"id" validate:"required,uuid"`
+	Name      string     `json:"name" validate:"required"`
+	Age       uint8      `json:"age" validate:"required,gte=0,lte=120"`
+	Gender    string     `json:"gender" validate:"required,oneof=male female"`
+	Email     string     `json:"email" validate:"required,email"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Version   int64      `json:"-"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
 // DTO
 
 type CreateUserDTO struct {
 	Name   string `json:"name" validate:"required"`
-	Age    uint8  `json:"age" validate:"required"`
+	Age    uint8  `json:"age" validate:"required,lte=120"`
 	Gender string `json:"gender" validate:"required,oneof=male female"`
 	Email  string `json:"email" validate:"required,email"`
 }
+
+type GetUserDTO struct {
+	WithDeleted bool   `query:"with_deleted"`
+	AsOf        string `query:"as_of" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+}
+
+type UserHistoryDTO struct {
+	Cursor string `query:"cursor"`
+	Limit  int    `query:"limit" validate:"gte=0"`
+}
+
+type CreateUsersBatchDTO struct {
+	Mode BatchMode `query:"mode" validate:"omitempty,oneof=atomic best_effort"`
+}
+
+type CreateImportJobDTO struct {
+	Format  ImportFormat      `json:"format" validate:"required,oneof=csv ndjson"`
+	Columns map[string]string `json:"columns" validate:"omitempty,dive,keys,required,endkeys,oneof=name age gender email"`
+}
+
+type ExportUsersDTO struct {
+	Format ExportFormat `query:"format" validate:"omitempty,oneof=csv ndjson json"`
+}
+
+type CreateWebhookDTO struct {
+	URL        string   `json:"url" validate:"required,http_url"`
+	EventTypes []string `json:"event_types" validate:"omitempty,dive,oneof=UserCreated UserUpdated UserDeleted"`
+	Secret     string   `json:"secret" validate:"omitempty,min=16,max=256"`
+}
+
+type CreateAPIKeyDTO struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,oneof=users:read users:write users:delete webhooks:manage api_keys:manage"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type WebhookDeliveriesDTO struct {
+	Status string `query:"status" validate:"omitempty,oneof=pending delivered dead"`
+	Cursor string `query:"cursor"`
+	Limit  int    `query:"limit" validate:"gte=0"`
+}
+
+type ListUsersDTO struct {
+	Cursor        string `query:"cursor"`
+	Limit         int    `query:"limit" validate:"gte=0"`
+	Gender        string `query:"gender" validate:"omitempty,oneof=male female"`
+	AgeMin        *uint8 `query:"age_min" validate:"omitempty,lte=120"`
+	AgeMax        *uint8 `query:"age_max" validate:"omitempty,lte=120"`
+	EmailDomain   string `query:"email_domain" validate:"omitempty,fqdn"`
+	CreatedAfter  string `query:"created_after" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	CreatedBefore string `query:"created_before" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	UpdatedAfter  string `query:"updated_after" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	UpdatedBefore string `query:"updated_before" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Sort          string `query:"sort" validate:"omitempty,oneof=name -name age -age created_at -created_at updated_at -updated_at"`
+	WithDeleted   bool   `query:"with_deleted"`
+}
+
+type SearchUsersDTO struct {
+	Query string `query:"q" validate:"required,max=100"`
+	Limit int    `query:"limit" validate:"gte=0"`
+}
+
+type BatchMode string
+
+const (
+	BatchAtomic     BatchMode = "atomic"
+	BatchBestEffort BatchMode = "best_effort"
+)
+
+// BatchItemResult is the outcome of one item of a batch: the created user
+// or the reason it was rejected.
+type BatchItemResult struct {
+	Index int
+	User  *User
+	Err   error
+}
+
+type ExportFormat string
+
+const (
+	ExportCSV    ExportFormat = "csv"
+	ExportNDJSON ExportFormat = "ndjson"
+	ExportJSON   ExportFormat = "json"
+)
+
+type ImportFormat string
+
+const (
+	ImportCSV    ImportFormat = "csv"
+	ImportNDJSON ImportFormat = "ndjson"
+)
+
+type ImportStatus string
+
+const (
+	ImportPending   ImportStatus = "pending"
+	ImportRunning   ImportStatus = "running"
+	ImportCompleted ImportStatus = "completed"
+	ImportFailed    ImportStatus = "failed"
+)
+
+// ImportJob tracks one streamed import of users. Columns maps source
+// column (CSV header or NDJSON key) to a CreateUserDTO json field.
+type ImportJob struct {
+	ID           uuid.UUID         `json:"id"`
+	Format       ImportFormat      `json:"format"`
+	Status       ImportStatus      `json:"status"`
+	Columns      map[string]string `json:"columns"`
+	TotalRows    int64             `json:"total_rows"`
+	ImportedRows int64             `json:"imported_rows"`
+	RejectedRows int64             `json:"rejected_rows"`
+	Error        string            `json:"error,omitempty"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+	StartedAt    *time.Time        `json:"started_at,omitempty"`
+	FinishedAt   *time.Time        `json:"finished_at,omitempty"`
+}
+
+type ImportRowError struct {
+	Line  int64
+	Error string
+	Raw   string
+}
+
+type ImportProgress struct {
+	TotalRows    int64
+	ImportedRows int64
+	RejectedRows int64
+	RowErrors    []ImportRowError
+}
+
+type EventType string
+
+const (
+	UserCreatedEvent EventType = "UserCreated"
+	UserUpdatedEvent EventType = "UserUpdated"
+	UserDeletedEvent EventType = "UserDeleted"
+)
+
+// OutboxEvent is a domain event stored with the write that caused it and
+// later handed to a publisher. Its json form is what publishers deliver.
+type OutboxEvent struct {
+	Seq         int64           `json:"-"`
+	ID          uuid.UUID       `json:"id"`
+	Type        EventType       `json:"type"`
+	AggregateID uuid.UUID       `json:"aggregate_id"`
+	Payload     json.RawMessage `json:"data"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Attempts    int             `json:"-"`
+}
+
+// UserEventPayload is the data of every user event: the user after the
+// write and who made it, when known.
+type UserEventPayload struct {
+	User      *User  `json:"user"`
+	Version   int64  `json:"version"`
+	Actor     string `json:"actor,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// WebhookSubscription sends the events of EventTypes, or of every type when
+// it is empty, to URL. Secret is only shown when the subscription is created.
+type WebhookSubscription struct {
+	ID         uuid.UUID   `json:"id"`
+	URL        string      `json:"url"`
+	EventTypes []EventType `json:"event_types"`
+	Secret     string      `json:"secret,omitempty"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookPending   WebhookDeliveryStatus = "pending"
+	WebhookDelivered WebhookDeliveryStatus = "delivered"
+	// WebhookDead marks a delivery that ran out of attempts.
+	WebhookDead WebhookDeliveryStatus = "dead"
+)
+
+// WebhookDelivery is one event on its way to one subscription, and the
+// delivery log entry for it. URL and Secret are filled only for sending.
+type WebhookDelivery struct {
+	ID             int64                 `json:"id"`
+	SubscriptionID uuid.UUID             `json:"subscription_id"`
+	EventID        uuid.UUID             `json:"event_id"`
+	EventType      EventType             `json:"event_type"`
+	Payload        json.RawMessage       `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at"`
+	LastStatusCode *int                  `json:"last_status_code,omitempty"`
+	LastError      string                `json:"last_error,omitempty"`
+	ReplayOf       *int64                `json:"replay_of,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+	URL            string                `json:"-"`
+	Secret         string                `json:"-"`
+}
+
+type WebhookDeliveryPage struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+type WebhookDeliveryFilter struct {
+	SubscriptionID uuid.UUID
+	Status         WebhookDeliveryStatus
+	Before         int64
+	Limit          int
+}
+
+// WebhookAttempt is the outcome of one try to deliver a webhook.
+// StatusCode is zero when no response arrived.
+type WebhookAttempt struct {
+	StatusCode int
+	Err        error
+}
+
+// Scopes a credential can be granted. Each route requires one of them.
+const (
+	ScopeUsersRead      = "users:read"
+	ScopeUsersWrite     = "users:write"
+	ScopeUsersDelete    = "users:delete"
+	ScopeWebhooksManage = "webhooks:manage"
+	ScopeAPIKeysManage  = "api_keys:manage"
+)
+
+// Roles of the built-in policy. API keys act with RoleService.
+const (
+	RoleUser    = "user"
+	RoleSupport = "support"
+	RoleAdmin   = "admin"
+	RoleService = "service"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Subject string
+	Scopes  []string
+	Roles   []string
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// RateLimit is a token bucket holding up to Burst tokens and refilled with
+// Requests tokens every Period. Each request takes one token.
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// PerSecond is the refill rate of the bucket.
+func (l RateLimit) PerSecond() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Decide describes a bucket left with tokens after a request that did or
+// did not get a token.
+func (l RateLimit) Decide(tokens float64, allowed bool) *RateLimitDecision {
+	decision := &RateLimitDecision{
+		Allowed:   allowed,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(l.Burst) - tokens) / l.PerSecond() * float64(time.Second)),
+	}
+	if !allowed {
+		decision.RetryAfter = time.Duration((1 - tokens) / l.PerSecond() * float64(time.Second))
+	}
+
+	return decision
+}
+
+// RateLimitDecision is the outcome of taking a token. Reset is the time
+// until the bucket is full again, RetryAfter the time until the next token
+// when the request was refused.
+type RateLimitDecision struct {
+	Allowed    bool
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// IdempotencyRecord is a write sent with an Idempotency-Key and, once it has
+// completed, the response replayed to retries of it. StatusCode is zero
+// while the first request is in flight.
+type IdempotencyRecord struct {
+	Owner       string
+	Key         string
+	Fingerprint []byte
+	StatusCode  int
+	Headers     map[string]string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// IdempotencyFingerprint identifies a request by its method, target and
+// body, so a key cannot be replayed for a different request.
+func IdempotencyFingerprint(method, target string, body []byte) []byte {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + target + "\n"))
+	hash.Write(body)
+	return hash.Sum(nil)
+}
+
+// APIKey is a long-lived credential for clients that cannot obtain bearer
+// tokens. Only a hash of the key is stored, so Key is filled once, when the
+// key is issued.
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedBy  string     `json:"created_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	Key        string     `json:"key,omitempty"`
+	SecretHash []byte     `json:"-"`
+}
+
+type UserPage struct {
+	Users      []User `json:"users"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type UserHistoryOperation string
+
+const (
+	UserCreated  UserHistoryOperation = "create"
+	UserUpdated  UserHistoryOperation = "update"
+	UserDeleted  UserHistoryOperation = "delete"
+	UserRestored UserHistoryOperation = "restore"
+	// UserSnapshot is the first entry of users created before history was kept.
+	UserSnapshot UserHistoryOperation = "snapshot"
+)
+
+// UserHistoryEntry is one recorded write of a user with its state before
+// and after it. Before is nil for creations and snapshots.
+type UserHistoryEntry struct {
+	ID        int64                `json:"id"`
+	UserID    uuid.UUID            `json:"user_id"`
+	Operation UserHistoryOperation `json:"operation"`
+	Before    *User                `json:"before"`
+	After     *User                `json:"after"`
+	Version   int64                `json:"version"`
+	Actor     string               `json:"actor,omitempty"`
+	RequestID string               `json:"request_id,omitempty"`
+	ChangedAt time.Time            `json:"changed_at"`
+}
+
+type UserHistoryPage struct {
+	Entries    []UserHistoryEntry `json:"entries"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+type UserSearchResult struct {
+	User
+	Rank       float64           `json:"rank"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+// Criteria
+
+type UserSortField string
+
+const (
+	UserSortByID        UserSortField = "id"
+	UserSortByName      UserSortField = "name"
+	UserSortByAge       UserSortField = "age"
+	UserSortByCreatedAt UserSortField = "created_at"
+	UserSortByUpdatedAt UserSortField = "updated_at"
+)
+
+type UserFilter struct {
+	Gender        string
+	AgeMin        *uint8
+	AgeMax        *uint8
+	EmailDomain   string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	WithDeleted   bool
+}
+
+type UserSort struct {
+	Field UserSortField
+	Desc  bool
+}
+
+type UserCriteria struct {
+	UserFilter
+	UserSort
+	Cursor string
+	Limit  int
+}
+
+type UserPatchType string
+
+const (
+	MergePatch UserPatchType = "application/merge-patch+json"
+	JSONPatch  UserPatchType = "application/json-patch+json"
+)
+
+type UserPatch struct {
+	Type     UserPatchType
+	Document []byte
+	Version  int64
+}
+
+// UserChanges holds only the columns a patch actually changed. Version is
+// the version the row is expected to have; zero skips the check.
+type UserChanges struct {
+	Name    *string
+	Age     *uint8
+	Gender  *string
+	Email   *string
+	Version int64
+}
+
+type UserSearchQuery struct {
+	Text  string
+	Limit int
+}
+
+// Filters
+
+// UserPosition is the keyset position a page starts after: the sort key of
+// the last user on the previous page and its id as a tiebreaker.
+type UserPosition struct {
+	ID    uuid.UUID
+	Value any
+}
+
+type UserListFilter struct {
+	UserFilter
+	UserSort
+	After *UserPosition
+	Limit int
+}
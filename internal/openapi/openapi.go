@@ -0,0 +1,163 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/pkg/errors"
+)
+
+//go:embed openapi.json
+var spec []byte
+
+//go:embed docs.html
+var docs []byte
+
+var methods = []string{
+	fiber.MethodGet, fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch, fiber.MethodDelete,
+}
+
+// SpecHandler serves the OpenAPI document.
+func SpecHandler(c fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	return c.Send(spec)
+}
+
+// DocsHandler serves a page that renders the document from /openapi.json.
+func DocsHandler(c fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Send(docs)
+}
+
+// CheckRoutes compares the routes of the router with the operations of the
+// document and reports every route that is not documented and every
+// operation that no route serves.
+func CheckRoutes(routes []fiber.Route) error {
+	doc, err := parse()
+	if err != nil {
+		return err
+	}
+
+	documented := map[string]struct{}{}
+	for path, item := range doc.Paths {
+		for method := range item {
+			documented[method+" "+path] = struct{}{}
+		}
+	}
+
+	served := map[string]struct{}{}
+	for _, route := range routes {
+		if route.Method == fiber.MethodHead {
+			continue
+		}
+		served[route.Method+" "+templatePath(route.Path)] = struct{}{}
+	}
+
+	undocumented := difference(served, documented)
+	unserved := difference(documented, served)
+	if len(undocumented) == 0 && len(unserved) == 0 {
+		return nil
+	}
+
+	var problems []string
+	if len(undocumented) > 0 {
+		problems = append(problems, fmt.Sprintf("routes missing from the spec: %s", strings.Join(undocumented, ", ")))
+	}
+	if len(unserved) > 0 {
+		problems = append(problems, fmt.Sprintf("operations without a route: %s", strings.Join(unserved, ", ")))
+	}
+	return errors.New(strings.Join(problems, "; "))
+}
+
+type document struct {
+	Paths map[string]map[string]operation
+}
+
+type operation struct {
+	Parameters  []parameter `json:"parameters"`
+	RequestBody *struct {
+		Required bool                       `json:"required"`
+		Content  map[string]json.RawMessage `json:"content"`
+	} `json:"requestBody"`
+}
+
+type parameter struct {
+	Ref      string `json:"$ref"`
+	Name     string `json:"name"`
+	In       string `json:"in"`
+	Required bool   `json:"required"`
+}
+
+// parse reads the operations of the document. Method keys are upper cased
+// to match fiber and the other keys of a path item are dropped.
+func parse() (*document, error) {
+	var raw struct {
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Parameters map[string]parameter `json:"parameters"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(spec, &raw); err != nil {
+		return nil, errors.Wrap(err, "parse openapi document")
+	}
+
+	doc := &document{Paths: make(map[string]map[string]operation, len(raw.Paths))}
+	for path, item := range raw.Paths {
+		doc.Paths[path] = map[string]operation{}
+		for _, method := range methods {
+			data, ok := item[strings.ToLower(method)]
+			if !ok {
+				continue
+			}
+
+			var op operation
+			if err := json.Unmarshal(data, &op); err != nil {
+				return nil, errors.Wrapf(err, "parse operation %s %s", method, path)
+			}
+			for i, param := range op.Parameters {
+				if param.Ref == "" {
+					continue
+				}
+				ref, ok := raw.Components.Parameters[strings.TrimPrefix(param.Ref, "#/components/parameters/")]
+				if !ok {
+					return nil, errors.Errorf("operation %s %s: unknown parameter %s", method, path, param.Ref)
+				}
+				ref.Ref = param.Ref
+				op.Parameters[i] = ref
+			}
+			doc.Paths[path][method] = op
+		}
+	}
+
+	return doc, nil
+}
+
+// templatePath turns a fiber route path into an OpenAPI path template.
+func templatePath(path string) string {
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + strings.TrimSuffix(strings.TrimPrefix(segment, ":"), "?") + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func difference(a, b map[string]struct{}) []string {
+	var diff []string
+	for key := range a {
+		if _, ok := b[key]; !ok {
+			diff = append(diff, key)
+		}
+	}
+	sort.Strings(diff)
+	return diff
+}
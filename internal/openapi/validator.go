@@ -0,0 +1,397 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/gofiber/fiber/v3"
+	"github.com/krackl1n/golang-project/internal/i18n"
+	"github.com/krackl1n/golang-project/internal/problem"
+	"github.com/pkg/errors"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+)
+
+const specURL = "openapi.json"
+
+// Validator checks requests against the parameters and request bodies of
+// the document before they reach the handlers.
+type Validator struct {
+	routes []*route
+}
+
+type route struct {
+	method   string
+	template string
+	pattern  *regexp.Regexp
+	params   []*paramSchema
+	body     map[string]*jsonschema.Schema
+	required bool
+}
+
+type paramSchema struct {
+	name     string
+	in       string
+	required bool
+	schema   *jsonschema.Schema
+}
+
+// NewValidator compiles the schemas of every operation in the document.
+func NewValidator() (*Validator, error) {
+	doc, err := parse()
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := jsonschema.UnmarshalJSON(bytes.NewReader(spec))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse openapi document")
+	}
+	compiler := jsonschema.NewCompiler()
+	compiler.DefaultDraft(jsonschema.Draft2020)
+	compiler.AssertFormat()
+	if err := compiler.AddResource(specURL, raw); err != nil {
+		return nil, errors.Wrap(err, "add openapi document")
+	}
+
+	compile := func(pointer ...string) (*jsonschema.Schema, error) {
+		for i, token := range pointer {
+			pointer[i] = strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+		}
+		return compiler.Compile(specURL + "#/" + strings.Join(pointer, "/"))
+	}
+
+	v := &Validator{}
+	for path, item := range doc.Paths {
+		for method, op := range item {
+			r := &route{
+				method:   method,
+				template: path,
+				pattern:  pathPattern(path),
+			}
+
+			for i, param := range op.Parameters {
+				var schema *jsonschema.Schema
+				if param.Ref != "" {
+					schema, err = compile("components", "parameters", strings.TrimPrefix(param.Ref, "#/components/parameters/"), "schema")
+				} else {
+					schema, err = compile("paths", path, strings.ToLower(method), "parameters", strconv.Itoa(i), "schema")
+				}
+				if err != nil {
+					return nil, errors.Wrapf(err, "compile parameter %s of %s %s", param.Name, method, path)
+				}
+				r.params = append(r.params, &paramSchema{
+					name:     param.Name,
+					in:       param.In,
+					required: param.Required,
+					schema:   schema,
+				})
+			}
+
+			if op.RequestBody != nil {
+				r.required = op.RequestBody.Required
+				r.body = make(map[string]*jsonschema.Schema, len(op.RequestBody.Content))
+				for mediaType := range op.RequestBody.Content {
+					// Other bodies, such as import uploads, are streamed and
+					// only their media type is checked.
+					if !isJSON(mediaType) {
+						r.body[mediaType] = nil
+						continue
+					}
+					schema, err := compile("paths", path, strings.ToLower(method), "requestBody", "content", mediaType, "schema")
+					if err != nil {
+						return nil, errors.Wrapf(err, "compile %s body of %s %s", mediaType, method, path)
+					}
+					r.body[mediaType] = schema
+				}
+			}
+
+			v.routes = append(v.routes, r)
+		}
+	}
+
+	// Literal segments win over parameters, as /user/search over /user/{id}.
+	sort.Slice(v.routes, func(i, j int) bool {
+		return moreSpecific(v.routes[i].template, v.routes[j].template)
+	})
+
+	return v, nil
+}
+
+// Middleware answers requests that do not match their operation with a
+// validation problem. Requests for undocumented paths are passed on.
+func (v *Validator) Middleware(c fiber.Ctx) error {
+	r, pathParams := v.match(c.Method(), c.Path())
+	if r == nil {
+		return c.Next()
+	}
+
+	trans := i18n.FromContext(c)
+	var fieldErrs []problem.FieldError
+	for _, param := range r.params {
+		var value string
+		switch param.in {
+		case "path":
+			value = pathParams[param.name]
+		case "query":
+			value = c.Query(param.name)
+		case "header":
+			value = c.Get(param.name)
+		}
+
+		if value == "" {
+			if param.required {
+				fieldErrs = append(fieldErrs, problem.FieldError{
+					Field:   param.name,
+					Rule:    "required",
+					Message: i18n.T(trans, i18n.DetailRequiredField, param.name),
+				})
+			}
+			continue
+		}
+
+		if err := param.schema.Validate(paramValue(param.schema, value)); err != nil {
+			fieldErrs = appendSchemaErrors(fieldErrs, []string{param.name}, err, trans)
+		}
+	}
+
+	if r.body != nil {
+		if p := validateBody(c, r, &fieldErrs, trans); p != nil {
+			slog.DebugContext(c.Context(), "openapi validation", slog.String("detail", p.Detail))
+			return problem.Write(c, p)
+		}
+	}
+
+	if len(fieldErrs) > 0 {
+		slog.DebugContext(c.Context(), "openapi validation", slog.Any("errors", fieldErrs))
+		return problem.Write(c, problem.Fields(fieldErrs, trans))
+	}
+
+	return c.Next()
+}
+
+// validateBody appends the schema violations of a JSON body to fieldErrs.
+// A body that cannot be checked at all is returned as a problem.
+func validateBody(c fiber.Ctx, r *route, fieldErrs *[]problem.FieldError, trans ut.Translator) *problem.Problem {
+	contentType := c.Get(fiber.HeaderContentType)
+	if contentType == "" && !r.required {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	schema, ok := r.body[mediaType]
+	if err != nil || !ok {
+		mediaTypes := make([]string, 0, len(r.body))
+		for mediaType := range r.body {
+			mediaTypes = append(mediaTypes, mediaType)
+		}
+		sort.Strings(mediaTypes)
+		return problem.New(http.StatusUnsupportedMediaType, i18n.T(trans, i18n.DetailUnsupportedMediaType, strings.Join(mediaTypes, ", ")))
+	}
+	if schema == nil {
+		return nil
+	}
+
+	body, err := jsonschema.UnmarshalJSON(bytes.NewReader(c.Body()))
+	if err != nil {
+		return problem.New(http.StatusBadRequest, i18n.T(trans, i18n.DetailMalformedBody))
+	}
+	if err := schema.Validate(body); err != nil {
+		*fieldErrs = appendSchemaErrors(*fieldErrs, nil, err, trans)
+	}
+
+	return nil
+}
+
+func (v *Validator) match(method, path string) (*route, map[string]string) {
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+
+	for _, r := range v.routes {
+		if r.method != method {
+			continue
+		}
+		matches := r.pattern.FindStringSubmatch(path)
+		if matches == nil {
+			continue
+		}
+
+		params := map[string]string{}
+		for i, name := range r.pattern.SubexpNames() {
+			if name != "" {
+				params[name] = matches[i]
+			}
+		}
+		return r, params
+	}
+
+	return nil, nil
+}
+
+// appendSchemaErrors adds one field error per failed keyword, with a
+// message in the language of trans. Fields are the dotted instance location
+// below prefix.
+func appendSchemaErrors(fieldErrs []problem.FieldError, prefix []string, err error, trans ut.Translator) []problem.FieldError {
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		field := fieldName(prefix, nil)
+		return append(fieldErrs, problem.FieldError{
+			Field:   field,
+			Rule:    "schema",
+			Message: i18n.T(trans, i18n.DetailInvalidField, field),
+		})
+	}
+
+	var walk func(*jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) > 0 {
+			for _, cause := range e.Causes {
+				walk(cause)
+			}
+			return
+		}
+
+		location := append(append([]string{}, prefix...), e.InstanceLocation...)
+		switch k := e.ErrorKind.(type) {
+		case *kind.Required:
+			for _, missing := range k.Missing {
+				field := fieldName(location, []string{missing})
+				fieldErrs = append(fieldErrs, problem.FieldError{
+					Field:   field,
+					Rule:    "required",
+					Message: i18n.T(trans, i18n.DetailRequiredField, field),
+				})
+			}
+			return
+		case *kind.AdditionalProperties:
+			for _, property := range k.Properties {
+				field := fieldName(location, []string{property})
+				fieldErrs = append(fieldErrs, problem.FieldError{
+					Field:   field,
+					Rule:    "additionalProperties",
+					Message: i18n.T(trans, i18n.DetailUnknownField, field),
+				})
+			}
+			return
+		}
+
+		rule := "schema"
+		if keywordPath := e.ErrorKind.KeywordPath(); len(keywordPath) > 0 {
+			rule = keywordPath[len(keywordPath)-1]
+		}
+		field := fieldName(location, nil)
+		fieldErrs = append(fieldErrs, problem.FieldError{
+			Field:   field,
+			Rule:    rule,
+			Message: schemaMessage(trans, field, e.ErrorKind),
+		})
+	}
+	walk(validationErr)
+
+	return fieldErrs
+}
+
+// schemaMessage describes a failed keyword the way the handlers describe
+// validator failures. Keywords without a message of their own get the
+// generic one.
+func schemaMessage(trans ut.Translator, field string, errKind jsonschema.ErrorKind) string {
+	switch k := errKind.(type) {
+	case *kind.Type:
+		return i18n.T(trans, i18n.DetailWrongType, field)
+	case *kind.Enum:
+		values := make([]string, 0, len(k.Want))
+		for _, value := range k.Want {
+			values = append(values, fmt.Sprint(value))
+		}
+		return i18n.T(trans, i18n.DetailOneOf, field, strings.Join(values, ", "))
+	case *kind.Minimum:
+		return i18n.T(trans, i18n.DetailMinimum, field, k.Want.RatString())
+	case *kind.Maximum:
+		return i18n.T(trans, i18n.DetailMaximum, field, k.Want.RatString())
+	case *kind.MinLength:
+		return i18n.T(trans, i18n.DetailMinLength, field, strconv.Itoa(k.Want))
+	case *kind.MaxLength:
+		return i18n.T(trans, i18n.DetailMaxLength, field, strconv.Itoa(k.Want))
+	case *kind.Format:
+		if k.Want == "uuid" {
+			return i18n.T(trans, i18n.DetailInvalidUUID, field)
+		}
+		return i18n.T(trans, i18n.DetailInvalidFormat, field, k.Want)
+	default:
+		return i18n.T(trans, i18n.DetailInvalidField, field)
+	}
+}
+
+func fieldName(location, suffix []string) string {
+	field := strings.Join(append(location, suffix...), ".")
+	if field == "" {
+		return "body"
+	}
+	return field
+}
+
+// paramValue converts a parameter to the JSON type its schema expects.
+// Values that do not convert stay strings and fail the type check.
+func paramValue(schema *jsonschema.Schema, value string) any {
+	for schema.Types == nil && schema.Ref != nil {
+		schema = schema.Ref
+	}
+	if schema.Types == nil {
+		return value
+	}
+
+	for _, typ := range schema.Types.ToStrings() {
+		switch typ {
+		case "integer":
+			if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+				return json.Number(value)
+			}
+		case "number":
+			if _, err := strconv.ParseFloat(value, 64); err == nil {
+				return json.Number(value)
+			}
+		case "boolean":
+			if b, err := strconv.ParseBool(value); err == nil {
+				return b
+			}
+		}
+	}
+	return value
+}
+
+func pathPattern(template string) *regexp.Regexp {
+	segments := strings.Split(template, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			segments[i] = fmt.Sprintf("(?P<%s>[^/]+)", segment[1:len(segment)-1])
+		} else {
+			segments[i] = regexp.QuoteMeta(segment)
+		}
+	}
+	return regexp.MustCompile("^" + strings.Join(segments, "/") + "$")
+}
+
+func moreSpecific(a, b string) bool {
+	segmentsA, segmentsB := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := 0; i < len(segmentsA) && i < len(segmentsB); i++ {
+		paramA, paramB := strings.HasPrefix(segmentsA[i], "{"), strings.HasPrefix(segmentsB[i], "{")
+		if paramA != paramB {
+			return paramB
+		}
+	}
+	return a < b
+}
+
+func isJSON(mediaType string) bool {
+	return mediaType == fiber.MIMEApplicationJSON || strings.HasSuffix(mediaType, "+json")
+}
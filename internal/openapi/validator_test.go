@@ -0,0 +1,176 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/krackl1n/golang-project/internal/problem"
+)
+
+func TestValidatorMiddleware(t *testing.T) {
+	validator, err := NewValidator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	app.Use(validator.Middleware)
+	app.Use(func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		body        string
+		status      int
+		fields      []string
+	}{
+		{
+			name:        "valid body",
+			method:      fiber.MethodPost,
+			target:      "/user",
+			contentType: fiber.MIMEApplicationJSON,
+			body:        `{"name":"Ann","age":30,"gender":"female","email":"ann@example.com"}`,
+			status:      fiber.StatusNoContent,
+		},
+		{
+			name:        "missing and out of range fields",
+			method:      fiber.MethodPost,
+			target:      "/user",
+			contentType: fiber.MIMEApplicationJSON,
+			body:        `{"age":300,"gender":"female","email":"ann@example.com"}`,
+			status:      fiber.StatusBadRequest,
+			fields:      []string{"age:maximum", "name:required"},
+		},
+		{
+			name:        "wrong media type",
+			method:      fiber.MethodPost,
+			target:      "/user",
+			contentType: fiber.MIMETextPlain,
+			body:        `name=Ann`,
+			status:      fiber.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "malformed JSON",
+			method:      fiber.MethodPost,
+			target:      "/user",
+			contentType: fiber.MIMEApplicationJSON,
+			body:        `{"name":`,
+			status:      fiber.StatusBadRequest,
+		},
+		{name: "valid query", method: fiber.MethodGet, target: "/user?limit=10&sort=-age", status: fiber.StatusNoContent},
+		{name: "invalid query", method: fiber.MethodGet, target: "/user?limit=ten&sort=email", status: fiber.StatusBadRequest, fields: []string{"limit:type", "sort:enum"}},
+		{name: "negative limit", method: fiber.MethodGet, target: "/user?limit=-1", status: fiber.StatusBadRequest, fields: []string{"limit:minimum"}},
+		{name: "undocumented path", method: fiber.MethodGet, target: "/metrics", status: fiber.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set(fiber.HeaderContentType, tt.contentType)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if tt.fields == nil {
+				return
+			}
+
+			var p problem.Problem
+			if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+				t.Fatal(err)
+			}
+			var fields []string
+			for _, fieldErr := range p.Errors {
+				fields = append(fields, fieldErr.Field+":"+fieldErr.Rule)
+			}
+			slices.Sort(fields)
+			if strings.Join(fields, ",") != strings.Join(tt.fields, ",") {
+				t.Fatalf("errors = %v, want %v", fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestValidatorMiddlewareFollowsTheRequestLanguage(t *testing.T) {
+	validator, err := NewValidator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	app.Use(validator.Middleware)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		detail      string
+		messages    map[string]string
+	}{
+		{
+			name:        "field errors",
+			contentType: fiber.MIMEApplicationJSON,
+			body:        `{"name":"","age":300,"gender":"other","email":"ann"}`,
+			detail:      "одно или несколько полей заполнены неверно",
+			messages: map[string]string{
+				"name":   "поле name должно содержать не меньше 1 символов",
+				"age":    "поле age должно быть не больше 120",
+				"gender": "поле gender должно быть одним из значений: male, female",
+				"email":  "поле email должно быть корректным значением формата email",
+			},
+		},
+		{
+			name:        "wrong media type",
+			contentType: fiber.MIMETextPlain,
+			body:        `name=Ann`,
+			detail:      "тело запроса должно иметь тип application/json",
+		},
+		{
+			name:        "malformed JSON",
+			contentType: fiber.MIMEApplicationJSON,
+			body:        `{"name":`,
+			detail:      "тело запроса не является корректным JSON",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodPost, "/user", strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, tt.contentType)
+			req.Header.Set(fiber.HeaderAcceptLanguage, "ru")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var p problem.Problem
+			if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+				t.Fatal(err)
+			}
+			if p.Detail != tt.detail {
+				t.Fatalf("detail = %q, want %q", p.Detail, tt.detail)
+			}
+			if len(p.Errors) != len(tt.messages) {
+				t.Fatalf("errors = %+v, want %v", p.Errors, tt.messages)
+			}
+			for _, fieldErr := range p.Errors {
+				if tt.messages[fieldErr.Field] != fieldErr.Message {
+					t.Errorf("%s: message = %q, want %q", fieldErr.Field, fieldErr.Message, tt.messages[fieldErr.Field])
+				}
+			}
+		})
+	}
+}
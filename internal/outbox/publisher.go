@@ -0,0 +1,140 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/krackl1n/golang-project/config"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/pkg/errors"
+)
+
+// Publisher delivers one event to the outside world. An error makes the
+// relay try the event again later, so publishing must be safe to repeat;
+// consumers deduplicate by event id.
+type Publisher interface {
+	Publish(ctx context.Context, event *models.OutboxEvent) error
+}
+
+// NewPublisher builds the publisher selected by OUTBOX_PUBLISHER. The
+// returned function releases what the publisher holds.
+func NewPublisher(cfg config.ConfigOutbox) (Publisher, func() error, error) {
+	noop := func() error { return nil }
+
+	switch cfg.Publisher {
+	case "log":
+		return &LogPublisher{}, noop, nil
+	case "file":
+		f, err := os.OpenFile(cfg.FilePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "open outbox file")
+		}
+		return NewWriterPublisher(f), f.Close, nil
+	case "http":
+		if cfg.HTTPURL == "" {
+			return nil, nil, errors.New("OUTBOX_HTTP_URL is required for the http publisher")
+		}
+		return NewHTTPPublisher(cfg.HTTPURL, &http.Client{Timeout: cfg.HTTPTimeout}), noop, nil
+	default:
+		return nil, nil, errors.Errorf("unknown outbox publisher %q", cfg.Publisher)
+	}
+}
+
+// LogPublisher writes events to the application log.
+type LogPublisher struct{}
+
+func (p *LogPublisher) Publish(_ context.Context, event *models.OutboxEvent) error {
+	slog.Info("event published",
+		slog.String("event_id", event.ID.String()),
+		slog.String("event_type", string(event.Type)),
+		slog.String("aggregate_id", event.AggregateID.String()),
+		slog.String("data", string(event.Payload)),
+	)
+	return nil
+}
+
+// WriterPublisher appends events to w as newline-delimited JSON.
+type WriterPublisher struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterPublisher(w io.Writer) *WriterPublisher {
+	return &WriterPublisher{w: w}
+}
+
+func (p *WriterPublisher) Publish(_ context.Context, event *models.OutboxEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "encode event")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, err := p.w.Write(append(data, '\n')); err != nil {
+		return errors.Wrap(err, "write event")
+	}
+	return nil
+}
+
+// HTTPPublisher POSTs every event as JSON to a fixed URL. Any status other
+// than 2xx counts as a failed delivery.
+type HTTPPublisher struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPPublisher(url string, client *http.Client) *HTTPPublisher {
+	return &HTTPPublisher{url: url, client: client}
+}
+
+func (p *HTTPPublisher) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "encode event")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(data))
+	if err != nil {
+		return errors.Wrap(err, "build request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", event.ID.String())
+	req.Header.Set("X-Event-Type", string(event.Type))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "post event")
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.New(fmt.Sprintf("post event: unexpected status %d", resp.StatusCode))
+	}
+	return nil
+}
+
+// MultiPublisher publishes every event to all of its publishers. When one
+// fails the event is retried for all of them, which their idempotency
+// makes harmless.
+type MultiPublisher []Publisher
+
+func (p MultiPublisher) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	var errs []error
+	for _, publisher := range p {
+		if err := publisher.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return stderrors.Join(errs...)
+}
@@ -0,0 +1,77 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/pkg/errors"
+)
+
+func TestHTTPPublisher(t *testing.T) {
+	event := &models.OutboxEvent{ID: uuid.New(), Type: models.UserUpdatedEvent, AggregateID: uuid.New(), Payload: json.RawMessage(`{"version":2}`)}
+
+	var received *http.Request
+	var body []byte
+	status := http.StatusAccepted
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	publisher := NewHTTPPublisher(server.URL, server.Client())
+
+	if err := publisher.Publish(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	if received.Header.Get("X-Event-ID") != event.ID.String() || received.Header.Get("X-Event-Type") != string(models.UserUpdatedEvent) {
+		t.Fatalf("headers = %v, want the event id and type", received.Header)
+	}
+	var decoded models.OutboxEvent
+	if err := json.Unmarshal(body, &decoded); err != nil || decoded.ID != event.ID || string(decoded.Payload) != `{"version":2}` {
+		t.Fatalf("body = %s, want the event", body)
+	}
+
+	status = http.StatusServiceUnavailable
+	if err := publisher.Publish(context.Background(), event); err == nil {
+		t.Fatal("a 503 counted as published")
+	}
+}
+
+func TestWriterPublisherWritesNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	publisher := NewWriterPublisher(&buf)
+	for i := 0; i < 2; i++ {
+		if err := publisher.Publish(context.Background(), &models.OutboxEvent{ID: uuid.New(), Payload: json.RawMessage(`{}`)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if lines := bytes.Count(buf.Bytes(), []byte("\n")); lines != 2 {
+		t.Fatalf("wrote %d lines, want 2", lines)
+	}
+}
+
+func TestMultiPublisherPublishesToAll(t *testing.T) {
+	var calls int
+	ok := publisherFunc(func(ctx context.Context, event *models.OutboxEvent) error {
+		calls++
+		return nil
+	})
+	failing := publisherFunc(func(ctx context.Context, event *models.OutboxEvent) error {
+		calls++
+		return errors.New("down")
+	})
+
+	err := MultiPublisher{failing, ok}.Publish(context.Background(), &models.OutboxEvent{})
+	if err == nil || calls != 2 {
+		t.Fatalf("err = %v after %d calls, want an error after both publishers ran", err, calls)
+	}
+}
@@ -0,0 +1,114 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/krackl1n/golang-project/config"
+	"github.com/krackl1n/golang-project/internal/repository"
+)
+
+// Relay moves events from the outbox table to a Publisher. Several relays
+// may run against the same database; each event is still published by one
+// of them at a time, unless publishing outlasts the lease.
+type Relay struct {
+	outbox    repository.OutboxProvider
+	publisher Publisher
+	cfg       config.ConfigOutbox
+}
+
+func NewRelay(outbox repository.OutboxProvider, publisher Publisher, cfg config.ConfigOutbox) *Relay {
+	return &Relay{
+		outbox:    outbox,
+		publisher: publisher,
+		cfg:       cfg,
+	}
+}
+
+// Start polls the outbox until the returned function is called. A full
+// batch is followed by the next one right away so a backlog drains quickly.
+func (r *Relay) Start() func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-timer.C:
+				handled, err := r.dispatch(ctx)
+				if err != nil && ctx.Err() == nil {
+					slog.Error("dispatch outbox events", slog.Any("error", err))
+				}
+				if err == nil && handled == r.cfg.BatchSize {
+					timer.Reset(0)
+					continue
+				}
+				timer.Reset(r.cfg.PollInterval)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// dispatch publishes one batch of due events in order and returns how many
+// were claimed. The batch is leased rather than locked, so no transaction
+// stays open while the publisher works. Events still unpublished when the
+// lease runs out are left for the next claim.
+func (r *Relay) dispatch(ctx context.Context) (int, error) {
+	events, err := r.outbox.Claim(ctx, r.cfg.BatchSize, r.cfg.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	leaseCtx, cancel := context.WithTimeout(ctx, r.cfg.Lease)
+	defer cancel()
+
+	delivered := 0
+	for i := range events {
+		if leaseCtx.Err() != nil {
+			break
+		}
+
+		event := &events[i]
+		if err := r.publisher.Publish(leaseCtx, event); err != nil {
+			retryIn := r.retryAfter(event.Attempts + 1)
+			slog.WarnContext(ctx, "publish event failed", slog.String("id", event.ID.String()), slog.Int("attempt", event.Attempts+1),
+				slog.Any("error", err), slog.Duration("retry_in", retryIn))
+			if err := r.outbox.Reschedule(ctx, event.Seq, err, retryIn); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		if err := r.outbox.MarkDelivered(ctx, event.Seq); err != nil {
+			return 0, err
+		}
+		delivered++
+	}
+
+	if len(events) > 0 {
+		slog.DebugContext(ctx, "dispatched outbox events", slog.Int("count", len(events)), slog.Int("delivered", delivered))
+	}
+	return len(events), nil
+}
+
+// retryAfter doubles the delay with every failed attempt, up to RetryMax.
+func (r *Relay) retryAfter(attempts int) time.Duration {
+	delay := r.cfg.RetryBase
+	for i := 1; i < attempts && delay < r.cfg.RetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, r.cfg.RetryMax)
+}
@@ -0,0 +1,129 @@
+package outbox
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/config"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/pkg/errors"
+)
+
+type rescheduled struct {
+	seq     int64
+	retryIn time.Duration
+}
+
+// fakeOutbox hands out its events once and records what became of them.
+type fakeOutbox struct {
+	mu          sync.Mutex
+	events      []models.OutboxEvent
+	lease       time.Duration
+	delivered   []int64
+	rescheduled []rescheduled
+}
+
+func (o *fakeOutbox) Claim(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.lease = lease
+	claimed := o.events[:min(limit, len(o.events))]
+	o.events = o.events[len(claimed):]
+	return claimed, nil
+}
+
+func (o *fakeOutbox) MarkDelivered(ctx context.Context, seq int64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.delivered = append(o.delivered, seq)
+	return nil
+}
+
+func (o *fakeOutbox) Reschedule(ctx context.Context, seq int64, cause error, retryIn time.Duration) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.rescheduled = append(o.rescheduled, rescheduled{seq: seq, retryIn: retryIn})
+	return nil
+}
+
+// publisherFunc adapts a function to a Publisher.
+type publisherFunc func(ctx context.Context, event *models.OutboxEvent) error
+
+func (f publisherFunc) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	return f(ctx, event)
+}
+
+func testEvents(n int) []models.OutboxEvent {
+	events := make([]models.OutboxEvent, n)
+	for i := range events {
+		events[i] = models.OutboxEvent{Seq: int64(i + 1), ID: uuid.New(), Type: models.UserCreatedEvent, Attempts: i}
+	}
+	return events
+}
+
+func testConfig() config.ConfigOutbox {
+	return config.ConfigOutbox{BatchSize: 10, Lease: time.Minute, RetryBase: time.Second, RetryMax: time.Minute}
+}
+
+func TestRelayDispatch(t *testing.T) {
+	outbox := &fakeOutbox{events: testEvents(3)}
+	var published []int64
+	publisher := publisherFunc(func(ctx context.Context, event *models.OutboxEvent) error {
+		published = append(published, event.Seq)
+		if event.Seq == 2 {
+			return errors.New("broker unavailable")
+		}
+		return nil
+	})
+
+	handled, err := NewRelay(outbox, publisher, testConfig()).dispatch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if handled != 3 || outbox.lease != time.Minute {
+		t.Fatalf("handled = %d with lease %s, want 3 with the configured lease", handled, outbox.lease)
+	}
+	if len(published) != 3 || published[0] != 1 || published[1] != 2 || published[2] != 3 {
+		t.Fatalf("published = %v, want events in order", published)
+	}
+	if len(outbox.delivered) != 2 || outbox.delivered[0] != 1 || outbox.delivered[1] != 3 {
+		t.Fatalf("delivered = %v, want 1 and 3", outbox.delivered)
+	}
+	// The second event had one attempt behind it already.
+	if len(outbox.rescheduled) != 1 || outbox.rescheduled[0] != (rescheduled{seq: 2, retryIn: 2 * time.Second}) {
+		t.Fatalf("rescheduled = %+v, want event 2 in 2s", outbox.rescheduled)
+	}
+}
+
+func TestRelayStopsWhenLeaseRunsOut(t *testing.T) {
+	outbox := &fakeOutbox{events: testEvents(3)}
+	cfg := testConfig()
+	cfg.Lease = 50 * time.Millisecond
+	publisher := publisherFunc(func(ctx context.Context, event *models.OutboxEvent) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	if _, err := NewRelay(outbox, publisher, cfg).dispatch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first event timed out; the others are left to the next claim.
+	if len(outbox.rescheduled) != 1 || len(outbox.delivered) != 0 {
+		t.Fatalf("rescheduled = %+v, delivered = %v, want only the first event rescheduled", outbox.rescheduled, outbox.delivered)
+	}
+}
+
+func TestRelayRetryAfter(t *testing.T) {
+	relay := NewRelay(nil, nil, config.ConfigOutbox{RetryBase: time.Second, RetryMax: 10 * time.Second})
+
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 4: 8 * time.Second, 5: 10 * time.Second, 30: 10 * time.Second} {
+		if got := relay.retryAfter(attempts); got != want {
+			t.Errorf("retryAfter(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Package policy decides which principal may perform which action on users,
+// based on the roles of the principal and, for ordinary users, on whether
+// the record is their own.
+package policy
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/pkg/errors"
+)
+
+// Action is an operation on users a role can be granted.
+type Action string
+
+const (
+	ActionRead   Action = "users:read"
+	ActionCreate Action = "users:create"
+	ActionUpdate Action = "users:update"
+	// ActionDelete also covers restoring and purging deleted users.
+	ActionDelete Action = "users:delete"
+)
+
+// ownSuffix restricts a permission to the record of the principal itself,
+// e.g. users:update:own.
+const ownSuffix = ":own"
+
+var actions = []Action{ActionRead, ActionCreate, ActionUpdate, ActionDelete}
+
+// defaultRoles is used when no policy file is configured. Ordinary users
+// manage their own record, support reads everyone, and deleting is left to
+// admins and to service credentials such as API keys.
+var defaultRoles = map[string][]string{
+	models.RoleUser:    {"users:read:own", "users:update:own"},
+	models.RoleSupport: {"users:read"},
+	models.RoleAdmin:   {"users:read", "users:create", "users:update", "users:delete"},
+	models.RoleService: {"users:read", "users:create", "users:update", "users:delete"},
+}
+
+type grant int
+
+const (
+	grantNone grant = iota
+	grantOwn
+	grantAny
+)
+
+// Policy maps every role to the actions it grants.
+type Policy struct {
+	roles map[string]map[Action]grant
+}
+
+// New builds a policy from role names and their permissions. Unknown
+// actions are rejected, so a typo cannot silently take a right away.
+func New(roles map[string][]string) (*Policy, error) {
+	p := &Policy{roles: make(map[string]map[Action]grant, len(roles))}
+	for role, permissions := range roles {
+		grants := make(map[Action]grant, len(permissions))
+		for _, permission := range permissions {
+			name, own := strings.CutSuffix(permission, ownSuffix)
+			action := Action(name)
+			if !slices.Contains(actions, action) {
+				return nil, errors.Errorf("role %s: unknown permission %q", role, permission)
+			}
+
+			g := grantAny
+			if own {
+				g = grantOwn
+			}
+			grants[action] = max(grants[action], g)
+		}
+		p.roles[role] = grants
+	}
+
+	return p, nil
+}
+
+// Default returns the built-in policy.
+func Default() *Policy {
+	p, err := New(defaultRoles)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Load reads a JSON file of the form {"roles": {"support": ["users:read"]}}.
+// Without a path the built-in policy is returned.
+func Load(path string) (*Policy, error) {
+	if path == "" {
+		return Default(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read policy file")
+	}
+
+	var file struct {
+		Roles map[string][]string `json:"roles"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, errors.Wrap(err, "parse policy file")
+	}
+
+	return New(file.Roles)
+}
+
+// Authorize allows action when one of the roles of principal grants it, or
+// grants it for the own record and the subject of principal is the user id
+// owner. owner is uuid.Nil for actions that do not target a single user.
+// Calls without a principal come from inside the service, or reach it while
+// authentication is disabled, and are allowed.
+func (p *Policy) Authorize(principal *models.Principal, action Action, owner uuid.UUID) error {
+	if principal == nil {
+		return nil
+	}
+
+	for _, role := range principal.Roles {
+		switch p.roles[role][action] {
+		case grantAny:
+			return nil
+		case grantOwn:
+			if subject, err := uuid.Parse(principal.Subject); err == nil && owner != uuid.Nil && subject == owner {
+				return nil
+			}
+		}
+	}
+
+	return &apperr.ForbiddenError{Permission: string(action)}
+}
@@ -0,0 +1,113 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/pkg/errors"
+)
+
+func TestAuthorize(t *testing.T) {
+	p := Default()
+	self := uuid.New()
+	other := uuid.New()
+
+	principal := func(roles ...string) *models.Principal {
+		return &models.Principal{Subject: self.String(), Roles: roles}
+	}
+
+	tests := []struct {
+		name      string
+		principal *models.Principal
+		action    Action
+		owner     uuid.UUID
+		allowed   bool
+	}{
+		{name: "no principal", principal: nil, action: ActionDelete, owner: other, allowed: true},
+		{name: "user reads own record", principal: principal(models.RoleUser), action: ActionRead, owner: self, allowed: true},
+		{name: "user updates own record", principal: principal(models.RoleUser), action: ActionUpdate, owner: self, allowed: true},
+		{name: "user reads someone else", principal: principal(models.RoleUser), action: ActionRead, owner: other},
+		{name: "user lists everyone", principal: principal(models.RoleUser), action: ActionRead, owner: uuid.Nil},
+		{name: "user deletes own record", principal: principal(models.RoleUser), action: ActionDelete, owner: self},
+		{name: "user with a non-uuid subject", principal: &models.Principal{Subject: "api", Roles: []string{models.RoleUser}}, action: ActionRead, owner: self},
+		{name: "support reads anyone", principal: principal(models.RoleSupport), action: ActionRead, owner: other, allowed: true},
+		{name: "support updates", principal: principal(models.RoleSupport), action: ActionUpdate, owner: other},
+		{name: "roles add up", principal: principal(models.RoleUser, models.RoleSupport), action: ActionRead, owner: other, allowed: true},
+		{name: "admin deletes", principal: principal(models.RoleAdmin), action: ActionDelete, owner: other, allowed: true},
+		{name: "unknown role", principal: principal("guest"), action: ActionRead, owner: self},
+		{name: "no roles", principal: principal(), action: ActionRead, owner: self},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.Authorize(tt.principal, tt.action, tt.owner)
+			if tt.allowed {
+				if err != nil {
+					t.Fatalf("err = %v, want allowed", err)
+				}
+				return
+			}
+
+			var forbidden *apperr.ForbiddenError
+			if !errors.As(err, &forbidden) || forbidden.Permission != string(tt.action) {
+				t.Fatalf("err = %v, want forbidden for %s", err, tt.action)
+			}
+		})
+	}
+}
+
+func TestNewKeepsTheWidestGrant(t *testing.T) {
+	p, err := New(map[string][]string{"editor": {"users:update:own", "users:update"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	principal := &models.Principal{Subject: uuid.NewString(), Roles: []string{"editor"}}
+	if err := p.Authorize(principal, ActionUpdate, uuid.New()); err != nil {
+		t.Fatalf("err = %v, want users:update to win over users:update:own", err)
+	}
+}
+
+func TestNewRejectsUnknownPermissions(t *testing.T) {
+	for _, permission := range []string{"users:raed", "users:read:all", "webhooks:manage"} {
+		if _, err := New(map[string][]string{"support": {permission}}); err == nil {
+			t.Fatalf("New accepted %q", permission)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	p, err := Load(write("policy.json", `{"roles": {"auditor": ["users:read"]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Authorize(&models.Principal{Roles: []string{"auditor"}}, ActionRead, uuid.New()); err != nil {
+		t.Fatalf("auditor read: %v", err)
+	}
+	if err := p.Authorize(&models.Principal{Roles: []string{models.RoleAdmin}}, ActionRead, uuid.New()); err == nil {
+		t.Fatal("a policy file replaces the built-in roles, want admin forbidden")
+	}
+
+	if p, err := Load(""); err != nil || p == nil {
+		t.Fatalf("Load(\"\") = %v, %v, want the built-in policy", p, err)
+	}
+	if _, err := Load(write("broken.json", `{"roles":`)); err == nil {
+		t.Fatal("broken file: want an error")
+	}
+	if _, err := Load(filepath.Join(dir, "missing.json")); err == nil {
+		t.Fatal("missing file: want an error")
+	}
+}
@@ -0,0 +1,214 @@
+package problem
+
+import (
+	"net/http"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v3"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/i18n"
+	"github.com/pkg/errors"
+)
+
+const ContentType = "application/problem+json"
+
+const (
+	TypeValidation         = "/problems/validation-error"
+	TypeNotFound           = "/problems/not-found"
+	TypeInvalidCursor      = "/problems/invalid-cursor"
+	TypeMalformedPatch     = "/problems/malformed-patch"
+	TypeInvalidPatch       = "/problems/invalid-patch"
+	TypeBatchAborted       = "/problems/batch-aborted"
+	TypeConflict           = "/problems/conflict"
+	TypePreconditionFailed = "/problems/precondition-failed"
+	TypeUnavailable        = "/problems/unavailable"
+	TypeUnauthenticated    = "/problems/unauthenticated"
+	TypeForbidden          = "/problems/forbidden"
+	TypeRateLimited        = "/problems/rate-limited"
+	TypeIdempotencyInUse   = "/problems/idempotency-key-in-use"
+	TypeIdempotencyReused  = "/problems/idempotency-key-reused"
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// New returns a problem without a specific type, titled after the status.
+func New(status int, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// Validation lists validator failures per field, with messages in the
+// language of trans.
+func Validation(errs validator.ValidationErrors, trans ut.Translator) *Problem {
+	p := typed(http.StatusBadRequest, TypeValidation, i18n.T(trans, i18n.TitleValidation), i18n.T(trans, i18n.DetailValidation))
+	p.Errors = make([]FieldError, 0, len(errs))
+
+	for _, fe := range errs {
+		p.Errors = append(p.Errors, FieldError{
+			Field:   fe.Field(),
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: message(fe, trans),
+		})
+	}
+
+	return p
+}
+
+// Fields reports invalid input found outside the validator, such as a
+// request that does not match the OpenAPI document.
+func Fields(errs []FieldError, trans ut.Translator) *Problem {
+	p := typed(http.StatusBadRequest, TypeValidation, i18n.T(trans, i18n.TitleValidation), i18n.T(trans, i18n.DetailValidation))
+	p.Errors = errs
+	return p
+}
+
+// FromError maps an error returned by the usecase layer onto a problem
+// described in the language of trans. Wrap chains never reach the client and
+// unknown errors are reduced to a generic detail.
+func FromError(err error, trans ut.Translator) *Problem {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		p := Validation(validationErrs, trans)
+		if errors.Is(err, apperr.ErrorInvalidPatch) {
+			p.Type, p.Status = TypeInvalidPatch, http.StatusUnprocessableEntity
+		}
+		return p
+	}
+
+	var (
+		conflictErr   *apperr.ConflictError
+		validationErr *apperr.ValidationError
+		forbiddenErr  *apperr.ForbiddenError
+	)
+	switch {
+	case errors.As(err, &validationErr):
+		p := typed(http.StatusBadRequest, TypeValidation, i18n.T(trans, i18n.TitleValidation), i18n.T(trans, i18n.DetailValidation))
+		if validationErr.Field != "" {
+			p.Errors = []FieldError{{
+				Field:   validationErr.Field,
+				Rule:    "constraint",
+				Message: i18n.T(trans, i18n.DetailInvalidField, validationErr.Field),
+			}}
+		}
+		return p
+	case errors.As(err, &conflictErr):
+		detail := i18n.T(trans, i18n.DetailConflict)
+		if conflictErr.Field != "" {
+			detail = i18n.T(trans, i18n.DetailConflictField, conflictErr.Field)
+		}
+		return typed(http.StatusConflict, TypeConflict, i18n.T(trans, i18n.TitleConflict), detail)
+	case errors.As(err, &forbiddenErr):
+		return typed(http.StatusForbidden, TypeForbidden, i18n.T(trans, i18n.TitleForbidden),
+			i18n.T(trans, i18n.DetailForbiddenPermission, forbiddenErr.Permission))
+	case errors.Is(err, apperr.ErrorNotFound):
+		return typed(http.StatusNotFound, TypeNotFound, i18n.T(trans, i18n.TitleNotFound), i18n.T(trans, i18n.DetailNotFound))
+	case errors.Is(err, apperr.ErrorInvalidCursor):
+		return typed(http.StatusBadRequest, TypeInvalidCursor, i18n.T(trans, i18n.TitleInvalidCursor), i18n.T(trans, i18n.DetailInvalidCursor))
+	case errors.Is(err, apperr.ErrorMalformedPatch):
+		return typed(http.StatusBadRequest, TypeMalformedPatch, i18n.T(trans, i18n.TitleMalformedPatch), detail(err, trans, i18n.DetailMalformedPatch))
+	case errors.Is(err, apperr.ErrorInvalidPatch):
+		return typed(http.StatusUnprocessableEntity, TypeInvalidPatch, i18n.T(trans, i18n.TitleInvalidPatch), detail(err, trans, i18n.DetailInvalidPatch))
+	case errors.Is(err, apperr.ErrorValidation):
+		return typed(http.StatusBadRequest, TypeValidation, i18n.T(trans, i18n.TitleValidation), detail(err, trans, i18n.DetailValidation))
+	case errors.Is(err, apperr.ErrorBatchAborted):
+		return typed(http.StatusFailedDependency, TypeBatchAborted, i18n.T(trans, i18n.TitleBatchAborted), i18n.T(trans, i18n.DetailBatchAborted))
+	case errors.Is(err, apperr.ErrorConflict):
+		return typed(http.StatusConflict, TypeConflict, i18n.T(trans, i18n.TitleConflict), i18n.T(trans, i18n.DetailConflict))
+	case errors.Is(err, apperr.ErrorPreconditionFailed):
+		return typed(http.StatusPreconditionFailed, TypePreconditionFailed, i18n.T(trans, i18n.TitlePreconditionFailed),
+			i18n.T(trans, i18n.DetailPreconditionFailed))
+	case errors.Is(err, apperr.ErrorUnavailable):
+		return typed(http.StatusServiceUnavailable, TypeUnavailable, i18n.T(trans, i18n.TitleUnavailable),
+			i18n.T(trans, i18n.DetailUnavailable))
+	case errors.Is(err, apperr.ErrorUnauthenticated):
+		return typed(http.StatusUnauthorized, TypeUnauthenticated, i18n.T(trans, i18n.TitleUnauthenticated),
+			i18n.T(trans, i18n.DetailUnauthenticated))
+	case errors.Is(err, apperr.ErrorForbidden):
+		return typed(http.StatusForbidden, TypeForbidden, i18n.T(trans, i18n.TitleForbidden), i18n.T(trans, i18n.DetailForbidden))
+	case errors.Is(err, apperr.ErrorRateLimited):
+		return typed(http.StatusTooManyRequests, TypeRateLimited, i18n.T(trans, i18n.TitleRateLimited),
+			i18n.T(trans, i18n.DetailRateLimited))
+	case errors.Is(err, apperr.ErrorIdempotencyKeyInUse):
+		return typed(http.StatusConflict, TypeIdempotencyInUse, i18n.T(trans, i18n.TitleIdempotencyInUse),
+			i18n.T(trans, i18n.DetailIdempotencyInUse))
+	case errors.Is(err, apperr.ErrorIdempotencyKeyReused):
+		return typed(http.StatusUnprocessableEntity, TypeIdempotencyReused, i18n.T(trans, i18n.TitleIdempotencyReused),
+			i18n.T(trans, i18n.DetailIdempotencyReused))
+	default:
+		p := New(http.StatusInternalServerError, i18n.T(trans, i18n.DetailInternal))
+		p.Title = i18n.T(trans, i18n.TitleInternal)
+		return p
+	}
+}
+
+// Write sends the problem with the application/problem+json content type.
+func Write(c fiber.Ctx, p *Problem) error {
+	if p.Instance == "" {
+		p.Instance = c.OriginalURL()
+	}
+	if p.Status == http.StatusServiceUnavailable {
+		c.Set(fiber.HeaderRetryAfter, "1")
+	}
+
+	return c.Status(p.Status).JSON(p, ContentType)
+}
+
+func typed(status int, typ, title, detail string) *Problem {
+	return &Problem{
+		Type:   typ,
+		Title:  title,
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// detail translates the explanation attached with apperr.WithDetail, or
+// fallback when there is none.
+func detail(err error, trans ut.Translator, fallback string) string {
+	var detailErr *apperr.DetailError
+	if errors.As(err, &detailErr) {
+		return i18n.T(trans, detailErr.Detail)
+	}
+	return i18n.T(trans, fallback)
+}
+
+// message translates a validator failure. Rules without a registered
+// translation get a generic localized message instead of the Go error text.
+func message(fe validator.FieldError, trans ut.Translator) string {
+	if msg := fe.Translate(trans); msg != fe.Error() {
+		return msg
+	}
+	return i18n.T(trans, i18n.DetailInvalidField, fe.Field())
+}
+
+// ErrorHandler renders errors that escape the handlers, such as unknown
+// routes, as problems instead of plain text.
+func ErrorHandler(c fiber.Ctx, err error) error {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return Write(c, New(fiberErr.Code, fiberErr.Message))
+	}
+
+	return Write(c, FromError(err, i18n.FromContext(c)))
+}
@@ -0,0 +1,154 @@
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/i18n"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/validation"
+	"github.com/pkg/errors"
+)
+
+func TestFromError(t *testing.T) {
+	trans := i18n.Translator("en")
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		typ    string
+		detail string
+	}{
+		{
+			name: "not found", err: errors.Wrap(apperr.ErrorNotFound, "userUC get by Id"),
+			status: http.StatusNotFound, typ: TypeNotFound, detail: "the requested resource does not exist",
+		},
+		{
+			name: "conflict on a field", err: errors.Wrap(&apperr.ConflictError{Field: "email", Reason: "already exists"}, "create user"),
+			status: http.StatusConflict, typ: TypeConflict, detail: "email is already taken",
+		},
+		{
+			name: "forbidden", err: &apperr.ForbiddenError{Permission: "users:delete"},
+			status: http.StatusForbidden, typ: TypeForbidden, detail: "the users:delete permission is required",
+		},
+		{
+			name: "malformed patch with detail", err: apperr.WithDetail(apperr.ErrorMalformedPatch, i18n.DetailMalformedPatch, errors.New("unexpected end of JSON input")),
+			status: http.StatusBadRequest, typ: TypeMalformedPatch, detail: "the patch document is not a valid patch",
+		},
+		{
+			name: "invalid patch without detail", err: errors.Wrap(apperr.ErrorInvalidPatch, "patch user"),
+			status: http.StatusUnprocessableEntity, typ: TypeInvalidPatch, detail: "the patch cannot be applied to the current resource",
+		},
+		{
+			name: "precondition failed", err: apperr.ErrorPreconditionFailed,
+			status: http.StatusPreconditionFailed, typ: TypePreconditionFailed, detail: "the resource was modified since it was read",
+		},
+		{
+			name: "unknown", err: errors.Wrap(errors.New("pq: password authentication failed for user app"), "get user"),
+			status: http.StatusInternalServerError, typ: "about:blank", detail: "an unexpected error occurred",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := FromError(tt.err, trans)
+			if p.Status != tt.status || p.Type != tt.typ || p.Detail != tt.detail {
+				t.Fatalf("FromError() = %+v, want %d %s %q", p, tt.status, tt.typ, tt.detail)
+			}
+		})
+	}
+}
+
+func TestValidationNamesJSONFields(t *testing.T) {
+	err := validation.Struct(models.CreateUserDTO{Name: "Ann", Age: 30, Gender: "other", Email: "ann"})
+
+	p := FromError(errors.Wrap(err, "validate"), i18n.Translator("en"))
+	if p.Status != http.StatusBadRequest || p.Type != TypeValidation {
+		t.Fatalf("got %d %s, want a validation problem", p.Status, p.Type)
+	}
+
+	want := map[string]string{"gender": "oneof", "email": "email"}
+	if len(p.Errors) != len(want) {
+		t.Fatalf("errors = %+v, want %v", p.Errors, want)
+	}
+	for _, fe := range p.Errors {
+		if want[fe.Field] != fe.Rule || fe.Message == "" {
+			t.Errorf("unexpected field error %+v", fe)
+		}
+	}
+}
+
+func TestInvalidPatchValidationIsUnprocessable(t *testing.T) {
+	err := validation.Struct(models.CreateUserDTO{})
+	p := FromError(apperr.WithDetail(apperr.ErrorInvalidPatch, i18n.DetailPatchInvalid, err), i18n.Translator("en"))
+
+	if p.Status != http.StatusUnprocessableEntity || p.Type != TypeInvalidPatch || len(p.Errors) == 0 {
+		t.Fatalf("got %+v, want an invalid patch problem listing the fields", p)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Get("/unavailable", func(c fiber.Ctx) error {
+		return Write(c, FromError(apperr.ErrorUnavailable, i18n.FromContext(c)))
+	})
+
+	tests := []struct {
+		target     string
+		status     int
+		retryAfter string
+	}{
+		{target: "/unavailable?x=1", status: http.StatusServiceUnavailable, retryAfter: "1"},
+		{target: "/missing", status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, tt.target, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var p Problem
+		if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != tt.status || p.Status != tt.status {
+			t.Errorf("%s: status = %d in the body %d, want %d", tt.target, resp.StatusCode, p.Status, tt.status)
+		}
+		if ct := resp.Header.Get(fiber.HeaderContentType); !strings.HasPrefix(ct, ContentType) {
+			t.Errorf("%s: content type = %q, want %q", tt.target, ct, ContentType)
+		}
+		if p.Instance != tt.target {
+			t.Errorf("%s: instance = %q", tt.target, p.Instance)
+		}
+		if got := resp.Header.Get(fiber.HeaderRetryAfter); got != tt.retryAfter {
+			t.Errorf("%s: Retry-After = %q, want %q", tt.target, got, tt.retryAfter)
+		}
+	}
+}
+
+func TestFromErrorIsLocalized(t *testing.T) {
+	trans := i18n.FromAcceptLanguage("ru-RU,ru;q=0.9")
+
+	p := FromError(&apperr.ConflictError{Field: "email"}, trans)
+	if p.Title != "Конфликт" || p.Detail != "значение поля email уже занято" {
+		t.Errorf("conflict = %q %q, want it in Russian", p.Title, p.Detail)
+	}
+
+	err := validation.Struct(models.CreateUserDTO{Name: "Ann", Age: 30, Gender: "female", Email: "ann"})
+	p = FromError(err, trans)
+	if p.Title != "Ошибка валидации" || len(p.Errors) != 1 || p.Errors[0].Field != "email" {
+		t.Fatalf("validation = %+v, want one Russian field error for email", p)
+	}
+	if en := FromError(err, i18n.Translator("en")); p.Errors[0].Message == en.Errors[0].Message {
+		t.Errorf("message %q is not translated", p.Errors[0].Message)
+	}
+}
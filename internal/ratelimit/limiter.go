@@ -0,0 +1,260 @@
+// Package ratelimit hands out per-client token buckets for HTTP routes.
+// Rules pick the bucket size by method and route, and the buckets live in
+// a Store: in process for a single replica, in Postgres for several.
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/krackl1n/golang-project/config"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/pkg/errors"
+)
+
+// Store keeps the token buckets. Take refills the bucket of key and takes a
+// token from it when one is left. Cleanup forgets buckets that are full, as
+// a missing bucket starts out full.
+type Store interface {
+	Take(ctx context.Context, key string, limit models.RateLimit) (*models.RateLimitDecision, error)
+	Cleanup(ctx context.Context) (int64, error)
+}
+
+// Rule limits the requests matching Method, or any method when it is empty,
+// and Pattern, a route path such as /user/:id.
+type Rule struct {
+	Name    string
+	Method  string
+	Pattern string
+	Limit   models.RateLimit
+
+	segments []string
+}
+
+// Limiter matches requests to rules and takes their tokens from the store.
+type Limiter struct {
+	rules           []Rule
+	fallback        *Rule
+	ip              *Rule
+	store           Store
+	cleanupInterval time.Duration
+}
+
+// NewLimiter parses the limits of cfg. Requests matching no route rule fall
+// back to the default limit, or are not limited when there is none. The IP
+// limit is kept apart from the route rules.
+func NewLimiter(cfg config.ConfigRateLimit, store Store) (*Limiter, error) {
+	rules, err := ParseRules(cfg.Routes)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse rate limit routes")
+	}
+
+	limiter := &Limiter{
+		rules:           rules,
+		store:           store,
+		cleanupInterval: cfg.CleanupInterval,
+	}
+	if cfg.Default != "" {
+		limit, err := ParseLimit(cfg.Default)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse default rate limit")
+		}
+		limiter.fallback = &Rule{Name: "default", Limit: limit}
+	}
+	if cfg.IP != "" {
+		limit, err := ParseLimit(cfg.IP)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse IP rate limit")
+		}
+		limiter.ip = &Rule{Name: "ip", Limit: limit}
+	}
+
+	return limiter, nil
+}
+
+// Match returns the most specific rule for a request, or nil when the
+// request is not limited. As with Fiber routes, a literal segment wins over
+// a parameter, so /user/search does not fall under a rule for /user/:id
+// when it has one of its own.
+func (l *Limiter) Match(method, path string) *Rule {
+	segments := splitPath(path)
+	for i := range l.rules {
+		if l.rules[i].matches(method, segments) {
+			return &l.rules[i]
+		}
+	}
+	return l.fallback
+}
+
+// IPRule returns the rule every request counts against by client address
+// before it is authenticated, or nil when there is none.
+func (l *Limiter) IPRule() *Rule {
+	return l.ip
+}
+
+// Take takes a token for client from the bucket it has for rule.
+func (l *Limiter) Take(ctx context.Context, rule *Rule, client string) (*models.RateLimitDecision, error) {
+	return l.store.Take(ctx, rule.Name+"|"+client, rule.Limit)
+}
+
+// Start periodically drops full buckets from the store. The returned
+// function stops it.
+func (l *Limiter) Start() func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(l.cleanupInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				removed, err := l.store.Cleanup(ctx)
+				if err != nil && ctx.Err() == nil {
+					slog.Error("clean up rate limit buckets", slog.Any("error", err))
+					continue
+				}
+				slog.Debug("cleaned up rate limit buckets", slog.Int64("count", removed))
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// ParseLimit reads <requests>/<period>[:<burst>], e.g. 20/1s:40 or
+// 10000/24h for a daily quota. The burst defaults to requests, and a bare
+// unit such as s stands for 1s.
+func ParseLimit(s string) (models.RateLimit, error) {
+	spec, burst, hasBurst := strings.Cut(strings.TrimSpace(s), ":")
+	requests, period, ok := strings.Cut(spec, "/")
+	if !ok {
+		return models.RateLimit{}, errors.Errorf("limit %q is not <requests>/<period>", s)
+	}
+
+	var (
+		limit models.RateLimit
+		err   error
+	)
+	if limit.Requests, err = strconv.Atoi(requests); err != nil || limit.Requests < 1 {
+		return models.RateLimit{}, errors.Errorf("limit %q: requests must be a positive integer", s)
+	}
+	if period != "" && !strings.ContainsAny(period[:1], "0123456789") {
+		period = "1" + period
+	}
+	if limit.Period, err = time.ParseDuration(period); err != nil || limit.Period <= 0 {
+		return models.RateLimit{}, errors.Errorf("limit %q: period must be a positive duration", s)
+	}
+	limit.Burst = limit.Requests
+	if hasBurst {
+		if limit.Burst, err = strconv.Atoi(burst); err != nil || limit.Burst < 1 {
+			return models.RateLimit{}, errors.Errorf("limit %q: burst must be a positive integer", s)
+		}
+	}
+
+	return limit, nil
+}
+
+// ParseRules reads rules of the form "<METHOD> <path>=<limit>" separated
+// by semicolons. The method may be left out or be * to match any.
+func ParseRules(s string) ([]Rule, error) {
+	var rules []Rule
+	for _, entry := range strings.Split(s, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		route, spec, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, errors.Errorf("rule %q has no limit", entry)
+		}
+		limit, err := ParseLimit(spec)
+		if err != nil {
+			return nil, err
+		}
+
+		rule := Rule{Limit: limit}
+		fields := strings.Fields(route)
+		switch len(fields) {
+		case 1:
+			rule.Pattern = fields[0]
+		case 2:
+			rule.Method, rule.Pattern = strings.ToUpper(fields[0]), fields[1]
+		default:
+			return nil, errors.Errorf("rule %q is not [<METHOD>] <path>=<limit>", entry)
+		}
+		if rule.Method == "*" {
+			rule.Method = ""
+		}
+		if !strings.HasPrefix(rule.Pattern, "/") {
+			return nil, errors.Errorf("rule %q: path must start with /", entry)
+		}
+
+		rule.Name = strings.TrimSpace(rule.Method + " " + rule.Pattern)
+		rule.segments = splitPath(rule.Pattern)
+		rules = append(rules, rule)
+	}
+
+	slices.SortStableFunc(rules, func(a, b Rule) int {
+		return slices.Compare(b.specificity(), a.specificity())
+	})
+
+	return rules, nil
+}
+
+func (r *Rule) matches(method string, segments []string) bool {
+	if r.Method != "" && r.Method != method {
+		return false
+	}
+
+	for i, segment := range r.segments {
+		if segment == "*" {
+			return true
+		}
+		if i >= len(segments) {
+			return false
+		}
+		if !strings.HasPrefix(segment, ":") && !strings.EqualFold(segment, segments[i]) {
+			return false
+		}
+	}
+
+	return len(segments) == len(r.segments)
+}
+
+// specificity ranks the segments of a rule, literal over parameter over
+// wildcard, followed by whether it names a method.
+func (r *Rule) specificity() []int {
+	ranks := make([]int, 0, len(r.segments)+1)
+	for _, segment := range r.segments {
+		switch {
+		case segment == "*":
+			ranks = append(ranks, 0)
+		case strings.HasPrefix(segment, ":"):
+			ranks = append(ranks, 1)
+		default:
+			ranks = append(ranks, 2)
+		}
+	}
+	if r.Method != "" {
+		return append(ranks, 1)
+	}
+	return append(ranks, 0)
+}
+
+// splitPath ignores a trailing slash, as Fiber does without strict routing.
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
@@ -0,0 +1,155 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/krackl1n/golang-project/config"
+	"github.com/krackl1n/golang-project/internal/models"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    models.RateLimit
+		wantErr bool
+	}{
+		{spec: "20/1s:40", want: models.RateLimit{Requests: 20, Period: time.Second, Burst: 40}},
+		{spec: "10000/24h", want: models.RateLimit{Requests: 10000, Period: 24 * time.Hour, Burst: 10000}},
+		{spec: "5/m", want: models.RateLimit{Requests: 5, Period: time.Minute, Burst: 5}},
+		{spec: " 3/10s ", want: models.RateLimit{Requests: 3, Period: 10 * time.Second, Burst: 3}},
+		{spec: "20", wantErr: true},
+		{spec: "0/1s", wantErr: true},
+		{spec: "20/0s", wantErr: true},
+		{spec: "20/1s:0", wantErr: true},
+		{spec: "x/1s", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseLimit(tt.spec)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseLimit(%q) = %+v, want an error", tt.spec, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseLimit(%q) = %+v, %v, want %+v", tt.spec, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseRulesRejectsMalformed(t *testing.T) {
+	for _, spec := range []string{"GET /user", "user=1/s", "GET /user extra=1/s", "/user=abc"} {
+		if _, err := ParseRules(spec); err == nil {
+			t.Errorf("ParseRules(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestLimiterMatch(t *testing.T) {
+	limiter, err := NewLimiter(config.ConfigRateLimit{
+		Default: "100/1s",
+		Routes:  "GET /user/:id=20/1s; /user/search=5/1s; POST /user/*=10/1s; * /webhooks=1/1s",
+	}, NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{method: "GET", path: "/user/0196a3c4-0000-7000-8000-000000000001", want: "GET /user/:id"},
+		{method: "GET", path: "/user/search", want: "/user/search"},
+		{method: "GET", path: "/USER/search/", want: "/user/search"},
+		{method: "POST", path: "/user/batch", want: "POST /user/*"},
+		{method: "DELETE", path: "/webhooks", want: "/webhooks"},
+		{method: "DELETE", path: "/user/1", want: "default"},
+		{method: "GET", path: "/user/1/history", want: "default"},
+	}
+
+	for _, tt := range tests {
+		rule := limiter.Match(tt.method, tt.path)
+		if rule == nil || rule.Name != tt.want {
+			t.Errorf("Match(%s %s) = %+v, want rule %q", tt.method, tt.path, rule, tt.want)
+		}
+	}
+}
+
+func TestLimiterWithoutDefault(t *testing.T) {
+	limiter, err := NewLimiter(config.ConfigRateLimit{Routes: "GET /user/:id=20/1s"}, NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rule := limiter.Match("GET", "/user"); rule != nil {
+		t.Fatalf("Match(GET /user) = %+v, want no limit", rule)
+	}
+	if rule := limiter.IPRule(); rule != nil {
+		t.Fatalf("IPRule() = %+v, want none", rule)
+	}
+}
+
+func TestLimiterKeepsBucketsPerRuleAndClient(t *testing.T) {
+	limiter, err := NewLimiter(config.ConfigRateLimit{Default: "1/1h", IP: "1/1h"}, NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	take := func(rule *Rule, client string) bool {
+		t.Helper()
+		decision, err := limiter.Take(ctx, rule, client)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return decision.Allowed
+	}
+
+	route := limiter.Match("GET", "/user")
+	if !take(route, "ip:10.0.0.1") || take(route, "ip:10.0.0.1") {
+		t.Fatal("want the first request allowed and the second rejected")
+	}
+	if !take(route, "ip:10.0.0.2") {
+		t.Fatal("another client shares the bucket")
+	}
+	if !take(limiter.IPRule(), "ip:10.0.0.1") {
+		t.Fatal("the IP rule shares the bucket of the route rule")
+	}
+}
+
+func TestMemoryStoreRefillsAndCleansUp(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	limit := models.RateLimit{Requests: 100, Period: time.Second, Burst: 2}
+
+	for i, want := range []bool{true, true, false} {
+		decision, err := store.Take(ctx, "client", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decision.Allowed != want {
+			t.Fatalf("take %d allowed = %v, want %v", i, decision.Allowed, want)
+		}
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	decision, err := store.Take(ctx, "client", limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decision.Allowed {
+		t.Fatal("bucket was not refilled")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	removed, err := store.Cleanup(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 {
+		t.Fatalf("Cleanup removed %d buckets, want the full one", removed)
+	}
+}
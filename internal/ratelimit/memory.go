@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/krackl1n/golang-project/internal/models"
+)
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   models.RateLimit
+}
+
+// MemoryStore keeps the buckets in process. Each replica then enforces the
+// limits on its own share of the traffic.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+	}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit models.RateLimit) (*models.RateLimitDecision, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst)}
+		s.buckets[key] = b
+	} else {
+		b.tokens = refill(b, now)
+	}
+	b.updated, b.limit = now, limit
+	b.tokens = min(b.tokens, float64(limit.Burst))
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+
+	return limit.Decide(b.tokens, allowed), nil
+}
+
+func (s *MemoryStore) Cleanup(ctx context.Context) (int64, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var removed int64
+	for key, b := range s.buckets {
+		if refill(b, now) >= float64(b.limit.Burst) {
+			delete(s.buckets, key)
+			removed++
+		}
+	}
+
+	return removed, nil
+}
+
+func refill(b *bucket, now time.Time) float64 {
+	tokens := b.tokens + now.Sub(b.updated).Seconds()*b.limit.PerSecond()
+	return min(tokens, float64(b.limit.Burst))
+}
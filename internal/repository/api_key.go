@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/pkg/errors"
+)
+
+const apiKeyColumns = `id, name, prefix, scopes, expires_at, last_used_at, coalesce(created_by, ''), created_at, revoked_at`
+
+type apiKeyRepository struct {
+	conn *pgxpool.Pool
+}
+
+func NewAPIKeyRepository(conn *pgxpool.Pool) APIKeyProvider {
+	return &apiKeyRepository{
+		conn: conn,
+	}
+}
+
+func scanAPIKey(row scanner, key *models.APIKey, extra ...any) error {
+	dest := []any{
+		&key.ID, &key.Name, &key.Prefix, &key.Scopes, &key.ExpiresAt, &key.LastUsedAt, &key.CreatedBy, &key.CreatedAt, &key.RevokedAt,
+	}
+	return row.Scan(append(dest, extra...)...)
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	query := `
+		INSERT INTO api_keys(id, name, prefix, secret_hash, scopes, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, nullif($7, ''))
+		RETURNING created_at
+	`
+
+	row := r.conn.QueryRow(ctx, query, key.ID, key.Name, key.Prefix, key.SecretHash, key.Scopes, key.ExpiresAt, key.CreatedBy)
+	if err := row.Scan(&key.CreatedAt); err != nil {
+		return errors.Wrap(mapError(err), "create api key")
+	}
+
+	slog.DebugContext(ctx, "created api key", slog.String("id", key.ID.String()), slog.String("prefix", key.Prefix))
+	return nil
+}
+
+// List returns every key, revoked ones included, without the secret hashes.
+func (r *apiKeyRepository) List(ctx context.Context) ([]models.APIKey, error) {
+	query := `
+		SELECT ` + apiKeyColumns + `
+		FROM api_keys
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.conn.Query(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(mapError(err), "list api keys")
+	}
+
+	keys, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.APIKey, error) {
+		var key models.APIKey
+		err := scanAPIKey(row, &key)
+		return key, err
+	})
+	if err != nil {
+		return nil, errors.Wrap(mapError(err), "scan api keys")
+	}
+
+	return keys, nil
+}
+
+// GetByPrefix returns the key with its secret hash, whether or not it is
+// still usable.
+func (r *apiKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	query := `
+		SELECT ` + apiKeyColumns + `, secret_hash
+		FROM api_keys
+		WHERE prefix=$1
+	`
+
+	var key models.APIKey
+	if err := scanAPIKey(r.conn.QueryRow(ctx, query, prefix), &key, &key.SecretHash); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperr.ErrorNotFound
+		}
+		return nil, errors.Wrap(mapError(err), "scan api key")
+	}
+
+	return &key, nil
+}
+
+// Revoke disables a key for good. Revoking it again keeps the first
+// revocation time.
+func (r *apiKeyRepository) Revoke(ctx context.Context, id uuid.UUID) (*models.APIKey, error) {
+	query := `
+		UPDATE api_keys
+		SET revoked_at = coalesce(revoked_at, CURRENT_TIMESTAMP)
+		WHERE id=$1
+		RETURNING ` + apiKeyColumns
+
+	var key models.APIKey
+	if err := scanAPIKey(r.conn.QueryRow(ctx, query, id), &key); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperr.ErrorNotFound
+		}
+		return nil, errors.Wrap(mapError(err), fmt.Sprintf("revoke api key: id=%s", id))
+	}
+
+	slog.DebugContext(ctx, "revoked api key", slog.String("id", id.String()))
+	return &key, nil
+}
+
+// TouchLastUsed records that a key was used. The timestamp is written at
+// most once a minute so busy keys do not turn every request into a write.
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE api_keys
+		SET last_used_at = CURRENT_TIMESTAMP
+		WHERE id=$1 AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute')
+	`
+
+	if _, err := r.conn.Exec(ctx, query, id); err != nil {
+		return errors.Wrap(mapError(err), fmt.Sprintf("touch api key: id=%s", id))
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/pkg/errors"
+)
+
+// constraintFields names the user field behind each constraint, so clients
+// can tell which value was rejected.
+var constraintFields = map[string]string{
+	"idx_users_email_unique": "email",
+	"users_email_check":      "email",
+	"users_gender_check":     "gender",
+	"users_age_check":        "age",
+}
+
+// mapError translates Postgres and connection errors into apperr errors.
+// Anything it does not recognise is returned unchanged.
+func mapError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		field := constraintFields[pgErr.ConstraintName]
+		if field == "" {
+			field = pgErr.ColumnName
+		}
+
+		switch pgErr.Code {
+		case "23505": // unique_violation
+			return &apperr.ConflictError{Field: field, Reason: "already exists"}
+		case "40001", "40P01": // serialization_failure, deadlock_detected
+			return &apperr.ConflictError{Reason: "concurrent update, retry the request"}
+		case "23514", "23502": // check_violation, not_null_violation
+			return &apperr.ValidationError{Field: field, Reason: "violates " + pgErr.ConstraintName}
+		case "22001", "22003": // string_data_right_truncation, numeric_value_out_of_range
+			return &apperr.ValidationError{Field: field, Reason: "value out of range"}
+		case "53300", "57P01", "57P03": // too_many_connections, admin_shutdown, cannot_connect_now
+			return &apperr.UnavailableError{Err: err}
+		}
+		if strings.HasPrefix(pgErr.Code, "08") { // connection_exception
+			return &apperr.UnavailableError{Err: err}
+		}
+		return err
+	}
+
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) || pgconn.Timeout(err) {
+		return &apperr.UnavailableError{Err: err}
+	}
+
+	return err
+}
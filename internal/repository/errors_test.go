@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/pkg/errors"
+)
+
+func TestMapError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		want      error
+		wantField string
+	}{
+		{
+			name: "duplicate email",
+			err:  &pgconn.PgError{Code: "23505", ConstraintName: "idx_users_email_unique"},
+			want: apperr.ErrorConflict, wantField: "email",
+		},
+		{
+			name: "serialization failure",
+			err:  &pgconn.PgError{Code: "40001"},
+			want: apperr.ErrorConflict,
+		},
+		{
+			name: "check violation",
+			err:  &pgconn.PgError{Code: "23514", ConstraintName: "users_age_check"},
+			want: apperr.ErrorValidation, wantField: "age",
+		},
+		{
+			name: "not null column",
+			err:  &pgconn.PgError{Code: "23502", ColumnName: "name"},
+			want: apperr.ErrorValidation, wantField: "name",
+		},
+		{
+			name: "wrapped too many connections",
+			err:  fmt.Errorf("query: %w", &pgconn.PgError{Code: "53300"}),
+			want: apperr.ErrorUnavailable,
+		},
+		{
+			name: "connection exception",
+			err:  &pgconn.PgError{Code: "08006"},
+			want: apperr.ErrorUnavailable,
+		},
+		{
+			name: "failed connect",
+			err:  &pgconn.ConnectError{Config: &pgconn.Config{}},
+			want: apperr.ErrorUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := mapError(tt.err)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("mapError() = %v, want %v", err, tt.want)
+			}
+
+			var (
+				conflictErr   *apperr.ConflictError
+				validationErr *apperr.ValidationError
+				field         string
+			)
+			switch {
+			case errors.As(err, &conflictErr):
+				field = conflictErr.Field
+			case errors.As(err, &validationErr):
+				field = validationErr.Field
+			}
+			if field != tt.wantField {
+				t.Fatalf("field = %q, want %q", field, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestMapErrorKeepsUnknownErrors(t *testing.T) {
+	for _, err := range []error{
+		errors.New("boom"),
+		context.DeadlineExceeded,
+		&pgconn.PgError{Code: "42P01"},
+	} {
+		if got := mapError(err); got != err {
+			t.Errorf("mapError(%v) = %v, want it unchanged", err, got)
+		}
+	}
+}
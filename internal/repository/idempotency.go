@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/pkg/errors"
+)
+
+type idempotencyRepository struct {
+	conn *pgxpool.Pool
+}
+
+func NewIdempotencyRepository(conn *pgxpool.Pool) IdempotencyProvider {
+	return &idempotencyRepository{
+		conn: conn,
+	}
+}
+
+// Claim stores record as in flight unless its key is taken, and returns nil
+// when it did. Otherwise it returns the record that holds the key. A key is
+// free again once it has expired, or when its request has been in flight
+// for longer than lockTimeout and is presumed lost with its replica. The
+// primary key serializes concurrent claims, so only one of them wins.
+func (r *idempotencyRepository) Claim(ctx context.Context, record *models.IdempotencyRecord, lockTimeout time.Duration) (*models.IdempotencyRecord, error) {
+	query := `
+		INSERT INTO idempotency_keys AS k (owner, key, fingerprint, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (owner, key) DO UPDATE SET
+			fingerprint = EXCLUDED.fingerprint,
+			status_code = NULL,
+			headers = NULL,
+			body = NULL,
+			created_at = CURRENT_TIMESTAMP,
+			completed_at = NULL,
+			expires_at = EXCLUDED.expires_at
+		WHERE k.expires_at <= CURRENT_TIMESTAMP
+			OR (k.status_code IS NULL AND k.created_at <= CURRENT_TIMESTAMP - make_interval(secs => $5))
+		RETURNING created_at
+	`
+
+	row := r.conn.QueryRow(ctx, query, record.Owner, record.Key, record.Fingerprint, record.ExpiresAt, lockTimeout.Seconds())
+	err := row.Scan(&record.CreatedAt)
+	if err == nil {
+		slog.DebugContext(ctx, "claimed idempotency key", slog.String("owner", record.Owner), slog.String("key", record.Key))
+		return nil, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, errors.Wrap(mapError(err), "claim idempotency key")
+	}
+
+	query = `
+		SELECT owner, key, fingerprint, coalesce(status_code, 0), headers, body, created_at, expires_at
+		FROM idempotency_keys
+		WHERE owner=$1 AND key=$2
+	`
+
+	var stored models.IdempotencyRecord
+	err = r.conn.QueryRow(ctx, query, record.Owner, record.Key).Scan(
+		&stored.Owner, &stored.Key, &stored.Fingerprint, &stored.StatusCode, &stored.Headers, &stored.Body, &stored.CreatedAt, &stored.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Deleted between both statements; the client may simply retry.
+			return nil, apperr.ErrorIdempotencyKeyInUse
+		}
+		return nil, errors.Wrap(mapError(err), "get idempotency key")
+	}
+
+	return &stored, nil
+}
+
+// Complete stores the response of a claimed record. The fingerprint guards
+// against completing a key that has since been claimed by another request.
+func (r *idempotencyRepository) Complete(ctx context.Context, record *models.IdempotencyRecord) error {
+	query := `
+		UPDATE idempotency_keys
+		SET status_code=$4, headers=$5, body=$6, completed_at=CURRENT_TIMESTAMP
+		WHERE owner=$1 AND key=$2 AND fingerprint=$3 AND status_code IS NULL
+	`
+
+	_, err := r.conn.Exec(ctx, query, record.Owner, record.Key, record.Fingerprint, record.StatusCode, record.Headers, record.Body)
+	if err != nil {
+		return errors.Wrap(mapError(err), fmt.Sprintf("complete idempotency key: owner=%s key=%s", record.Owner, record.Key))
+	}
+
+	return nil
+}
+
+// Release frees a key whose request is still in flight, so it can be
+// retried.
+func (r *idempotencyRepository) Release(ctx context.Context, owner, key string) error {
+	query := `
+		DELETE FROM idempotency_keys
+		WHERE owner=$1 AND key=$2 AND status_code IS NULL
+	`
+
+	if _, err := r.conn.Exec(ctx, query, owner, key); err != nil {
+		return errors.Wrap(mapError(err), fmt.Sprintf("release idempotency key: owner=%s key=%s", owner, key))
+	}
+
+	return nil
+}
+
+func (r *idempotencyRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	query := `
+		DELETE FROM idempotency_keys
+		WHERE expires_at <= CURRENT_TIMESTAMP
+	`
+
+	result, err := r.conn.Exec(ctx, query)
+	if err != nil {
+		return 0, errors.Wrap(mapError(err), "delete expired idempotency keys")
+	}
+
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/pkg/errors"
+)
+
+const importJobColumns = `id, format, status, columns, total_rows, imported_rows, rejected_rows,
+	coalesce(error, ''), created_at, updated_at, started_at, finished_at`
+
+type importJobRepository struct {
+	conn *pgxpool.Pool
+}
+
+func NewImportJobRepository(conn *pgxpool.Pool) ImportJobProvider {
+	return &importJobRepository{
+		conn: conn,
+	}
+}
+
+func scanImportJob(row scanner, job *models.ImportJob) error {
+	return row.Scan(
+		&job.ID, &job.Format, &job.Status, &job.Columns, &job.TotalRows, &job.ImportedRows, &job.RejectedRows,
+		&job.Error, &job.CreatedAt, &job.UpdatedAt, &job.StartedAt, &job.FinishedAt,
+	)
+}
+
+func (r *importJobRepository) Create(ctx context.Context, job *models.ImportJob) error {
+	query := `
+		INSERT INTO import_jobs(id, format, status, columns)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at, updated_at
+	`
+
+	row := r.conn.QueryRow(ctx, query, job.ID, job.Format, job.Status, job.Columns)
+	if err := row.Scan(&job.CreatedAt, &job.UpdatedAt); err != nil {
+		return errors.Wrap(mapError(err), "create import job")
+	}
+
+	slog.DebugContext(ctx, "created import job", slog.String("id", job.ID.String()))
+	return nil
+}
+
+func (r *importJobRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.ImportJob, error) {
+	query := `
+		SELECT ` + importJobColumns + `
+		FROM import_jobs
+		WHERE id=$1
+	`
+
+	var job models.ImportJob
+	if err := scanImportJob(r.conn.QueryRow(ctx, query, id), &job); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperr.ErrorNotFound
+		}
+		return nil, errors.Wrap(mapError(err), "scan import job")
+	}
+
+	return &job, nil
+}
+
+// Start moves a pending job to running. A job accepts data only once.
+func (r *importJobRepository) Start(ctx context.Context, id uuid.UUID) (*models.ImportJob, error) {
+	query := `
+		UPDATE import_jobs
+		SET status = $2, started_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = $3
+		RETURNING ` + importJobColumns
+
+	var job models.ImportJob
+	row := r.conn.QueryRow(ctx, query, id, models.ImportRunning, models.ImportPending)
+	if err := scanImportJob(row, &job); err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(mapError(err), fmt.Sprintf("start import job: id=%s", id))
+		}
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, &apperr.ConflictError{Field: "status", Reason: "import job already started"}
+	}
+
+	slog.DebugContext(ctx, "started import job", slog.String("id", id.String()))
+	return &job, nil
+}
+
+// AddProgress adds the counters of one processed chunk and copies its
+// rejected rows into the error report in a single transaction. Progress
+// also shows the job is alive; a job that is no longer running, because it
+// was taken for stale, accepts none.
+func (r *importJobRepository) AddProgress(ctx context.Context, id uuid.UUID, progress *models.ImportProgress) error {
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(mapError(err), "begin import progress")
+	}
+	defer tx.Rollback(ctx)
+
+	if len(progress.RowErrors) > 0 {
+		rows := make([][]any, 0, len(progress.RowErrors))
+		for _, rowError := range progress.RowErrors {
+			rows = append(rows, []any{id, rowError.Line, rowError.Error, rowError.Raw})
+		}
+
+		_, err := tx.CopyFrom(ctx,
+			pgx.Identifier{"import_job_errors"},
+			[]string{"job_id", "line", "error", "raw"},
+			pgx.CopyFromRows(rows),
+		)
+		if err != nil {
+			return errors.Wrap(mapError(err), "copy import errors")
+		}
+	}
+
+	query := `
+		UPDATE import_jobs
+		SET total_rows = total_rows + $2,
+			imported_rows = imported_rows + $3,
+			rejected_rows = rejected_rows + $4,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = $5
+	`
+	tag, err := tx.Exec(ctx, query, id, progress.TotalRows, progress.ImportedRows, progress.RejectedRows, models.ImportRunning)
+	if err != nil {
+		return errors.Wrap(mapError(err), "update import progress")
+	}
+	if tag.RowsAffected() == 0 {
+		return &apperr.ConflictError{Field: "status", Reason: "import job is not running"}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(mapError(err), "commit import progress")
+	}
+
+	return nil
+}
+
+// Finish moves a running job to its final status.
+func (r *importJobRepository) Finish(ctx context.Context, id uuid.UUID, status models.ImportStatus, message string) (*models.ImportJob, error) {
+	query := `
+		UPDATE import_jobs
+		SET status = $2, error = NULLIF($3, ''), finished_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = $4
+		RETURNING ` + importJobColumns
+
+	var job models.ImportJob
+	row := r.conn.QueryRow(ctx, query, id, status, message, models.ImportRunning)
+	if err := scanImportJob(row, &job); err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(mapError(err), fmt.Sprintf("finish import job: id=%s", id))
+		}
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, &apperr.ConflictError{Field: "status", Reason: "import job is not running"}
+	}
+
+	slog.DebugContext(ctx, "finished import job", slog.String("id", id.String()), slog.String("status", string(status)))
+	return &job, nil
+}
+
+// FailStale marks running jobs that have recorded no progress since before
+// as failed with message, so a job whose instance died does not stay
+// running forever.
+func (r *importJobRepository) FailStale(ctx context.Context, before time.Time, message string) (int64, error) {
+	query := `
+		UPDATE import_jobs
+		SET status = $3, error = $4, finished_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE status = $1 AND updated_at < $2
+	`
+
+	tag, err := r.conn.Exec(ctx, query, models.ImportRunning, before, models.ImportFailed, message)
+	if err != nil {
+		return 0, errors.Wrap(mapError(err), "fail stale import jobs")
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// StreamErrors calls fn for every rejected row of the job in line order
+// without loading the report into memory.
+func (r *importJobRepository) StreamErrors(ctx context.Context, id uuid.UUID, fn func(rowError *models.ImportRowError) error) error {
+	query := `
+		SELECT line, error, raw
+		FROM import_job_errors
+		WHERE job_id = $1
+		ORDER BY line
+	`
+
+	rows, err := r.conn.Query(ctx, query, id)
+	if err != nil {
+		return errors.Wrap(mapError(err), "query import errors")
+	}
+	defer rows.Close()
+
+	var rowError models.ImportRowError
+	for rows.Next() {
+		if err := rows.Scan(&rowError.Line, &rowError.Error, &rowError.Raw); err != nil {
+			return errors.Wrap(err, "scan import error")
+		}
+		if err := fn(&rowError); err != nil {
+			return err
+		}
+	}
+
+	return errors.Wrap(rows.Err(), "iterate import errors")
+}
@@ -2,14 +2,72 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/krackl1n/golang-project/internal/models"
 )
 
+type APIKeyProvider interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	List(ctx context.Context) ([]models.APIKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error)
+	Revoke(ctx context.Context, id uuid.UUID) (*models.APIKey, error)
+	TouchLastUsed(ctx context.Context, id uuid.UUID) error
+}
+
+type IdempotencyProvider interface {
+	Claim(ctx context.Context, record *models.IdempotencyRecord, lockTimeout time.Duration) (*models.IdempotencyRecord, error)
+	Complete(ctx context.Context, record *models.IdempotencyRecord) error
+	Release(ctx context.Context, owner, key string) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+type ImportJobProvider interface {
+	Create(ctx context.Context, job *models.ImportJob) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.ImportJob, error)
+	Start(ctx context.Context, id uuid.UUID) (*models.ImportJob, error)
+	AddProgress(ctx context.Context, id uuid.UUID, progress *models.ImportProgress) error
+	Finish(ctx context.Context, id uuid.UUID, status models.ImportStatus, message string) (*models.ImportJob, error)
+	FailStale(ctx context.Context, before time.Time, message string) (int64, error)
+	StreamErrors(ctx context.Context, id uuid.UUID, fn func(rowError *models.ImportRowError) error) error
+}
+
+type OutboxProvider interface {
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error)
+	MarkDelivered(ctx context.Context, seq int64) error
+	Reschedule(ctx context.Context, seq int64, cause error, retryIn time.Duration) error
+}
+
+type WebhookProvider interface {
+	CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error
+	ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id uuid.UUID) error
+	EnqueueDeliveries(ctx context.Context, event *models.OutboxEvent) (int64, error)
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	RecordAttempt(ctx context.Context, id int64, attempt models.WebhookAttempt, status models.WebhookDeliveryStatus, retryIn time.Duration) error
+	ListDeliveries(ctx context.Context, filter *models.WebhookDeliveryFilter) ([]models.WebhookDelivery, error)
+	GetDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, error)
+	ReplayDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, error)
+}
+
+type RateLimitProvider interface {
+	Take(ctx context.Context, key string, limit models.RateLimit) (*models.RateLimitDecision, error)
+	Cleanup(ctx context.Context) (int64, error)
+}
+
 type UserProvider interface {
 	Create(ctx context.Context, user *models.User) (uuid.UUID, error)
-	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	CreateBatch(ctx context.Context, users []models.User, atomic bool) ([]error, error)
+	GetByID(ctx context.Context, id uuid.UUID, withDeleted bool) (*models.User, error)
+	List(ctx context.Context, filter *models.UserListFilter) ([]models.User, error)
+	SearchUsers(ctx context.Context, query *models.UserSearchQuery) ([]models.UserSearchResult, error)
+	Export(ctx context.Context, filter *models.UserFilter, fn func(user *models.User) error) error
 	Update(ctx context.Context, user *models.User) error
-	Delete(ctx context.Context, id uuid.UUID) error
+	UpdateFields(ctx context.Context, id uuid.UUID, changes *models.UserChanges) (*models.User, error)
+	Delete(ctx context.Context, id uuid.UUID, version int64) error
+	Restore(ctx context.Context, id uuid.UUID) (*models.User, error)
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
+	History(ctx context.Context, id uuid.UUID, before int64, limit int) ([]models.UserHistoryEntry, error)
+	GetAsOf(ctx context.Context, id uuid.UUID, at time.Time) (*models.User, error)
 }
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/requestctx"
+	"github.com/pkg/errors"
+)
+
+// enqueueUserEvents stores one event per user in the transaction of the
+// write, so an event exists if and only if the write is committed.
+func enqueueUserEvents(ctx context.Context, tx pgx.Tx, eventType models.EventType, users ...*models.User) error {
+	rows := make([][]any, 0, len(users))
+	for _, user := range users {
+		eventId, err := uuid.NewV7()
+		if err != nil {
+			return errors.Wrap(err, "generate UUID")
+		}
+
+		payload, err := json.Marshal(models.UserEventPayload{
+			User:      user,
+			Version:   user.Version,
+			Actor:     requestctx.Actor(ctx),
+			RequestID: requestctx.RequestID(ctx),
+		})
+		if err != nil {
+			return errors.Wrap(err, "encode event payload")
+		}
+
+		rows = append(rows, []any{eventId, eventType, user.ID, payload})
+	}
+
+	_, err := tx.CopyFrom(ctx,
+		pgx.Identifier{"outbox_events"},
+		[]string{"event_id", "event_type", "aggregate_id", "payload"},
+		pgx.CopyFromRows(rows),
+	)
+	if err != nil {
+		return errors.Wrap(mapError(err), fmt.Sprintf("enqueue %s events", eventType))
+	}
+
+	return nil
+}
+
+type outboxRepository struct {
+	conn *pgxpool.Pool
+}
+
+func NewOutboxRepository(conn *pgxpool.Pool) OutboxProvider {
+	return &outboxRepository{
+		conn: conn,
+	}
+}
+
+// Claim leases up to limit due events, oldest first: they are skipped by
+// other relays until lease has passed, without a transaction or row lock
+// being held while they are published. Rows already locked by another
+// claim are skipped too. An event that is neither marked delivered nor
+// rescheduled before the lease ends is claimed again, so events are
+// published at least once.
+func (r *outboxRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error) {
+	query := `
+		WITH claimed AS (
+			UPDATE outbox_events o
+			SET next_attempt_at = CURRENT_TIMESTAMP + $2::interval
+			FROM (
+				SELECT id
+				FROM outbox_events
+				WHERE delivered_at IS NULL AND next_attempt_at <= CURRENT_TIMESTAMP
+				ORDER BY id
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			) due
+			WHERE o.id = due.id
+			RETURNING o.id, o.event_id, o.event_type, o.aggregate_id, o.payload, o.created_at, o.attempts
+		)
+		SELECT id, event_id, event_type, aggregate_id, payload, created_at, attempts
+		FROM claimed
+		ORDER BY id
+	`
+
+	rows, err := r.conn.Query(ctx, query, limit, lease)
+	if err != nil {
+		return nil, errors.Wrap(mapError(err), "claim outbox events")
+	}
+	events, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.OutboxEvent, error) {
+		var event models.OutboxEvent
+		err := row.Scan(&event.Seq, &event.ID, &event.Type, &event.AggregateID, &event.Payload, &event.OccurredAt, &event.Attempts)
+		return event, err
+	})
+	if err != nil {
+		return nil, errors.Wrap(mapError(err), "scan outbox events")
+	}
+
+	return events, nil
+}
+
+func (r *outboxRepository) MarkDelivered(ctx context.Context, seq int64) error {
+	query := `
+		UPDATE outbox_events
+		SET attempts = attempts + 1, last_error = NULL, delivered_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+	if _, err := r.conn.Exec(ctx, query, seq); err != nil {
+		return errors.Wrap(mapError(err), fmt.Sprintf("mark outbox event delivered: id=%d", seq))
+	}
+
+	return nil
+}
+
+// Reschedule records a failed attempt to publish an event and makes it due
+// again after retryIn.
+func (r *outboxRepository) Reschedule(ctx context.Context, seq int64, cause error, retryIn time.Duration) error {
+	query := `
+		UPDATE outbox_events
+		SET attempts = attempts + 1, last_error = $2, next_attempt_at = CURRENT_TIMESTAMP + $3::interval
+		WHERE id = $1
+	`
+	if _, err := r.conn.Exec(ctx, query, seq, cause.Error(), retryIn); err != nil {
+		return errors.Wrap(mapError(err), fmt.Sprintf("reschedule outbox event: id=%d", seq))
+	}
+
+	return nil
+}
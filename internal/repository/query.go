@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/pkg/errors"
+)
+
+const insertUserQuery = `
+	INSERT INTO users(id, name, age, gender, email)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING created_at, updated_at, version
+`
+
+// insertUserSkipConflictQuery inserts nothing and returns no row when the
+// user clashes with a unique index.
+const insertUserSkipConflictQuery = `
+	INSERT INTO users(id, name, age, gender, email)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT DO NOTHING
+	RETURNING created_at, updated_at, version
+`
+
+// setAuditContextQuery scopes the actor and request id to the current
+// transaction; the user_history trigger reads them back.
+const setAuditContextQuery = `SELECT set_config('app.actor', $1, true), set_config('app.request_id', $2, true)`
+
+// exportFetchSize is how many rows an export pulls from its cursor at once.
+const exportFetchSize = 1000
+
+const userColumns = "id, name, age, gender, email, created_at, updated_at, version, deleted_at"
+
+// userSortColumns whitelists the columns a user list can be ordered by.
+var userSortColumns = map[models.UserSortField]string{
+	models.UserSortByID:        "id",
+	models.UserSortByName:      "name",
+	models.UserSortByAge:       "age",
+	models.UserSortByCreatedAt: "created_at",
+	models.UserSortByUpdatedAt: "updated_at",
+}
+
+const userHistoryColumns = "id, user_id, operation, before, after, version, coalesce(actor, ''), coalesce(request_id, ''), changed_at"
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUser(row scanner, user *models.User) error {
+	return row.Scan(&user.ID, &user.Name, &user.Age, &user.Gender, &user.Email, &user.CreatedAt, &user.UpdatedAt, &user.Version, &user.DeletedAt)
+}
+
+func scanUserHistoryEntry(row scanner, entry *models.UserHistoryEntry) error {
+	return row.Scan(&entry.ID, &entry.UserID, &entry.Operation, &entry.Before, &entry.After, &entry.Version, &entry.Actor, &entry.RequestID, &entry.ChangedAt)
+}
+
+// queryBuilder collects WHERE conditions and keeps their arguments
+// positional, so values never end up in the SQL text.
+type queryBuilder struct {
+	conds []string
+	args  []any
+}
+
+func (b *queryBuilder) arg(value any) string {
+	b.args = append(b.args, value)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+// where adds a condition; every %s in format is replaced with a placeholder
+// bound to the matching value.
+func (b *queryBuilder) where(format string, values ...any) {
+	placeholders := make([]any, 0, len(values))
+	for _, value := range values {
+		placeholders = append(placeholders, b.arg(value))
+	}
+	b.conds = append(b.conds, fmt.Sprintf(format, placeholders...))
+}
+
+func (b *queryBuilder) whereClause() string {
+	if len(b.conds) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(b.conds, " AND ")
+}
+
+func (b *queryBuilder) applyUserFilter(filter *models.UserFilter) {
+	if !filter.WithDeleted {
+		b.where("deleted_at IS NULL")
+	}
+	if filter.Gender != "" {
+		b.where("gender = %s", filter.Gender)
+	}
+	if filter.AgeMin != nil {
+		b.where("age >= %s", *filter.AgeMin)
+	}
+	if filter.AgeMax != nil {
+		b.where("age <= %s", *filter.AgeMax)
+	}
+	if filter.EmailDomain != "" {
+		b.where("lower(split_part(email, '@', 2)) = %s", filter.EmailDomain)
+	}
+	if filter.CreatedAfter != nil {
+		b.where("created_at >= %s", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		b.where("created_at < %s", *filter.CreatedBefore)
+	}
+	if filter.UpdatedAfter != nil {
+		b.where("updated_at >= %s", *filter.UpdatedAfter)
+	}
+	if filter.UpdatedBefore != nil {
+		b.where("updated_at < %s", *filter.UpdatedBefore)
+	}
+}
+
+// applyKeyset restricts the query to rows after pos in the given order and
+// returns the matching ORDER BY clause.
+func (b *queryBuilder) applyKeyset(sort models.UserSort, pos *models.UserPosition) (string, error) {
+	column, ok := userSortColumns[sort.Field]
+	if !ok {
+		return "", errors.Errorf("unsupported sort field %q", sort.Field)
+	}
+
+	op, dir := ">", "ASC"
+	if sort.Desc {
+		op, dir = "<", "DESC"
+	}
+
+	if column == "id" {
+		if pos != nil {
+			b.where("id "+op+" %s", pos.ID)
+		}
+		return fmt.Sprintf("ORDER BY id %s", dir), nil
+	}
+
+	if pos != nil {
+		b.where("("+column+", id) "+op+" (%s, %s)", pos.Value, pos.ID)
+	}
+	return fmt.Sprintf("ORDER BY %s %s, id %s", column, dir, dir), nil
+}
+
+// prefixTSQuery builds a to_tsquery expression that prefix-matches every word
+// of the search text. Only letters and digits survive, so the user input can
+// never inject tsquery operators.
+func prefixTSQuery(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+
+	return strings.Join(words, " & ")
+}
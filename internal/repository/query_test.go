@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/internal/models"
+)
+
+func TestPrefixTSQuery(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "Ann", want: "ann:*"},
+		{text: "ann smith", want: "ann:* & smith:*"},
+		{text: "ann@example.com", want: "ann:* & example:* & com:*"},
+		{text: "Zoë 42", want: "zoë:* & 42:*"},
+		{text: "a & !b | c:*", want: "a:* & b:* & c:*"},
+		{text: "'); DROP TABLE users; --", want: "drop:* & table:* & users:*"},
+		{text: " !?& ", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := prefixTSQuery(tt.text); got != tt.want {
+			t.Errorf("prefixTSQuery(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestQueryBuilderUserList(t *testing.T) {
+	ageMin, ageMax := uint8(20), uint8(40)
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	id := uuid.Must(uuid.NewV7())
+
+	var b queryBuilder
+	b.applyUserFilter(&models.UserFilter{
+		Gender:       "female",
+		AgeMin:       &ageMin,
+		AgeMax:       &ageMax,
+		EmailDomain:  "example.com",
+		CreatedAfter: &created,
+	})
+	orderBy, err := b.applyKeyset(models.UserSort{Field: models.UserSortByName, Desc: true}, &models.UserPosition{ID: id, Value: "Ann"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantWhere := "WHERE deleted_at IS NULL AND gender = $1 AND age >= $2 AND age <= $3 AND lower(split_part(email, '@', 2)) = $4" +
+		" AND created_at >= $5 AND (name, id) < ($6, $7)"
+	if got := b.whereClause(); got != wantWhere {
+		t.Errorf("where = %q, want %q", got, wantWhere)
+	}
+	if want := "ORDER BY name DESC, id DESC"; orderBy != want {
+		t.Errorf("order by = %q, want %q", orderBy, want)
+	}
+	wantArgs := []any{"female", ageMin, ageMax, "example.com", created, "Ann", id}
+	if !reflect.DeepEqual(b.args, wantArgs) {
+		t.Errorf("args = %v, want %v", b.args, wantArgs)
+	}
+}
+
+func TestQueryBuilderSortsByIDAlone(t *testing.T) {
+	var b queryBuilder
+	b.applyUserFilter(&models.UserFilter{WithDeleted: true})
+	orderBy, err := b.applyKeyset(models.UserSort{Field: models.UserSortByID}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.whereClause() != "" || orderBy != "ORDER BY id ASC" {
+		t.Errorf("got %q %q, want no conditions and ORDER BY id ASC", b.whereClause(), orderBy)
+	}
+}
+
+func TestQueryBuilderRejectsUnknownSort(t *testing.T) {
+	var b queryBuilder
+	if _, err := b.applyKeyset(models.UserSort{Field: "email; DROP TABLE users"}, nil); err == nil {
+		t.Fatal("applyKeyset accepted a field outside the whitelist")
+	}
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/pkg/errors"
+)
+
+// refilledTokens is the content of bucket b refilled up to now, given the
+// burst in $2 and the rate in $3.
+const refilledTokens = `least($2::float8, b.tokens + extract(epoch FROM CURRENT_TIMESTAMP - b.updated_at)::float8 * $3::float8)`
+
+type rateLimitRepository struct {
+	conn *pgxpool.Pool
+}
+
+// NewRateLimitRepository keeps the token buckets in Postgres, so every
+// replica of the service draws from the same buckets.
+func NewRateLimitRepository(conn *pgxpool.Pool) RateLimitProvider {
+	return &rateLimitRepository{
+		conn: conn,
+	}
+}
+
+// Take refills and takes from the bucket in one upsert, so concurrent
+// requests of a client are serialized on its row. Time is measured by the
+// database clock alone.
+func (r *rateLimitRepository) Take(ctx context.Context, key string, limit models.RateLimit) (*models.RateLimitDecision, error) {
+	query := `
+		INSERT INTO rate_limit_buckets AS b (key, tokens, burst, rate, allowed)
+		VALUES ($1, $2::float8 - 1, $2::float8, $3::float8, true)
+		ON CONFLICT (key) DO UPDATE SET
+			tokens = CASE WHEN ` + refilledTokens + ` >= 1 THEN ` + refilledTokens + ` - 1 ELSE ` + refilledTokens + ` END,
+			allowed = ` + refilledTokens + ` >= 1,
+			burst = $2::float8,
+			rate = $3::float8,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING tokens, allowed
+	`
+
+	var (
+		tokens  float64
+		allowed bool
+	)
+	row := r.conn.QueryRow(ctx, query, key, float64(limit.Burst), limit.PerSecond())
+	if err := row.Scan(&tokens, &allowed); err != nil {
+		return nil, errors.Wrap(mapError(err), "take rate limit token")
+	}
+
+	return limit.Decide(tokens, allowed), nil
+}
+
+// Cleanup removes buckets that have refilled completely; a missing bucket
+// starts out full anyway.
+func (r *rateLimitRepository) Cleanup(ctx context.Context) (int64, error) {
+	query := `
+		DELETE FROM rate_limit_buckets
+		WHERE tokens + extract(epoch FROM CURRENT_TIMESTAMP - updated_at)::float8 * rate >= burst
+	`
+
+	result, err := r.conn.Exec(ctx, query)
+	if err != nil {
+		return 0, errors.Wrap(mapError(err), "clean up rate limit buckets")
+	}
+
+	return result.RowsAffected(), nil
+}
@@ -4,12 +4,16 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/krackl1n/golang-project/internal/apperr"
 	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/requestctx"
 	"github.com/pkg/errors"
 )
 
@@ -23,77 +27,609 @@ func NewUserRepository(conn *pgxpool.Pool) UserProvider {
 	}
 }
 
-func (r *userRepository) Create(ctx context.Context, user *models.User) (uuid.UUID, error) {
-	query := `
-		INSERT INTO users(id, name, age, gender, email) 
-		VALUES ($1, $2, $3, $4, $5)
-	`
+// begin starts a write transaction and hands the actor and request id of
+// ctx to the trigger that fills user_history.
+func (r *userRepository) begin(ctx context.Context) (pgx.Tx, error) {
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return nil, errors.Wrap(mapError(err), "begin transaction")
+	}
+
+	if _, err := tx.Exec(ctx, setAuditContextQuery, requestctx.Actor(ctx), requestctx.RequestID(ctx)); err != nil {
+		tx.Rollback(ctx)
+		return nil, errors.Wrap(mapError(err), "set audit context")
+	}
 
-	_, err := r.conn.Exec(ctx, query, user.ID.String(), user.Name, user.Age, user.Gender, user.Email)
+	return tx, nil
+}
+
+func (r *userRepository) Create(ctx context.Context, user *models.User) (uuid.UUID, error) {
+	tx, err := r.begin(ctx)
 	if err != nil {
-		return uuid.Nil, errors.Wrap(err, "create user")
+		return uuid.Nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	row := tx.QueryRow(ctx, insertUserQuery, user.ID.String(), user.Name, user.Age, user.Gender, user.Email)
+	if err := row.Scan(&user.CreatedAt, &user.UpdatedAt, &user.Version); err != nil {
+		return uuid.Nil, errors.Wrap(mapError(err), "create user")
+	}
+
+	if err := enqueueUserEvents(ctx, tx, models.UserCreatedEvent, user); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return uuid.Nil, errors.Wrap(mapError(err), "commit create user")
 	}
 
-	slog.Debug(fmt.Sprintf("created user: id=%s", user.ID))
+	slog.DebugContext(ctx, "created user", slog.String("id", user.ID.String()))
 	return user.ID, nil
 }
 
-func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+// CreateBatch inserts all users through a single pgx batch. In atomic mode
+// the batch runs in one transaction and the first failure aborts every item.
+// Otherwise each user is inserted under a savepoint of its own; the returned
+// slice holds the error of every item, nil for created users.
+func (r *userRepository) CreateBatch(ctx context.Context, users []models.User, atomic bool) ([]error, error) {
+	if atomic {
+		return r.createBatchAtomic(ctx, users)
+	}
+	return r.createBatchBestEffort(ctx, users)
+}
+
+func (r *userRepository) createBatchAtomic(ctx context.Context, users []models.User) ([]error, error) {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	batch := &pgx.Batch{}
+	for _, user := range users {
+		batch.Queue(insertUserQuery, user.ID, user.Name, user.Age, user.Gender, user.Email)
+	}
+
+	errs := make([]error, len(users))
+	results := tx.SendBatch(ctx, batch)
+	for i := range users {
+		err := results.QueryRow().Scan(&users[i].CreatedAt, &users[i].UpdatedAt, &users[i].Version)
+		if err != nil {
+			results.Close()
+			for j := range errs {
+				errs[j] = apperr.ErrorBatchAborted
+			}
+			errs[i] = mapError(err)
+			slog.DebugContext(ctx, "batch aborted", slog.Int("index", i))
+			return errs, nil
+		}
+	}
+	if err := results.Close(); err != nil {
+		return nil, errors.Wrap(mapError(err), "close batch")
+	}
+
+	created := make([]*models.User, len(users))
+	for i := range users {
+		created[i] = &users[i]
+	}
+	if err := enqueueUserEvents(ctx, tx, models.UserCreatedEvent, created...); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, errors.Wrap(mapError(err), "commit batch")
+	}
+
+	slog.DebugContext(ctx, "created users batch", slog.Int("count", len(users)))
+	return errs, nil
+}
+
+// createBatchBestEffort skips duplicate emails with ON CONFLICT DO NOTHING.
+// Every item runs under a savepoint of its own, so any other failure only
+// rolls back that item and the rest of the batch still commits.
+func (r *userRepository) createBatchBestEffort(ctx context.Context, users []models.User) ([]error, error) {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	errs := make([]error, len(users))
+	created := make([]*models.User, 0, len(users))
+	for i := range users {
+		itemErr, err := insertUserSavepoint(ctx, tx, &users[i])
+		if err != nil {
+			return nil, err
+		}
+		errs[i] = itemErr
+		if itemErr == nil {
+			created = append(created, &users[i])
+		}
+	}
+
+	if len(created) > 0 {
+		if err := enqueueUserEvents(ctx, tx, models.UserCreatedEvent, created...); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, errors.Wrap(mapError(err), "commit batch")
+	}
+
+	slog.DebugContext(ctx, "created users batch", slog.Int("count", len(created)))
+	return errs, nil
+}
+
+// insertUserSavepoint inserts user under a savepoint. A statement the
+// database rejects is returned as the error of the item; anything else,
+// such as a lost connection, fails the whole batch.
+func insertUserSavepoint(ctx context.Context, tx pgx.Tx, user *models.User) (itemErr error, err error) {
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		return nil, errors.Wrap(mapError(err), "create savepoint")
+	}
+	defer savepoint.Rollback(ctx)
+
+	row := savepoint.QueryRow(ctx, insertUserSkipConflictQuery, user.ID, user.Name, user.Age, user.Gender, user.Email)
+	err = row.Scan(&user.CreatedAt, &user.UpdatedAt, &user.Version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &apperr.ConflictError{Field: "email", Reason: "already exists"}, nil
+	}
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if !errors.As(err, &pgErr) {
+			return nil, errors.Wrap(mapError(err), "create users batch")
+		}
+		if rollbackErr := savepoint.Rollback(ctx); rollbackErr != nil {
+			return nil, errors.Wrap(mapError(rollbackErr), "roll back to savepoint")
+		}
+		return mapError(err), nil
+	}
+
+	if err := savepoint.Commit(ctx); err != nil {
+		return nil, errors.Wrap(mapError(err), "release savepoint")
+	}
+
+	return nil, nil
+}
+
+func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID, withDeleted bool) (*models.User, error) {
 	query := `
-		SELECT id, name, age, gender, email 
+		SELECT ` + userColumns + `
 		FROM users 
-		WHERE id=$1
+		WHERE id=$1 AND ($2 OR deleted_at IS NULL)
 	`
 
 	var user models.User
-	row := r.conn.QueryRow(ctx, query, id)
-	err := row.Scan(&user.ID, &user.Name, &user.Age, &user.Gender, &user.Email)
-	if err != nil {
+	row := r.conn.QueryRow(ctx, query, id, withDeleted)
+	if err := scanUser(row, &user); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, apperr.ErrorNotFound
 		}
-		return nil, errors.Wrap(err, "scan user data")
+		return nil, errors.Wrap(mapError(err), "scan user data")
 	}
 
-	slog.Debug(fmt.Sprintf("received successfully: id=%s", id))
+	slog.DebugContext(ctx, "received user", slog.String("id", id.String()))
 	return &user, nil
 }
 
+func (r *userRepository) List(ctx context.Context, filter *models.UserListFilter) ([]models.User, error) {
+	var b queryBuilder
+	b.applyUserFilter(&filter.UserFilter)
+	orderBy, err := b.applyKeyset(filter.UserSort, filter.After)
+	if err != nil {
+		return nil, errors.Wrap(err, "list users")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM users
+		%s
+		%s
+		LIMIT %s
+	`, userColumns, b.whereClause(), orderBy, b.arg(filter.Limit))
+
+	rows, err := r.conn.Query(ctx, query, b.args...)
+	if err != nil {
+		return nil, errors.Wrap(mapError(err), "list users")
+	}
+	defer rows.Close()
+
+	users := make([]models.User, 0, filter.Limit)
+	for rows.Next() {
+		var user models.User
+		if err := scanUser(rows, &user); err != nil {
+			return nil, errors.Wrap(mapError(err), "scan user data")
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(mapError(err), "iterate users")
+	}
+
+	slog.DebugContext(ctx, "listed users", slog.Int("count", len(users)))
+	return users, nil
+}
+
+// Export streams the users matching filter to fn in id order. Rows are
+// fetched from a server-side cursor inside a read-only REPEATABLE READ
+// transaction, so the export is one consistent snapshot and at most
+// exportFetchSize rows are held in memory. fn is passed the same *User
+// for every row and must not keep it.
+func (r *userRepository) Export(ctx context.Context, filter *models.UserFilter, fn func(user *models.User) error) error {
+	tx, err := r.conn.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadOnly,
+	})
+	if err != nil {
+		return errors.Wrap(mapError(err), "begin export")
+	}
+	defer tx.Rollback(ctx)
+
+	var b queryBuilder
+	b.applyUserFilter(filter)
+	query := fmt.Sprintf(`
+		DECLARE user_export NO SCROLL CURSOR FOR
+		SELECT %s
+		FROM users
+		%s
+		ORDER BY id
+	`, userColumns, b.whereClause())
+
+	if _, err := tx.Exec(ctx, query, b.args...); err != nil {
+		return errors.Wrap(mapError(err), "declare export cursor")
+	}
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM user_export", exportFetchSize)
+	var exported int
+	for {
+		rows, err := tx.Query(ctx, fetch)
+		if err != nil {
+			return errors.Wrap(mapError(err), "fetch users")
+		}
+
+		var fetched int
+		var user models.User
+		for rows.Next() {
+			if err := scanUser(rows, &user); err != nil {
+				rows.Close()
+				return errors.Wrap(mapError(err), "scan user data")
+			}
+			if err := fn(&user); err != nil {
+				rows.Close()
+				return err
+			}
+			fetched++
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return errors.Wrap(mapError(err), "iterate users")
+		}
+
+		exported += fetched
+		if fetched < exportFetchSize {
+			break
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(mapError(err), "commit export")
+	}
+
+	slog.DebugContext(ctx, "exported users", slog.Int("count", exported))
+	return nil
+}
+
+func (r *userRepository) SearchUsers(ctx context.Context, search *models.UserSearchQuery) ([]models.UserSearchResult, error) {
+	tsQuery := prefixTSQuery(search.Text)
+	if tsQuery == "" {
+		return []models.UserSearchResult{}, nil
+	}
+
+	// Full-text prefix matches and trigram word similarity are combined into
+	// a single rank, so both exact and misspelled input find the user.
+	// Trigrams are case-insensitive already; the columns are compared as they
+	// are, so the trigram indexes on them apply.
+	query := `
+		WITH q AS (
+			SELECT to_tsquery('simple', $1) AS tsq, $2::text AS term
+		)
+		SELECT ` + userColumns + `,
+			ts_rank(search_vector, q.tsq)
+				+ greatest(word_similarity(q.term, name), word_similarity(q.term, email)) AS rank,
+			ts_headline('simple', name, q.tsq, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true'),
+			ts_headline('simple', email, q.tsq, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true')
+		FROM users, q
+		WHERE deleted_at IS NULL
+			AND (search_vector @@ q.tsq
+				OR q.term <% name
+				OR q.term <% email)
+		ORDER BY rank DESC, id
+		LIMIT $3
+	`
+
+	rows, err := r.conn.Query(ctx, query, tsQuery, search.Text, search.Limit)
+	if err != nil {
+		return nil, errors.Wrap(mapError(err), "search users")
+	}
+	defer rows.Close()
+
+	results := make([]models.UserSearchResult, 0, search.Limit)
+	for rows.Next() {
+		var (
+			result                models.UserSearchResult
+			nameMatch, emailMatch string
+		)
+		err := rows.Scan(
+			&result.ID, &result.Name, &result.Age, &result.Gender, &result.Email, &result.CreatedAt, &result.UpdatedAt, &result.Version, &result.DeletedAt,
+			&result.Rank, &nameMatch, &emailMatch,
+		)
+		if err != nil {
+			return nil, errors.Wrap(mapError(err), "scan search result")
+		}
+
+		result.Highlights = make(map[string]string)
+		if nameMatch != result.Name {
+			result.Highlights["name"] = nameMatch
+		}
+		if emailMatch != result.Email {
+			result.Highlights["email"] = emailMatch
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(mapError(err), "iterate search results")
+	}
+
+	slog.DebugContext(ctx, "searched users", slog.Int("count", len(results)))
+	return results, nil
+}
+
+// Update replaces the editable fields of a live user and then overwrites
+// user with the stored row, so fields the caller cannot set, such as
+// deleted_at, never come from the request.
 func (r *userRepository) Update(ctx context.Context, user *models.User) error {
 	query := `
         UPDATE users
-        SET name = $1, age = $2, gender = $3, email = $4
-        WHERE id = $5
+        SET name = $1, age = $2, gender = $3, email = $4, updated_at = CURRENT_TIMESTAMP, version = version + 1
+        WHERE id = $5 AND deleted_at IS NULL AND ($6::bigint = 0 OR version = $6)
+        RETURNING ` + userColumns + `
     `
 
-	result, err := r.conn.Exec(ctx, query, user.Name, user.Age, user.Gender, user.Email, user.ID)
+	tx, err := r.begin(ctx)
 	if err != nil {
-		return errors.Wrap(err, fmt.Sprintf("update user: id=%s", user.ID))
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	row := tx.QueryRow(ctx, query, user.Name, user.Age, user.Gender, user.Email, user.ID, user.Version)
+	if err := scanUser(row, user); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return r.writeConflict(ctx, user.ID)
+		}
+		return errors.Wrap(mapError(err), fmt.Sprintf("update user: id=%s", user.ID))
+	}
+
+	if err := enqueueUserEvents(ctx, tx, models.UserUpdatedEvent, user); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(mapError(err), fmt.Sprintf("commit update user: id=%s", user.ID))
+	}
+
+	slog.DebugContext(ctx, "updated user", slog.String("id", user.ID.String()))
+	return nil
+}
+
+func (r *userRepository) UpdateFields(ctx context.Context, id uuid.UUID, changes *models.UserChanges) (*models.User, error) {
+	var b queryBuilder
+	set := []string{"updated_at = CURRENT_TIMESTAMP", "version = version + 1"}
+	if changes.Name != nil {
+		set = append(set, "name = "+b.arg(*changes.Name))
+	}
+	if changes.Age != nil {
+		set = append(set, "age = "+b.arg(*changes.Age))
+	}
+	if changes.Gender != nil {
+		set = append(set, "gender = "+b.arg(*changes.Gender))
+	}
+	if changes.Email != nil {
+		set = append(set, "email = "+b.arg(*changes.Email))
+	}
+
+	idArg, versionArg := b.arg(id), b.arg(changes.Version)
+
+	query := fmt.Sprintf(`
+		UPDATE users
+		SET %s
+		WHERE id = %s AND deleted_at IS NULL AND (%s::bigint = 0 OR version = %s)
+		RETURNING %s
+	`, strings.Join(set, ", "), idArg, versionArg, versionArg, userColumns)
+
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var user models.User
+	row := tx.QueryRow(ctx, query, b.args...)
+	if err := scanUser(row, &user); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, r.writeConflict(ctx, id)
+		}
+		return nil, errors.Wrap(mapError(err), fmt.Sprintf("update user fields: id=%s", id))
+	}
+
+	if err := enqueueUserEvents(ctx, tx, models.UserUpdatedEvent, &user); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, errors.Wrap(mapError(err), fmt.Sprintf("commit update user fields: id=%s", id))
+	}
+
+	slog.DebugContext(ctx, "updated user fields", slog.String("id", id.String()))
+	return &user, nil
+}
+
+func (r *userRepository) Delete(ctx context.Context, id uuid.UUID, version int64) error {
+	query := `
+		UPDATE users
+		SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id=$1 AND deleted_at IS NULL AND ($2::bigint = 0 OR version = $2)
+		RETURNING ` + userColumns
+
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var user models.User
+	if err := scanUser(tx.QueryRow(ctx, query, id, version), &user); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return r.writeConflict(ctx, id)
+		}
+		return errors.Wrap(mapError(err), fmt.Sprintf("delete user: id=%s", id))
+	}
+
+	if err := enqueueUserEvents(ctx, tx, models.UserDeletedEvent, &user); err != nil {
+		return err
 	}
 
-	if result.RowsAffected() == 0 {
-		return apperr.ErrorNotFound
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(mapError(err), fmt.Sprintf("commit delete user: id=%s", id))
 	}
 
-	slog.Debug(fmt.Sprintf("updated successfully: id=%s", user.ID))
+	slog.DebugContext(ctx, "deleted user", slog.String("id", id.String()))
 	return nil
 }
 
-func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
+func (r *userRepository) Restore(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	query := `
+		UPDATE users
+		SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id=$1 AND deleted_at IS NOT NULL
+		RETURNING ` + userColumns
+
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var user models.User
+	row := tx.QueryRow(ctx, query, id)
+	if err := scanUser(row, &user); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperr.ErrorNotFound
+		}
+		return nil, errors.Wrap(mapError(err), fmt.Sprintf("restore user: id=%s", id))
+	}
+
+	// A restored user is visible again; consumers see it as an update.
+	if err := enqueueUserEvents(ctx, tx, models.UserUpdatedEvent, &user); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, errors.Wrap(mapError(err), fmt.Sprintf("commit restore user: id=%s", id))
+	}
+
+	slog.DebugContext(ctx, "restored user", slog.String("id", id.String()))
+	return &user, nil
+}
+
+// Purge removes soft-deleted users for good. Their user_history rows stay
+// for the audit trail, with the name and email blanked by the history
+// trigger.
+func (r *userRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
 	query := `
 		DELETE FROM users
-		WHERE id=$1
+		WHERE deleted_at < $1
 	`
 
-	result, err := r.conn.Exec(ctx, query, id)
+	result, err := r.conn.Exec(ctx, query, deletedBefore)
 	if err != nil {
-		return errors.Wrap(err, fmt.Sprintf("delete user: id=%s", id))
+		return 0, errors.Wrap(mapError(err), "purge users")
 	}
 
-	if result.RowsAffected() == 0 {
-		return apperr.ErrorNotFound
+	slog.DebugContext(ctx, "purged users", slog.Int64("count", result.RowsAffected()))
+	return result.RowsAffected(), nil
+}
+
+// History returns up to limit history entries of a user, newest first,
+// starting below the entry id before when it is not zero.
+func (r *userRepository) History(ctx context.Context, id uuid.UUID, before int64, limit int) ([]models.UserHistoryEntry, error) {
+	query := `
+		SELECT ` + userHistoryColumns + `
+		FROM user_history
+		WHERE user_id = $1 AND ($2::bigint = 0 OR id < $2)
+		ORDER BY id DESC
+		LIMIT $3
+	`
+
+	rows, err := r.conn.Query(ctx, query, id, before, limit)
+	if err != nil {
+		return nil, errors.Wrap(mapError(err), "list user history")
 	}
+	defer rows.Close()
 
-	slog.Debug(fmt.Sprintf("deleted successfully: id=%s", id))
-	return nil
+	entries := make([]models.UserHistoryEntry, 0, limit)
+	for rows.Next() {
+		var entry models.UserHistoryEntry
+		if err := scanUserHistoryEntry(rows, &entry); err != nil {
+			return nil, errors.Wrap(mapError(err), "scan user history")
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(mapError(err), "iterate user history")
+	}
+
+	slog.DebugContext(ctx, "listed user history", slog.String("id", id.String()), slog.Int("count", len(entries)))
+	return entries, nil
+}
+
+// GetAsOf reconstructs a user from the last history entry written at or
+// before at. A user deleted by then is returned with DeletedAt set.
+func (r *userRepository) GetAsOf(ctx context.Context, id uuid.UUID, at time.Time) (*models.User, error) {
+	query := `
+		SELECT after, version
+		FROM user_history
+		WHERE user_id = $1 AND changed_at <= $2
+		ORDER BY changed_at DESC, id DESC
+		LIMIT 1
+	`
+
+	var user models.User
+	var version int64
+	if err := r.conn.QueryRow(ctx, query, id, at).Scan(&user, &version); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperr.ErrorNotFound
+		}
+		return nil, errors.Wrap(mapError(err), fmt.Sprintf("get user as of: id=%s", id))
+	}
+	user.Version = version
+
+	slog.DebugContext(ctx, "reconstructed user", slog.String("id", id.String()), slog.Time("as_of", at))
+	return &user, nil
+}
+
+// writeConflict explains why a versioned write touched no rows: either the
+// user is gone or it was changed since the caller read it.
+func (r *userRepository) writeConflict(ctx context.Context, id uuid.UUID) error {
+	var version int64
+	err := r.conn.QueryRow(ctx, `SELECT version FROM users WHERE id=$1 AND deleted_at IS NULL`, id).Scan(&version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apperr.ErrorNotFound
+		}
+		return errors.Wrap(mapError(err), fmt.Sprintf("get user version: id=%s", id))
+	}
+
+	return apperr.ErrorPreconditionFailed
 }
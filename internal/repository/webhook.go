@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/pkg/errors"
+)
+
+const webhookDeliveryColumns = `d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.status, d.attempts,
+	d.next_attempt_at, d.last_status_code, coalesce(d.last_error, ''), d.replay_of, d.created_at, d.updated_at, d.delivered_at`
+
+type webhookRepository struct {
+	conn *pgxpool.Pool
+}
+
+func NewWebhookRepository(conn *pgxpool.Pool) WebhookProvider {
+	return &webhookRepository{
+		conn: conn,
+	}
+}
+
+func scanWebhookDelivery(row scanner, delivery *models.WebhookDelivery, extra ...any) error {
+	dest := []any{
+		&delivery.ID, &delivery.SubscriptionID, &delivery.EventID, &delivery.EventType, &delivery.Payload, &delivery.Status, &delivery.Attempts,
+		&delivery.NextAttemptAt, &delivery.LastStatusCode, &delivery.LastError, &delivery.ReplayOf, &delivery.CreatedAt, &delivery.UpdatedAt, &delivery.DeliveredAt,
+	}
+	return row.Scan(append(dest, extra...)...)
+}
+
+func (r *webhookRepository) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	query := `
+		INSERT INTO webhook_subscriptions(id, url, event_types, secret)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at
+	`
+
+	row := r.conn.QueryRow(ctx, query, subscription.ID, subscription.URL, subscription.EventTypes, subscription.Secret)
+	if err := row.Scan(&subscription.CreatedAt); err != nil {
+		return errors.Wrap(mapError(err), "create webhook subscription")
+	}
+
+	slog.DebugContext(ctx, "created webhook subscription", slog.String("id", subscription.ID.String()))
+	return nil
+}
+
+// ListSubscriptions returns every subscription without its secret.
+func (r *webhookRepository) ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	query := `
+		SELECT id, url, event_types, created_at
+		FROM webhook_subscriptions
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.conn.Query(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(mapError(err), "list webhook subscriptions")
+	}
+
+	subscriptions, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.WebhookSubscription, error) {
+		var subscription models.WebhookSubscription
+		err := row.Scan(&subscription.ID, &subscription.URL, &subscription.EventTypes, &subscription.CreatedAt)
+		return subscription, err
+	})
+	if err != nil {
+		return nil, errors.Wrap(mapError(err), "scan webhook subscriptions")
+	}
+
+	return subscriptions, nil
+}
+
+// DeleteSubscription removes a subscription together with its delivery log.
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	result, err := r.conn.Exec(ctx, `DELETE FROM webhook_subscriptions WHERE id=$1`, id)
+	if err != nil {
+		return errors.Wrap(mapError(err), fmt.Sprintf("delete webhook subscription: id=%s", id))
+	}
+	if result.RowsAffected() == 0 {
+		return apperr.ErrorNotFound
+	}
+
+	slog.DebugContext(ctx, "deleted webhook subscription", slog.String("id", id.String()))
+	return nil
+}
+
+// EnqueueDeliveries creates a pending delivery of event for every matching
+// subscription. Enqueueing the same event again adds nothing.
+func (r *webhookRepository) EnqueueDeliveries(ctx context.Context, event *models.OutboxEvent) (int64, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return 0, errors.Wrap(err, "encode webhook payload")
+	}
+
+	query := `
+		INSERT INTO webhook_deliveries(subscription_id, event_id, event_type, payload)
+		SELECT id, $1::uuid, $2::text, $3::jsonb
+		FROM webhook_subscriptions
+		WHERE cardinality(event_types) = 0 OR $2::text = ANY(event_types)
+		ON CONFLICT DO NOTHING
+	`
+
+	result, err := r.conn.Exec(ctx, query, event.ID, event.Type, payload)
+	if err != nil {
+		return 0, errors.Wrap(mapError(err), "enqueue webhook deliveries")
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// ClaimDeliveries leases up to limit due deliveries together with the URL
+// and secret of their subscription: other dispatchers skip them until
+// lease has passed, without a transaction or row lock being held while
+// they are sent. A delivery whose attempt is not recorded before the lease
+// ends is claimed again.
+func (r *webhookRepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	query := `
+		WITH claimed AS (
+			UPDATE webhook_deliveries d
+			SET next_attempt_at = CURRENT_TIMESTAMP + $3::interval
+			FROM (
+				SELECT id
+				FROM webhook_deliveries
+				WHERE status = $1 AND next_attempt_at <= CURRENT_TIMESTAMP
+				ORDER BY id
+				LIMIT $2
+				FOR UPDATE SKIP LOCKED
+			) due
+			WHERE d.id = due.id
+			RETURNING d.*
+		)
+		SELECT ` + webhookDeliveryColumns + `, s.url, s.secret
+		FROM claimed d
+		JOIN webhook_subscriptions s ON s.id = d.subscription_id
+		ORDER BY d.id
+	`
+
+	rows, err := r.conn.Query(ctx, query, models.WebhookPending, limit, lease)
+	if err != nil {
+		return nil, errors.Wrap(mapError(err), "claim webhook deliveries")
+	}
+	deliveries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.WebhookDelivery, error) {
+		var delivery models.WebhookDelivery
+		err := scanWebhookDelivery(row, &delivery, &delivery.URL, &delivery.Secret)
+		return delivery, err
+	})
+	if err != nil {
+		return nil, errors.Wrap(mapError(err), "scan webhook deliveries")
+	}
+
+	return deliveries, nil
+}
+
+// RecordAttempt logs an attempt at a delivery and moves it to status. A
+// delivery left pending is due again after retryIn.
+func (r *webhookRepository) RecordAttempt(
+	ctx context.Context,
+	id int64,
+	attempt models.WebhookAttempt,
+	status models.WebhookDeliveryStatus,
+	retryIn time.Duration,
+) error {
+	var statusCode *int
+	if attempt.StatusCode != 0 {
+		statusCode = &attempt.StatusCode
+	}
+
+	if attempt.Err == nil {
+		query := `
+			UPDATE webhook_deliveries
+			SET status = $2, attempts = attempts + 1, last_status_code = $3, last_error = NULL,
+				delivered_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1
+		`
+		if _, err := r.conn.Exec(ctx, query, id, status, statusCode); err != nil {
+			return errors.Wrap(mapError(err), fmt.Sprintf("mark webhook delivered: id=%d", id))
+		}
+		return nil
+	}
+
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = attempts + 1, last_status_code = $3, last_error = $4,
+			next_attempt_at = CURRENT_TIMESTAMP + $5::interval, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+	if _, err := r.conn.Exec(ctx, query, id, status, statusCode, attempt.Err.Error(), retryIn); err != nil {
+		return errors.Wrap(mapError(err), fmt.Sprintf("reschedule webhook delivery: id=%d", id))
+	}
+	return nil
+}
+
+// ListDeliveries returns the delivery log of a subscription, newest first.
+func (r *webhookRepository) ListDeliveries(ctx context.Context, filter *models.WebhookDeliveryFilter) ([]models.WebhookDelivery, error) {
+	var b queryBuilder
+	b.where("d.subscription_id = %s", filter.SubscriptionID)
+	if filter.Status != "" {
+		b.where("d.status = %s", filter.Status)
+	}
+	if filter.Before != 0 {
+		b.where("d.id < %s", filter.Before)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM webhook_deliveries d
+		%s
+		ORDER BY d.id DESC
+		LIMIT %s
+	`, webhookDeliveryColumns, b.whereClause(), b.arg(filter.Limit))
+
+	rows, err := r.conn.Query(ctx, query, b.args...)
+	if err != nil {
+		return nil, errors.Wrap(mapError(err), "list webhook deliveries")
+	}
+
+	deliveries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.WebhookDelivery, error) {
+		var delivery models.WebhookDelivery
+		err := scanWebhookDelivery(row, &delivery)
+		return delivery, err
+	})
+	if err != nil {
+		return nil, errors.Wrap(mapError(err), "scan webhook deliveries")
+	}
+
+	return deliveries, nil
+}
+
+func (r *webhookRepository) GetDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, error) {
+	query := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries d
+		WHERE d.id = $1
+	`
+
+	var delivery models.WebhookDelivery
+	if err := scanWebhookDelivery(r.conn.QueryRow(ctx, query, id), &delivery); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperr.ErrorNotFound
+		}
+		return nil, errors.Wrap(mapError(err), fmt.Sprintf("get webhook delivery: id=%d", id))
+	}
+
+	return &delivery, nil
+}
+
+// ReplayDelivery queues a fresh copy of a delivery, whatever its state,
+// and leaves the original in the log.
+func (r *webhookRepository) ReplayDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, error) {
+	query := `
+		WITH d AS (
+			INSERT INTO webhook_deliveries(subscription_id, event_id, event_type, payload, replay_of)
+			SELECT subscription_id, event_id, event_type, payload, id
+			FROM webhook_deliveries
+			WHERE id = $1
+			RETURNING *
+		)
+		SELECT ` + webhookDeliveryColumns + `
+		FROM d
+	`
+
+	var delivery models.WebhookDelivery
+	if err := scanWebhookDelivery(r.conn.QueryRow(ctx, query, id), &delivery); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperr.ErrorNotFound
+		}
+		return nil, errors.Wrap(mapError(err), fmt.Sprintf("replay webhook delivery: id=%d", id))
+	}
+
+	slog.DebugContext(ctx, "replayed webhook delivery", slog.Int64("id", id), slog.Int64("replay", delivery.ID))
+	return &delivery, nil
+}
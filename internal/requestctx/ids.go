@@ -0,0 +1,22 @@
+package requestctx
+
+import "github.com/google/uuid"
+
+// maxRequestIDLength bounds ids taken from clients, as they end up in logs,
+// audit rows and outbox events.
+const maxRequestIDLength = 128
+
+// EnsureRequestID returns requestID when it is usable and a new UUID when
+// it is missing or holds anything but up to 128 printable ASCII characters.
+func EnsureRequestID(requestID string) string {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return uuid.NewString()
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < '!' || requestID[i] > '~' {
+			return uuid.NewString()
+		}
+	}
+
+	return requestID
+}
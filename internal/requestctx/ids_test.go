@@ -0,0 +1,39 @@
+package requestctx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestEnsureRequestID(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+		keep      bool
+	}{
+		{name: "client id", requestID: "a1b2-c3", keep: true},
+		{name: "longest id", requestID: strings.Repeat("x", maxRequestIDLength), keep: true},
+		{name: "missing", requestID: ""},
+		{name: "too long", requestID: strings.Repeat("x", maxRequestIDLength+1)},
+		{name: "space", requestID: "a b"},
+		{name: "newline", requestID: "a\nlevel=ERROR"},
+		{name: "non ASCII", requestID: "idé"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EnsureRequestID(tt.requestID)
+			if tt.keep {
+				if got != tt.requestID {
+					t.Fatalf("EnsureRequestID(%q) = %q, want it kept", tt.requestID, got)
+				}
+				return
+			}
+			if _, err := uuid.Parse(got); err != nil {
+				t.Fatalf("EnsureRequestID(%q) = %q, want a new UUID", tt.requestID, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+// Package requestctx carries who made a request and under which id through
+// the context, so lower layers can record it without knowing about HTTP.
+package requestctx
+
+import (
+	"context"
+
+	"github.com/krackl1n/golang-project/internal/models"
+)
+
+type contextKey int
+
+const (
+	actorKey contextKey = iota
+	requestIDKey
+	principalKey
+	routeKey
+	pathKey
+)
+
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// Actor returns the principal acting in ctx, or "" when it is unknown.
+func Actor(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey).(string)
+	return actor
+}
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID returns the id of the request behind ctx, or "" outside of one.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// WithRoute stores the RPC method a request was made for.
+func WithRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeKey, route)
+}
+
+// Route returns the route of the request behind ctx, or "" outside of one.
+func Route(ctx context.Context) string {
+	route, _ := ctx.Value(routeKey).(string)
+	return route
+}
+
+type target struct {
+	method string
+	path   string
+}
+
+// WithPath stores the HTTP method and the path a request was made for. The
+// path is the one the client sent, not the route it matched.
+func WithPath(ctx context.Context, method, path string) context.Context {
+	return context.WithValue(ctx, pathKey, target{method: method, path: path})
+}
+
+// Path returns the HTTP method and path of the request behind ctx, or empty
+// strings outside of one.
+func Path(ctx context.Context) (method, path string) {
+	t, _ := ctx.Value(pathKey).(target)
+	return t.method, t.path
+}
+
+// WithPrincipal stores the authenticated caller and makes its subject the
+// actor of ctx.
+func WithPrincipal(ctx context.Context, principal *models.Principal) context.Context {
+	ctx = WithActor(ctx, principal.Subject)
+	return context.WithValue(ctx, principalKey, principal)
+}
+
+// Principal returns the authenticated caller of ctx, or nil when the
+// request was not authenticated.
+func Principal(ctx context.Context) *models.Principal {
+	principal, _ := ctx.Value(principalKey).(*models.Principal)
+	return principal
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/krackl1n/golang-project/internal/tracing"
 	"github.com/pkg/errors"
 )
 
@@ -12,10 +13,20 @@ func GetConnect(connString string) (*pgxpool.Pool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	conn, err := pgxpool.New(ctx, connString)
+	poolConfig, err := pgxpool.ParseConfig(connString)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse connection string")
+	}
+	poolConfig.ConnConfig.Tracer = tracing.PgxTracer{}
+
+	conn, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "create pool")
+	}
 
 	if err := conn.Ping(ctx); err != nil {
+		conn.Close()
 		return nil, errors.Wrap(err, "ping database")
 	}
-	return conn, errors.Wrap(err, "get connections")
+	return conn, nil
 }
@@ -0,0 +1,99 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans routes the spans of the service to a recorder for the rest
+// of the test.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestPgxTracerQuerySpans(t *testing.T) {
+	recorder := recordSpans(t)
+	tracer := PgxTracer{}
+
+	parentCtx, parent := Tracer().Start(context.Background(), "usecase")
+	tests := []struct {
+		sql  string
+		tag  string
+		err  error
+		name string
+		rows int64
+		fail bool
+	}{
+		{sql: "  update users set name = $1", tag: "UPDATE 3", name: "UPDATE", rows: 3},
+		{sql: "with x as (select 1) select * from x", tag: "SELECT 1", name: "WITH", rows: 1},
+		{sql: "SELECT * FROM users WHERE id = $1", tag: "SELECT 0", err: pgx.ErrNoRows, name: "SELECT"},
+		{sql: "INSERT INTO users VALUES ($1)", err: errors.New("duplicate key"), name: "INSERT", fail: true},
+		{sql: "", name: "QUERY"},
+	}
+	for _, tt := range tests {
+		ctx := tracer.TraceQueryStart(parentCtx, nil, pgx.TraceQueryStartData{SQL: tt.sql})
+		tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag(tt.tag), Err: tt.err})
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != len(tests)+1 {
+		t.Fatalf("got %d spans, want %d", len(spans), len(tests)+1)
+	}
+	for i, tt := range tests {
+		span := spans[i]
+		if span.Name() != tt.name || span.SpanKind() != trace.SpanKindClient || span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("%q: span %s of kind %s under %s", tt.sql, span.Name(), span.SpanKind(), span.Parent().SpanID())
+		}
+		if failed := span.Status().Code == codes.Error; failed != tt.fail {
+			t.Errorf("%q: status %v, want failed %v", tt.sql, span.Status(), tt.fail)
+		}
+		if tt.rows > 0 && !slices.Contains(span.Attributes(), semconv.DBResponseReturnedRows(int(tt.rows))) {
+			t.Errorf("%q: attributes %v, want %d rows", tt.sql, span.Attributes(), tt.rows)
+		}
+		if !slices.Contains(span.Attributes(), semconv.DBSystemNamePostgreSQL) {
+			t.Errorf("%q: attributes %v, want the postgresql system", tt.sql, span.Attributes())
+		}
+	}
+}
+
+func TestPgxTracerBatchSpan(t *testing.T) {
+	recorder := recordSpans(t)
+	tracer := PgxTracer{}
+
+	batch := &pgx.Batch{}
+	batch.Queue("SELECT 1")
+	batch.Queue("SELECT 2")
+	ctx := tracer.TraceBatchStart(context.Background(), nil, pgx.TraceBatchStartData{Batch: batch})
+	tracer.TraceBatchQuery(ctx, nil, pgx.TraceBatchQueryData{SQL: "SELECT 1"})
+	tracer.TraceBatchQuery(ctx, nil, pgx.TraceBatchQueryData{SQL: "SELECT 2", Err: errors.New("boom")})
+	tracer.TraceBatchEnd(ctx, nil, pgx.TraceBatchEndData{Err: errors.New("boom")})
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want one for the batch", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "BATCH" || !slices.Contains(span.Attributes(), semconv.DBOperationBatchSize(2)) || len(span.Events()) != 3 {
+		t.Fatalf("span %s with attributes %v and %d events, want a batch of 2 with two query events and the error", span.Name(), span.Attributes(), len(span.Events()))
+	}
+	if span.Status().Code != codes.Error {
+		t.Fatalf("status = %v, want the failed batch marked", span.Status())
+	}
+}
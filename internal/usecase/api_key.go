@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/repository"
+	"github.com/krackl1n/golang-project/internal/requestctx"
+	"github.com/pkg/errors"
+)
+
+// apiKeyMarker starts every key, so leaked keys are easy to recognise and
+// to search for.
+const apiKeyMarker = "uk"
+
+type apiKeyUC struct {
+	apiKeyRepository repository.APIKeyProvider
+}
+
+func NewAPIKey(apiKeyRepository repository.APIKeyProvider) APIKeyProvider {
+	return &apiKeyUC{
+		apiKeyRepository: apiKeyRepository,
+	}
+}
+
+// IssueAPIKey creates a key of the form uk_<prefix>_<secret>. The prefix
+// identifies the key and stays visible in listings; the whole key is
+// returned only here.
+func (uc *apiKeyUC) IssueAPIKey(ctx context.Context, createAPIKeyDTO *models.CreateAPIKeyDTO) (*models.APIKey, error) {
+	if createAPIKeyDTO.ExpiresAt != nil && !createAPIKeyDTO.ExpiresAt.After(time.Now()) {
+		return nil, &apperr.ValidationError{Field: "expires_at", Reason: "must be in the future"}
+	}
+
+	keyId, err := uuid.NewV7()
+	if err != nil {
+		return nil, errors.Wrap(err, "generate UUID")
+	}
+
+	random := make([]byte, 6+32)
+	if _, err := rand.Read(random); err != nil {
+		return nil, errors.Wrap(err, "generate api key")
+	}
+	prefix := apiKeyMarker + "_" + hex.EncodeToString(random[:6])
+	plain := prefix + "_" + hex.EncodeToString(random[6:])
+	hash := sha256.Sum256([]byte(plain))
+
+	scopes := slices.Clone(createAPIKeyDTO.Scopes)
+	slices.Sort(scopes)
+
+	key := &models.APIKey{
+		ID:         keyId,
+		Name:       createAPIKeyDTO.Name,
+		Prefix:     prefix,
+		Scopes:     slices.Compact(scopes),
+		ExpiresAt:  createAPIKeyDTO.ExpiresAt,
+		CreatedBy:  requestctx.Actor(ctx),
+		SecretHash: hash[:],
+	}
+	if err := uc.apiKeyRepository.Create(ctx, key); err != nil {
+		return nil, errors.Wrap(err, "issue api key")
+	}
+
+	key.Key = plain
+	return key, nil
+}
+
+func (uc *apiKeyUC) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	keys, err := uc.apiKeyRepository.List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "list api keys")
+	}
+
+	return keys, nil
+}
+
+func (uc *apiKeyUC) RevokeAPIKey(ctx context.Context, id uuid.UUID) (*models.APIKey, error) {
+	key, err := uc.apiKeyRepository.Revoke(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "revoke api key")
+	}
+
+	return key, nil
+}
+
+// AuthenticateAPIKey returns the principal of a key that exists, matches
+// its stored hash and is neither revoked nor expired.
+func (uc *apiKeyUC) AuthenticateAPIKey(ctx context.Context, plain string) (*models.Principal, error) {
+	parts := strings.Split(plain, "_")
+	if len(parts) != 3 || parts[0] != apiKeyMarker {
+		return nil, errors.Wrap(apperr.ErrorUnauthenticated, "malformed api key")
+	}
+
+	key, err := uc.apiKeyRepository.GetByPrefix(ctx, parts[0]+"_"+parts[1])
+	if err != nil {
+		if errors.Is(err, apperr.ErrorNotFound) {
+			return nil, errors.Wrap(apperr.ErrorUnauthenticated, "unknown api key")
+		}
+		return nil, errors.Wrap(err, "get api key")
+	}
+
+	hash := sha256.Sum256([]byte(plain))
+	if subtle.ConstantTimeCompare(hash[:], key.SecretHash) != 1 {
+		return nil, errors.Wrap(apperr.ErrorUnauthenticated, "api key secret mismatch")
+	}
+	if key.RevokedAt != nil {
+		return nil, errors.Wrap(apperr.ErrorUnauthenticated, "api key revoked")
+	}
+	if key.ExpiresAt != nil && !key.ExpiresAt.After(time.Now()) {
+		return nil, errors.Wrap(apperr.ErrorUnauthenticated, "api key expired")
+	}
+
+	// A failed bookkeeping write must not lock the client out.
+	if err := uc.apiKeyRepository.TouchLastUsed(ctx, key.ID); err != nil {
+		slog.WarnContext(ctx, "touch api key", slog.String("id", key.ID.String()), slog.Any("error", err))
+	}
+
+	return &models.Principal{
+		Subject: "api_key:" + key.ID.String(),
+		Scopes:  key.Scopes,
+		Roles:   []string{models.RoleService},
+	}, nil
+}
@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/repository"
+	"github.com/pkg/errors"
+)
+
+// fakeAPIKeyRepository keeps keys by prefix.
+type fakeAPIKeyRepository struct {
+	repository.APIKeyProvider
+	keys    map[string]*models.APIKey
+	touched []uuid.UUID
+}
+
+func (r *fakeAPIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	stored := *key
+	r.keys[key.Prefix] = &stored
+	return nil
+}
+
+func (r *fakeAPIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	key, ok := r.keys[prefix]
+	if !ok {
+		return nil, apperr.ErrorNotFound
+	}
+	return key, nil
+}
+
+func (r *fakeAPIKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID) error {
+	r.touched = append(r.touched, id)
+	return nil
+}
+
+func TestIssueAPIKey(t *testing.T) {
+	repo := &fakeAPIKeyRepository{keys: map[string]*models.APIKey{}}
+	uc := NewAPIKey(repo)
+
+	key, err := uc.IssueAPIKey(context.Background(), &models.CreateAPIKeyDTO{
+		Name:   "ci",
+		Scopes: []string{models.ScopeUsersWrite, models.ScopeUsersRead, models.ScopeUsersWrite},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(key.Key, key.Prefix+"_") || !strings.HasPrefix(key.Prefix, "uk_") {
+		t.Fatalf("key %q with prefix %q, want uk_<prefix>_<secret>", key.Key, key.Prefix)
+	}
+	if want := []string{models.ScopeUsersRead, models.ScopeUsersWrite}; !slices.Equal(key.Scopes, want) {
+		t.Fatalf("scopes = %v, want %v", key.Scopes, want)
+	}
+	stored := repo.keys[key.Prefix]
+	hash := sha256.Sum256([]byte(key.Key))
+	if stored.Key != "" || !bytes.Equal(stored.SecretHash, hash[:]) {
+		t.Fatal("stored key keeps the plain secret, want only its hash")
+	}
+
+	past := time.Now().Add(-time.Minute)
+	_, err = uc.IssueAPIKey(context.Background(), &models.CreateAPIKeyDTO{Name: "old", ExpiresAt: &past})
+	var validationErr *apperr.ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Field != "expires_at" {
+		t.Fatalf("expired key: err = %v, want a validation error on expires_at", err)
+	}
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	repo := &fakeAPIKeyRepository{keys: map[string]*models.APIKey{}}
+	uc := NewAPIKey(repo)
+	ctx := context.Background()
+
+	issue := func() *models.APIKey {
+		key, err := uc.IssueAPIKey(ctx, &models.CreateAPIKeyDTO{Name: "ci", Scopes: []string{models.ScopeUsersRead}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+
+	valid := issue()
+	principal, err := uc.AuthenticateAPIKey(ctx, valid.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if principal.Subject != "api_key:"+valid.ID.String() || !principal.HasScope(models.ScopeUsersRead) || !slices.Equal(principal.Roles, []string{models.RoleService}) {
+		t.Fatalf("principal = %+v", principal)
+	}
+	if !slices.Equal(repo.touched, []uuid.UUID{valid.ID}) {
+		t.Fatalf("touched = %v, want the last use of %s recorded", repo.touched, valid.ID)
+	}
+
+	revoked := issue()
+	now := time.Now()
+	repo.keys[revoked.Prefix].RevokedAt = &now
+
+	expired := issue()
+	past := now.Add(-time.Minute)
+	repo.keys[expired.Prefix].ExpiresAt = &past
+
+	tests := []struct {
+		name string
+		key  string
+	}{
+		{name: "malformed", key: "not-a-key"},
+		{name: "foreign marker", key: strings.Replace(valid.Key, "uk_", "xx_", 1)},
+		{name: "unknown prefix", key: "uk_000000000000_" + strings.Repeat("0", 64)},
+		{name: "wrong secret", key: valid.Prefix + "_" + strings.Repeat("0", 64)},
+		{name: "revoked", key: revoked.Key},
+		{name: "expired", key: expired.Key},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := uc.AuthenticateAPIKey(ctx, tt.key); !errors.Is(err, apperr.ErrorUnauthenticated) {
+				t.Fatalf("err = %v, want unauthenticated", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/krackl1n/golang-project/config"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/policy"
+	"github.com/pkg/errors"
+)
+
+func newTestBatchUC(users *fakeUserRepository) UserProvider {
+	cfg := &config.Config{ConfigBatch: config.ConfigBatch{MaxSize: 3}}
+	return New(users, cfg, policy.Default())
+}
+
+func testBatch() []models.CreateUserDTO {
+	return []models.CreateUserDTO{
+		{Name: "Ann", Age: 30, Gender: "female", Email: "ann@example.com"},
+		{Name: "Bob", Age: 130, Gender: "male", Email: "bob@example.com"},
+		{Name: "Eve", Age: 40, Gender: "female", Email: "eve@example.com"},
+	}
+}
+
+func TestCreateUsersBestEffort(t *testing.T) {
+	users := &fakeUserRepository{takenEmails: map[string]bool{"eve@example.com": true}}
+
+	results, err := newTestBatchUC(users).CreateUsers(context.Background(), testBatch(), models.BatchBestEffort)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var validationErrs validator.ValidationErrors
+	switch {
+	case results[0].Err != nil || results[0].User == nil || results[0].User.Email != "ann@example.com":
+		t.Errorf("item 0 = %+v, want it created", results[0])
+	case !errors.As(results[1].Err, &validationErrs) || validationErrs[0].Field() != "age":
+		t.Errorf("item 1 error = %v, want the age out of range", results[1].Err)
+	case !errors.Is(results[2].Err, apperr.ErrorConflict):
+		t.Errorf("item 2 error = %v, want a conflict", results[2].Err)
+	}
+	for i, result := range results {
+		if result.Index != i {
+			t.Errorf("result %d has index %d", i, result.Index)
+		}
+	}
+	if len(users.inserted) != 1 {
+		t.Errorf("inserted %d users, want 1", len(users.inserted))
+	}
+}
+
+func TestCreateUsersAtomicAbortsOnInvalidItem(t *testing.T) {
+	users := &fakeUserRepository{}
+
+	results, err := newTestBatchUC(users).CreateUsers(context.Background(), testBatch(), models.BatchAtomic)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(users.inserted) != 0 {
+		t.Fatalf("inserted %d users, want none", len(users.inserted))
+	}
+	for _, i := range []int{0, 2} {
+		if results[i].Err != apperr.ErrorBatchAborted {
+			t.Errorf("item %d error = %v, want %v", i, results[i].Err, apperr.ErrorBatchAborted)
+		}
+	}
+	if results[1].Err == nil || results[1].Err == apperr.ErrorBatchAborted {
+		t.Errorf("item 1 error = %v, want its own validation error", results[1].Err)
+	}
+}
+
+func TestCreateUsersRejectsBatchSize(t *testing.T) {
+	uc := newTestBatchUC(&fakeUserRepository{})
+
+	for _, dtos := range [][]models.CreateUserDTO{nil, append(testBatch(), testBatch()[0])} {
+		var validationErr *apperr.ValidationError
+		if _, err := uc.CreateUsers(context.Background(), dtos, models.BatchBestEffort); !errors.As(err, &validationErr) || validationErr.Field != "users" {
+			t.Errorf("CreateUsers() of %d users = %v, want a validation error on users", len(dtos), err)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/models"
+)
+
+// cursor is the keyset position of the last user on a page. User ids are
+// UUIDv7, so ordering by id is ordering by creation time. For other sort
+// fields the sort key of that user is kept as text in Value.
+type cursor struct {
+	ID    uuid.UUID            `json:"id"`
+	Sort  models.UserSortField `json:"s,omitempty"`
+	Desc  bool                 `json:"d,omitempty"`
+	Value string               `json:"v,omitempty"`
+}
+
+func newCursor(user *models.User, sort models.UserSort) cursor {
+	cur := cursor{
+		ID:   user.ID,
+		Sort: sort.Field,
+		Desc: sort.Desc,
+	}
+
+	switch sort.Field {
+	case models.UserSortByName:
+		cur.Value = user.Name
+	case models.UserSortByAge:
+		cur.Value = strconv.Itoa(int(user.Age))
+	case models.UserSortByCreatedAt:
+		cur.Value = user.CreatedAt.Format(time.RFC3339Nano)
+	case models.UserSortByUpdatedAt:
+		cur.Value = user.UpdatedAt.Format(time.RFC3339Nano)
+	}
+
+	return cur
+}
+
+// position converts the cursor back into a typed keyset position. A cursor
+// is only valid for the sort order it was issued for.
+func (cur cursor) position(sort models.UserSort) (*models.UserPosition, error) {
+	if cur.Sort != sort.Field || cur.Desc != sort.Desc {
+		return nil, apperr.ErrorInvalidCursor
+	}
+
+	pos := &models.UserPosition{
+		ID: cur.ID,
+	}
+
+	var err error
+	switch sort.Field {
+	case models.UserSortByName:
+		pos.Value = cur.Value
+	case models.UserSortByAge:
+		var age uint64
+		age, err = strconv.ParseUint(cur.Value, 10, 8)
+		pos.Value = uint8(age)
+	case models.UserSortByCreatedAt, models.UserSortByUpdatedAt:
+		pos.Value, err = time.Parse(time.RFC3339Nano, cur.Value)
+	}
+	if err != nil {
+		return nil, apperr.ErrorInvalidCursor
+	}
+
+	return pos, nil
+}
+
+func encodeCursor(cur cursor) string {
+	data, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var cur cursor
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cur, apperr.ErrorInvalidCursor
+	}
+	if err := json.Unmarshal(data, &cur); err != nil || cur.ID == uuid.Nil {
+		return cur, apperr.ErrorInvalidCursor
+	}
+
+	return cur, nil
+}
+
+// Sequence cursors hold the id of the last row on a page of rows that are
+// paged in descending id order, such as history entries and deliveries.
+func encodeSeqCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodeSeqCursor(s string) (int64, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return 0, apperr.ErrorInvalidCursor
+	}
+
+	id, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, apperr.ErrorInvalidCursor
+	}
+
+	return id, nil
+}
@@ -0,0 +1,167 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/config"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/policy"
+	"github.com/krackl1n/golang-project/internal/repository"
+)
+
+// fakeListRepository pages through users kept in id order, the way the
+// keyset query does.
+type fakeListRepository struct {
+	repository.UserProvider
+	users   []models.User
+	filters []models.UserListFilter
+}
+
+func (r *fakeListRepository) List(ctx context.Context, filter *models.UserListFilter) ([]models.User, error) {
+	r.filters = append(r.filters, *filter)
+
+	users := r.users
+	if filter.After != nil {
+		i := slices.IndexFunc(users, func(user models.User) bool { return user.ID.String() > filter.After.ID.String() })
+		if i < 0 {
+			i = len(users)
+		}
+		users = users[i:]
+	}
+	return users[:min(filter.Limit, len(users))], nil
+}
+
+func newTestUserUC(users repository.UserProvider) UserProvider {
+	cfg := &config.Config{ConfigPagination: config.ConfigPagination{DefaultPageSize: 2, MaxPageSize: 3}}
+	return New(users, cfg, policy.Default())
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	user := &models.User{ID: uuid.Must(uuid.NewV7())}
+	sort := models.UserSort{Field: models.UserSortByID}
+
+	cur, err := decodeCursor(encodeCursor(newCursor(user, sort)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pos, err := cur.position(sort)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos.ID != user.ID {
+		t.Fatalf("position id = %s, want %s", pos.ID, user.ID)
+	}
+}
+
+func TestDecodeCursorRejectsGarbage(t *testing.T) {
+	for _, s := range []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("not json")),
+		base64.RawURLEncoding.EncodeToString([]byte(`{"id":"00000000-0000-0000-0000-000000000000"}`)),
+	} {
+		if _, err := decodeCursor(s); err != apperr.ErrorInvalidCursor {
+			t.Errorf("decodeCursor(%q) = %v, want %v", s, err, apperr.ErrorInvalidCursor)
+		}
+	}
+}
+
+func TestListUsersPagesThroughEveryUser(t *testing.T) {
+	repo := &fakeListRepository{}
+	for range 5 {
+		repo.users = append(repo.users, models.User{ID: uuid.Must(uuid.NewV7())})
+	}
+	uc := newTestUserUC(repo)
+
+	var seen []uuid.UUID
+	criteria := &models.UserCriteria{}
+	for pages := 1; ; pages++ {
+		page, err := uc.ListUsers(context.Background(), criteria)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, user := range page.Users {
+			seen = append(seen, user.ID)
+		}
+		if page.NextCursor == "" {
+			if pages != 3 {
+				t.Fatalf("got %d pages, want 3 of the default size 2", pages)
+			}
+			break
+		}
+		criteria.Cursor = page.NextCursor
+	}
+
+	var want []uuid.UUID
+	for _, user := range repo.users {
+		want = append(want, user.ID)
+	}
+	if !slices.Equal(seen, want) {
+		t.Fatalf("paged through %v, want %v", seen, want)
+	}
+	for _, filter := range repo.filters {
+		if filter.Limit != 3 {
+			t.Fatalf("repository asked for %d users, want one more than the page size", filter.Limit)
+		}
+	}
+}
+
+func TestListUsersClampsThePageSize(t *testing.T) {
+	repo := &fakeListRepository{}
+	if _, err := newTestUserUC(repo).ListUsers(context.Background(), &models.UserCriteria{Limit: 1000}); err != nil {
+		t.Fatal(err)
+	}
+	if repo.filters[0].Limit != 4 {
+		t.Fatalf("repository asked for %d users, want the maximum page size plus one", repo.filters[0].Limit)
+	}
+}
+
+func TestCursorKeepsTheSortKey(t *testing.T) {
+	updated := time.Date(2025, 5, 1, 12, 30, 0, 123456789, time.UTC)
+	user := &models.User{ID: uuid.Must(uuid.NewV7()), Name: "Ann", Age: 42, CreatedAt: updated.Add(-time.Hour), UpdatedAt: updated}
+
+	tests := []struct {
+		sort models.UserSort
+		want any
+	}{
+		{sort: models.UserSort{Field: models.UserSortByName}, want: "Ann"},
+		{sort: models.UserSort{Field: models.UserSortByAge, Desc: true}, want: uint8(42)},
+		{sort: models.UserSort{Field: models.UserSortByCreatedAt}, want: user.CreatedAt},
+		{sort: models.UserSort{Field: models.UserSortByUpdatedAt, Desc: true}, want: updated},
+	}
+
+	for _, tt := range tests {
+		cur, err := decodeCursor(encodeCursor(newCursor(user, tt.sort)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pos, err := cur.position(tt.sort)
+		if err != nil {
+			t.Fatalf("%+v: %v", tt.sort, err)
+		}
+		if pos.ID != user.ID || !reflect.DeepEqual(pos.Value, tt.want) {
+			t.Errorf("%+v: position = %+v, want %v", tt.sort, pos, tt.want)
+		}
+	}
+}
+
+func TestCursorIsBoundToItsSort(t *testing.T) {
+	user := &models.User{ID: uuid.Must(uuid.NewV7()), Age: 42}
+	cur := newCursor(user, models.UserSort{Field: models.UserSortByAge})
+
+	for _, sort := range []models.UserSort{
+		{Field: models.UserSortByAge, Desc: true},
+		{Field: models.UserSortByName},
+		{Field: models.UserSortByID},
+	} {
+		if _, err := cur.position(sort); err != apperr.ErrorInvalidCursor {
+			t.Errorf("position(%+v) = %v, want %v", sort, err, apperr.ErrorInvalidCursor)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/repository"
+)
+
+// fakeHistoryRepository keeps history entries newest first and the user
+// returned by as-of reads.
+type fakeHistoryRepository struct {
+	repository.UserProvider
+	entries []models.UserHistoryEntry
+	asOf    *models.User
+}
+
+func (r *fakeHistoryRepository) History(ctx context.Context, id uuid.UUID, before int64, limit int) ([]models.UserHistoryEntry, error) {
+	var entries []models.UserHistoryEntry
+	for _, entry := range r.entries {
+		if before == 0 || entry.ID < before {
+			entries = append(entries, entry)
+		}
+	}
+	return entries[:min(limit, len(entries))], nil
+}
+
+func (r *fakeHistoryRepository) GetAsOf(ctx context.Context, id uuid.UUID, at time.Time) (*models.User, error) {
+	return r.asOf, nil
+}
+
+func TestUserHistoryPages(t *testing.T) {
+	repo := &fakeHistoryRepository{}
+	for id := int64(5); id > 0; id-- {
+		repo.entries = append(repo.entries, models.UserHistoryEntry{ID: id})
+	}
+	uc := newTestUserUC(repo)
+
+	var ids []int64
+	dto := &models.UserHistoryDTO{}
+	for {
+		page, err := uc.UserHistory(context.Background(), uuid.New(), dto)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, entry := range page.Entries {
+			ids = append(ids, entry.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		dto.Cursor = page.NextCursor
+	}
+
+	if len(ids) != 5 || ids[0] != 5 || ids[4] != 1 {
+		t.Fatalf("paged through %v, want 5 down to 1", ids)
+	}
+}
+
+func TestUserHistoryOfUnknownUser(t *testing.T) {
+	uc := newTestUserUC(&fakeHistoryRepository{})
+
+	if _, err := uc.UserHistory(context.Background(), uuid.New(), &models.UserHistoryDTO{}); err != apperr.ErrorNotFound {
+		t.Fatalf("error = %v, want %v", err, apperr.ErrorNotFound)
+	}
+	if _, err := uc.UserHistory(context.Background(), uuid.New(), &models.UserHistoryDTO{Cursor: "bm90IGEgbnVtYmVy"}); err != apperr.ErrorInvalidCursor {
+		t.Fatalf("error = %v, want %v", err, apperr.ErrorInvalidCursor)
+	}
+}
+
+func TestGetUserAsOfHidesDeletedUsers(t *testing.T) {
+	deletedAt := time.Now()
+	repo := &fakeHistoryRepository{asOf: &models.User{ID: uuid.New(), DeletedAt: &deletedAt}}
+	uc := newTestUserUC(repo)
+
+	if _, err := uc.GetUserAsOf(context.Background(), repo.asOf.ID, deletedAt, false); err != apperr.ErrorNotFound {
+		t.Fatalf("error = %v, want %v", err, apperr.ErrorNotFound)
+	}
+	user, err := uc.GetUserAsOf(context.Background(), repo.asOf.ID, deletedAt, true)
+	if err != nil || user.ID != repo.asOf.ID {
+		t.Fatalf("GetUserAsOf() = %v, %v, want the deleted user", user, err)
+	}
+}
+
+func TestSeqCursorRoundTrip(t *testing.T) {
+	id, err := decodeSeqCursor(encodeSeqCursor(42))
+	if err != nil || id != 42 {
+		t.Fatalf("decodeSeqCursor() = %d, %v, want 42", id, err)
+	}
+	for _, s := range []string{"!", encodeSeqCursor(0), encodeSeqCursor(-3)} {
+		if _, err := decodeSeqCursor(s); err != apperr.ErrorInvalidCursor {
+			t.Errorf("decodeSeqCursor(%q) = %v, want %v", s, err, apperr.ErrorInvalidCursor)
+		}
+	}
+}
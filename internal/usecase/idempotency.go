@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/krackl1n/golang-project/config"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/repository"
+	"github.com/krackl1n/golang-project/internal/requestctx"
+	"github.com/pkg/errors"
+)
+
+// maxIdempotencyKeyLength leaves room for UUIDs and any other key a client
+// is likely to generate.
+const maxIdempotencyKeyLength = 255
+
+type idempotencyUC struct {
+	idempotencyRepository repository.IdempotencyProvider
+	idempotency           config.ConfigIdempotency
+}
+
+func NewIdempotency(idempotencyRepository repository.IdempotencyProvider, cfg *config.Config) IdempotencyProvider {
+	return &idempotencyUC{
+		idempotencyRepository: idempotencyRepository,
+		idempotency:           cfg.ConfigIdempotency,
+	}
+}
+
+// BeginRequest claims the key of request for the actor of ctx, as keys of
+// different clients never collide. It returns nil when the request should
+// run, or the completed record whose response is to be replayed. A key
+// still in flight fails with apperr.ErrorIdempotencyKeyInUse, and a key used
+// for a different request with apperr.ErrorIdempotencyKeyReused.
+func (uc *idempotencyUC) BeginRequest(ctx context.Context, request *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	if len(request.Key) > maxIdempotencyKeyLength {
+		return nil, &apperr.ValidationError{Field: "Idempotency-Key", Reason: "too long"}
+	}
+
+	request.Owner = requestctx.Actor(ctx)
+	request.ExpiresAt = time.Now().Add(uc.idempotency.TTL)
+
+	stored, err := uc.idempotencyRepository.Claim(ctx, request, uc.idempotency.LockTimeout)
+	if err != nil {
+		return nil, errors.Wrap(err, "claim idempotency key")
+	}
+	if stored == nil {
+		return nil, nil
+	}
+
+	if !bytes.Equal(stored.Fingerprint, request.Fingerprint) {
+		return nil, errors.Wrap(apperr.ErrorIdempotencyKeyReused, fmt.Sprintf("idempotency key: owner=%s key=%s", request.Owner, request.Key))
+	}
+	if stored.StatusCode == 0 {
+		return nil, errors.Wrap(apperr.ErrorIdempotencyKeyInUse, fmt.Sprintf("idempotency key: owner=%s key=%s", request.Owner, request.Key))
+	}
+
+	return stored, nil
+}
+
+// CompleteRequest stores the response of a request begun with
+// BeginRequest for replay until the key expires.
+func (uc *idempotencyUC) CompleteRequest(ctx context.Context, request *models.IdempotencyRecord) error {
+	if err := uc.idempotencyRepository.Complete(ctx, request); err != nil {
+		return errors.Wrap(err, "complete idempotency key")
+	}
+
+	return nil
+}
+
+// AbandonRequest frees the key of a request that produced no response
+// worth replaying, so a retry runs it again.
+func (uc *idempotencyUC) AbandonRequest(ctx context.Context, request *models.IdempotencyRecord) error {
+	if err := uc.idempotencyRepository.Release(ctx, request.Owner, request.Key); err != nil {
+		return errors.Wrap(err, "release idempotency key")
+	}
+
+	return nil
+}
+
+func (uc *idempotencyUC) PurgeExpiredKeys(ctx context.Context) (int64, error) {
+	purged, err := uc.idempotencyRepository.DeleteExpired(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "purge idempotency keys")
+	}
+
+	return purged, nil
+}
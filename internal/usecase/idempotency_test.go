@@ -0,0 +1,98 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/krackl1n/golang-project/config"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/repository"
+	"github.com/krackl1n/golang-project/internal/requestctx"
+	"github.com/pkg/errors"
+)
+
+// fakeIdempotencyRepository keeps records by owner and key, ignoring
+// expiry and lock timeouts.
+type fakeIdempotencyRepository struct {
+	repository.IdempotencyProvider
+	records     map[string]models.IdempotencyRecord
+	lockTimeout time.Duration
+}
+
+func (r *fakeIdempotencyRepository) Claim(ctx context.Context, record *models.IdempotencyRecord, lockTimeout time.Duration) (*models.IdempotencyRecord, error) {
+	r.lockTimeout = lockTimeout
+	if stored, ok := r.records[record.Owner+"|"+record.Key]; ok {
+		return &stored, nil
+	}
+	r.records[record.Owner+"|"+record.Key] = *record
+	return nil, nil
+}
+
+func (r *fakeIdempotencyRepository) Complete(ctx context.Context, record *models.IdempotencyRecord) error {
+	r.records[record.Owner+"|"+record.Key] = *record
+	return nil
+}
+
+func (r *fakeIdempotencyRepository) Release(ctx context.Context, owner, key string) error {
+	delete(r.records, owner+"|"+key)
+	return nil
+}
+
+func TestBeginRequest(t *testing.T) {
+	repo := &fakeIdempotencyRepository{records: map[string]models.IdempotencyRecord{}}
+	uc := NewIdempotency(repo, &config.Config{ConfigIdempotency: config.ConfigIdempotency{TTL: time.Hour, LockTimeout: time.Minute}})
+	alice := requestctx.WithActor(context.Background(), "alice")
+	bob := requestctx.WithActor(context.Background(), "bob")
+
+	request := func(body string) *models.IdempotencyRecord {
+		return &models.IdempotencyRecord{Key: "k1", Fingerprint: models.IdempotencyFingerprint("POST", "/user", []byte(body))}
+	}
+
+	first := request(`{"name":"Ann"}`)
+	if stored, err := uc.BeginRequest(alice, first); stored != nil || err != nil {
+		t.Fatalf("first request = %v, %v, want it to run", stored, err)
+	}
+	if first.Owner != "alice" || time.Until(first.ExpiresAt) < 59*time.Minute || repo.lockTimeout != time.Minute {
+		t.Fatalf("claimed %+v with lock timeout %s", first, repo.lockTimeout)
+	}
+
+	if _, err := uc.BeginRequest(alice, request(`{"name":"Ann"}`)); !errors.Is(err, apperr.ErrorIdempotencyKeyInUse) {
+		t.Fatalf("retry in flight: err = %v, want key in use", err)
+	}
+	if _, err := uc.BeginRequest(alice, request(`{"name":"Bob"}`)); !errors.Is(err, apperr.ErrorIdempotencyKeyReused) {
+		t.Fatalf("other body: err = %v, want key reused", err)
+	}
+	if stored, err := uc.BeginRequest(bob, request(`{"name":"Ann"}`)); stored != nil || err != nil {
+		t.Fatalf("same key of another client = %v, %v, want it to run", stored, err)
+	}
+
+	first.StatusCode = 201
+	first.Body = []byte(`{"id":"1"}`)
+	if err := uc.CompleteRequest(alice, first); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := uc.BeginRequest(alice, request(`{"name":"Ann"}`))
+	if err != nil || stored == nil || stored.StatusCode != 201 || string(stored.Body) != `{"id":"1"}` {
+		t.Fatalf("retry after completion = %+v, %v, want the stored response", stored, err)
+	}
+
+	if err := uc.AbandonRequest(bob, &models.IdempotencyRecord{Owner: "bob", Key: "k1"}); err != nil {
+		t.Fatal(err)
+	}
+	if stored, err := uc.BeginRequest(bob, request(`{"name":"Ann"}`)); stored != nil || err != nil {
+		t.Fatalf("retry after abandoning = %v, %v, want it to run again", stored, err)
+	}
+}
+
+func TestBeginRequestRejectsLongKeys(t *testing.T) {
+	uc := NewIdempotency(&fakeIdempotencyRepository{records: map[string]models.IdempotencyRecord{}}, &config.Config{})
+
+	_, err := uc.BeginRequest(context.Background(), &models.IdempotencyRecord{Key: strings.Repeat("k", maxIdempotencyKeyLength+1)})
+	var validationErr *apperr.ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Field != "Idempotency-Key" {
+		t.Fatalf("err = %v, want a validation error on Idempotency-Key", err)
+	}
+}
@@ -0,0 +1,248 @@
+package usecase
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/config"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/policy"
+	"github.com/krackl1n/golang-project/internal/repository"
+	"github.com/krackl1n/golang-project/internal/requestctx"
+	"github.com/krackl1n/golang-project/internal/validation"
+	"github.com/pkg/errors"
+)
+
+// staleImportMessage is the error of jobs that stopped making progress.
+const staleImportMessage = "import stopped making progress"
+
+type importUC struct {
+	userRepository repository.UserProvider
+	jobRepository  repository.ImportJobProvider
+	batch          config.ConfigBatch
+	policy         *policy.Policy
+}
+
+// NewImport expects the plain user repository: imported users are not
+// worth caching. Import jobs create users, so every operation on them needs
+// the users:create permission.
+func NewImport(
+	userRepository repository.UserProvider,
+	jobRepository repository.ImportJobProvider,
+	cfg *config.Config,
+	accessPolicy *policy.Policy,
+) ImportProvider {
+	return &importUC{
+		userRepository: userRepository,
+		jobRepository:  jobRepository,
+		batch:          cfg.ConfigBatch,
+		policy:         accessPolicy,
+	}
+}
+
+func (uc *importUC) CreateImportJob(ctx context.Context, createImportJobDTO *models.CreateImportJobDTO) (*models.ImportJob, error) {
+	if err := uc.policy.Authorize(requestctx.Principal(ctx), policy.ActionCreate, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	jobId, err := uuid.NewV7()
+	if err != nil {
+		return nil, errors.Wrap(err, "generate UUID")
+	}
+
+	columns := createImportJobDTO.Columns
+	if columns == nil {
+		columns = map[string]string{}
+	}
+
+	job := &models.ImportJob{
+		ID:      jobId,
+		Format:  createImportJobDTO.Format,
+		Status:  models.ImportPending,
+		Columns: columns,
+	}
+	if err := uc.jobRepository.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (uc *importUC) GetImportJob(ctx context.Context, id uuid.UUID) (*models.ImportJob, error) {
+	if err := uc.policy.Authorize(requestctx.Principal(ctx), policy.ActionCreate, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	job, err := uc.jobRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "importUC get by Id")
+	}
+
+	return job, nil
+}
+
+// RunImport reads r row by row and inserts valid users in chunks of
+// ImportChunkSize rows, so memory stays bounded by one chunk whatever the
+// file size. Rejected rows go to the job's error report with the chunk they
+// were read in; only an unreadable
+// file or a failing database marks the job as failed.
+func (uc *importUC) RunImport(ctx context.Context, id uuid.UUID, r io.Reader) (*models.ImportJob, error) {
+	if err := uc.policy.Authorize(requestctx.Principal(ctx), policy.ActionCreate, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	job, err := uc.jobRepository.Start(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.importRows(ctx, job, r); err != nil {
+		slog.ErrorContext(ctx, "import job failed", slog.String("id", id.String()), slog.Any("error", err))
+		// The request context may be gone already, the job must still leave running.
+		if _, finishErr := uc.jobRepository.Finish(context.WithoutCancel(ctx), id, models.ImportFailed, err.Error()); finishErr != nil {
+			return nil, errors.Wrap(finishErr, "finish failed import job")
+		}
+		return nil, errors.Wrap(err, "import users")
+	}
+
+	return uc.jobRepository.Finish(ctx, id, models.ImportCompleted, "")
+}
+
+func (uc *importUC) WriteImportErrors(ctx context.Context, id uuid.UUID, fn func(rowError *models.ImportRowError) error) error {
+	if err := uc.policy.Authorize(requestctx.Principal(ctx), policy.ActionCreate, uuid.Nil); err != nil {
+		return err
+	}
+
+	return uc.jobRepository.StreamErrors(ctx, id, fn)
+}
+
+// FailStaleImportJobs marks running jobs that have recorded no progress for
+// ImportStaleAfter as failed.
+func (uc *importUC) FailStaleImportJobs(ctx context.Context) (int64, error) {
+	if err := uc.policy.Authorize(requestctx.Principal(ctx), policy.ActionCreate, uuid.Nil); err != nil {
+		return 0, err
+	}
+
+	failed, err := uc.jobRepository.FailStale(ctx, time.Now().Add(-uc.batch.ImportStaleAfter), staleImportMessage)
+	if err != nil {
+		return 0, errors.Wrap(err, "fail stale import jobs")
+	}
+
+	return failed, nil
+}
+
+func (uc *importUC) importRows(ctx context.Context, job *models.ImportJob, r io.Reader) error {
+	reader, err := newRowReader(job.Format, r, job.Columns)
+	if err != nil {
+		return &apperr.ValidationError{Field: "file", Reason: err.Error()}
+	}
+
+	chunk := newImportChunk(uc.batch.ImportChunkSize)
+	for {
+		row, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, "read import file")
+		}
+
+		chunk.add(row)
+		// Rejected rows count too: their errors wait in the chunk until it is
+		// flushed.
+		if chunk.progress.TotalRows >= int64(uc.batch.ImportChunkSize) {
+			if err := uc.flush(ctx, job.ID, chunk); err != nil {
+				return err
+			}
+		}
+	}
+
+	return uc.flush(ctx, job.ID, chunk)
+}
+
+// flush inserts the chunk in best-effort mode, records its progress and
+// resets it for the next rows.
+func (uc *importUC) flush(ctx context.Context, jobId uuid.UUID, chunk *importChunk) error {
+	if len(chunk.users) > 0 {
+		errs, err := uc.userRepository.CreateBatch(ctx, chunk.users, false)
+		if err != nil {
+			return errors.Wrap(err, "insert import chunk")
+		}
+		for i, err := range errs {
+			if err != nil {
+				chunk.reject(chunk.rows[i], err)
+				continue
+			}
+			chunk.progress.ImportedRows++
+		}
+	}
+
+	if chunk.progress.TotalRows > 0 {
+		if err := uc.jobRepository.AddProgress(ctx, jobId, &chunk.progress); err != nil {
+			return errors.Wrap(err, "record import progress")
+		}
+	}
+
+	chunk.reset()
+	return nil
+}
+
+// importChunk collects the users of one insert together with the rows
+// they came from, so database rejections keep their line numbers.
+type importChunk struct {
+	users    []models.User
+	rows     []*importRow
+	progress models.ImportProgress
+}
+
+func newImportChunk(size int) *importChunk {
+	return &importChunk{
+		users: make([]models.User, 0, size),
+		rows:  make([]*importRow, 0, size),
+	}
+}
+
+func (c *importChunk) add(row *importRow) {
+	c.progress.TotalRows++
+	if row.Err != nil {
+		c.reject(row, row.Err)
+		return
+	}
+
+	createUserDTO, err := toCreateUserDTO(row.Fields)
+	if err != nil {
+		c.reject(row, err)
+		return
+	}
+	if err := validation.Struct(createUserDTO); err != nil {
+		c.reject(row, err)
+		return
+	}
+
+	user, err := newUser(createUserDTO)
+	if err != nil {
+		c.reject(row, err)
+		return
+	}
+	c.users = append(c.users, *user)
+	c.rows = append(c.rows, row)
+}
+
+func (c *importChunk) reject(row *importRow, err error) {
+	c.progress.RejectedRows++
+	c.progress.RowErrors = append(c.progress.RowErrors, models.ImportRowError{
+		Line:  row.Line,
+		Error: validation.Describe(err),
+		Raw:   row.Raw,
+	})
+}
+
+func (c *importChunk) reset() {
+	c.users = c.users[:0]
+	c.rows = c.rows[:0]
+	c.progress = models.ImportProgress{}
+}
@@ -0,0 +1,195 @@
+package usecase
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/pkg/errors"
+)
+
+// maxNDJSONLine bounds the memory a single NDJSON record may take.
+const maxNDJSONLine = 1 << 20
+
+var userFields = []string{"name", "age", "gender", "email"}
+
+// importRow is one record of an import file. Err is set when the record
+// itself is malformed; the row is rejected but the import goes on.
+type importRow struct {
+	Line   int64
+	Raw    string
+	Fields map[string]string
+	Err    error
+}
+
+// rowReader yields the records of an import file one at a time. Next
+// returns io.EOF at the end and any other error when the file cannot be
+// read any further.
+type rowReader interface {
+	Next() (*importRow, error)
+}
+
+func newRowReader(format models.ImportFormat, r io.Reader, columns map[string]string) (rowReader, error) {
+	switch format {
+	case models.ImportCSV:
+		return newCSVReader(r, columns)
+	case models.ImportNDJSON:
+		return newNDJSONReader(r, columns), nil
+	default:
+		return nil, errors.Errorf("unsupported import format %q", format)
+	}
+}
+
+// mapColumn resolves a source column to a user field: through the job
+// mapping when one is given, by name otherwise.
+func mapColumn(columns map[string]string, source string) string {
+	source = strings.ToLower(strings.TrimSpace(source))
+	if len(columns) > 0 {
+		for from, to := range columns {
+			if strings.ToLower(from) == source {
+				return to
+			}
+		}
+		return ""
+	}
+	for _, field := range userFields {
+		if field == source {
+			return field
+		}
+	}
+	return ""
+}
+
+type csvReader struct {
+	reader *csv.Reader
+	fields []string
+}
+
+func newCSVReader(r io.Reader, columns map[string]string) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.Wrap(err, "read csv header")
+	}
+
+	fields := make([]string, len(header))
+	mapped := make(map[string]bool, len(userFields))
+	for i, column := range header {
+		fields[i] = mapColumn(columns, column)
+		mapped[fields[i]] = true
+	}
+	for _, field := range userFields {
+		if !mapped[field] {
+			return nil, errors.Errorf("csv header has no column for %q", field)
+		}
+	}
+
+	return &csvReader{reader: reader, fields: fields}, nil
+}
+
+func (r *csvReader) Next() (*importRow, error) {
+	record, err := r.reader.Read()
+
+	var parseErr *csv.ParseError
+	switch {
+	case errors.As(err, &parseErr):
+		return &importRow{Line: int64(parseErr.StartLine), Raw: encodeCSV(record), Err: parseErr.Err}, nil
+	case err != nil:
+		return nil, err
+	}
+
+	line, _ := r.reader.FieldPos(0)
+	row := &importRow{Line: int64(line), Raw: encodeCSV(record)}
+	row.Fields = make(map[string]string, len(userFields))
+	for i, value := range record {
+		if r.fields[i] != "" {
+			row.Fields[r.fields[i]] = value
+		}
+	}
+
+	return row, nil
+}
+
+func encodeCSV(record []string) string {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write(record)
+	w.Flush()
+	return strings.TrimRight(buf.String(), "\r\n")
+}
+
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+	columns map[string]string
+	line    int64
+}
+
+func newNDJSONReader(r io.Reader, columns map[string]string) *ndjsonReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxNDJSONLine)
+
+	return &ndjsonReader{scanner: scanner, columns: columns}
+}
+
+func (r *ndjsonReader) Next() (*importRow, error) {
+	for r.scanner.Scan() {
+		r.line++
+		data := bytes.TrimSpace(r.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		row := &importRow{Line: r.line, Raw: string(data)}
+
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var record map[string]any
+		if err := decoder.Decode(&record); err != nil {
+			row.Err = errors.Wrap(err, "invalid json")
+			return row, nil
+		}
+
+		row.Fields = make(map[string]string, len(userFields))
+		for key, value := range record {
+			field := mapColumn(r.columns, key)
+			if field == "" || value == nil {
+				continue
+			}
+			row.Fields[field] = fmt.Sprint(value)
+		}
+		return row, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("read line %d", r.line+1))
+	}
+	return nil, io.EOF
+}
+
+// toCreateUserDTO converts mapped fields; validation happens afterwards.
+func toCreateUserDTO(fields map[string]string) (*models.CreateUserDTO, error) {
+	dto := &models.CreateUserDTO{
+		Name:   strings.TrimSpace(fields["name"]),
+		Gender: strings.TrimSpace(fields["gender"]),
+		Email:  strings.TrimSpace(fields["email"]),
+	}
+
+	if age := strings.TrimSpace(fields["age"]); age != "" {
+		value, err := strconv.ParseUint(age, 10, 8)
+		if err != nil {
+			return nil, errors.Errorf("age %q is not a number between 0 and 255", age)
+		}
+		dto.Age = uint8(value)
+	}
+
+	return dto, nil
+}
@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"io"
+	"maps"
+	"strings"
+	"testing"
+
+	"github.com/krackl1n/golang-project/internal/models"
+)
+
+// readRows drains a reader, failing the test on a file-level error.
+func readRows(t *testing.T, reader rowReader) []*importRow {
+	t.Helper()
+	var rows []*importRow
+	for {
+		row, err := reader.Next()
+		if err == io.EOF {
+			return rows
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, row)
+	}
+}
+
+func TestCSVReader(t *testing.T) {
+	file := "Email, Name,age,gender,notes\nann@example.com,Ann,30,female,vip\n\"bob@example.com,Bob,40,male\neve@example.com,Eve,25,female,\n"
+	reader, err := newRowReader(models.ImportCSV, strings.NewReader(file), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := readRows(t, reader)
+	if len(rows) != 2 || rows[1].Err == nil {
+		t.Fatalf("rows = %+v, want the valid row and then the unterminated quote rejected", rows)
+	}
+	want := map[string]string{"name": "Ann", "age": "30", "gender": "female", "email": "ann@example.com"}
+	if rows[0].Line != 2 || rows[0].Err != nil || !maps.Equal(rows[0].Fields, want) {
+		t.Fatalf("row = %+v, want line 2 with %v", rows[0], want)
+	}
+}
+
+func TestCSVReaderRejectsMalformedRows(t *testing.T) {
+	file := "name,age,gender,email\nann,30,female\nbob,40,male,bob@example.com\n"
+	reader, err := newRowReader(models.ImportCSV, strings.NewReader(file), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := readRows(t, reader)
+	if len(rows) != 2 || rows[0].Err == nil || rows[0].Line != 2 || rows[1].Err != nil || rows[1].Line != 3 {
+		t.Fatalf("rows = %+v, want line 2 rejected and line 3 read", rows)
+	}
+}
+
+func TestCSVReaderNeedsEveryField(t *testing.T) {
+	if _, err := newRowReader(models.ImportCSV, strings.NewReader("name,age,email\n"), nil); err == nil {
+		t.Fatal("accepted a header without gender")
+	}
+
+	columns := map[string]string{"Full Name": "name", "Years": "age", "Sex": "gender", "Mail": "email"}
+	reader, err := newRowReader(models.ImportCSV, strings.NewReader("full name,years,sex,mail\nAnn,30,female,ann@example.com\n"), columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows := readRows(t, reader); len(rows) != 1 || rows[0].Fields["name"] != "Ann" || rows[0].Fields["email"] != "ann@example.com" {
+		t.Fatalf("rows = %+v, want the mapped columns", rows)
+	}
+}
+
+func TestNDJSONReader(t *testing.T) {
+	file := `{"name":"Ann","age":30,"gender":"female","email":"ann@example.com","notes":null}
+
+{"name":"Bob",
+{"name":"Eve","age":25.5,"gender":"female","email":"eve@example.com"}
+`
+	reader, err := newRowReader(models.ImportNDJSON, strings.NewReader(file), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := readRows(t, reader)
+	if len(rows) != 3 {
+		t.Fatalf("rows = %d, want 3 skipping the blank line", len(rows))
+	}
+	if rows[0].Line != 1 || rows[0].Err != nil || rows[0].Fields["age"] != "30" {
+		t.Errorf("row 1 = %+v", rows[0])
+	}
+	if rows[1].Line != 3 || rows[1].Err == nil {
+		t.Errorf("row 3 = %+v, want it rejected as invalid json", rows[1])
+	}
+	if rows[2].Line != 4 || rows[2].Fields["age"] != "25.5" {
+		t.Errorf("row 4 = %+v, want the age kept as written", rows[2])
+	}
+	if _, err := toCreateUserDTO(rows[2].Fields); err == nil {
+		t.Error("toCreateUserDTO accepted a fractional age")
+	}
+}
+
+func TestNDJSONReaderStopsOnOversizedLine(t *testing.T) {
+	reader, err := newRowReader(models.ImportNDJSON, strings.NewReader(`{"name":"`+strings.Repeat("a", maxNDJSONLine)+`"}`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reader.Next(); err == nil || err == io.EOF {
+		t.Fatalf("Next() = %v, want the line to be too long", err)
+	}
+}
@@ -0,0 +1,163 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/config"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/policy"
+	"github.com/krackl1n/golang-project/internal/repository"
+	"github.com/pkg/errors"
+)
+
+// fakeUserRepository inserts every user except those whose email is taken.
+type fakeUserRepository struct {
+	repository.UserProvider
+	takenEmails map[string]bool
+	inserted    []models.User
+}
+
+func (r *fakeUserRepository) CreateBatch(ctx context.Context, users []models.User, atomic bool) ([]error, error) {
+	errs := make([]error, len(users))
+	for i, user := range users {
+		if r.takenEmails[user.Email] {
+			errs[i] = &apperr.ConflictError{Field: "email"}
+			continue
+		}
+		r.inserted = append(r.inserted, user)
+	}
+	return errs, nil
+}
+
+// fakeImportJobRepository keeps the progress of every flush. Once stopped,
+// the job is no longer running and refuses progress.
+type fakeImportJobRepository struct {
+	repository.ImportJobProvider
+	job        models.ImportJob
+	flushes    []models.ImportProgress
+	stopped    bool
+	staleSince time.Time
+}
+
+func (r *fakeImportJobRepository) Start(ctx context.Context, id uuid.UUID) (*models.ImportJob, error) {
+	r.job.Status = models.ImportRunning
+	return &r.job, nil
+}
+
+func (r *fakeImportJobRepository) AddProgress(ctx context.Context, id uuid.UUID, progress *models.ImportProgress) error {
+	if r.stopped {
+		return &apperr.ConflictError{Field: "status", Reason: "import job is not running"}
+	}
+	flushed := *progress
+	flushed.RowErrors = append([]models.ImportRowError(nil), progress.RowErrors...)
+	r.flushes = append(r.flushes, flushed)
+	r.job.TotalRows += progress.TotalRows
+	r.job.ImportedRows += progress.ImportedRows
+	r.job.RejectedRows += progress.RejectedRows
+	return nil
+}
+
+func (r *fakeImportJobRepository) Finish(ctx context.Context, id uuid.UUID, status models.ImportStatus, message string) (*models.ImportJob, error) {
+	if r.stopped {
+		return nil, &apperr.ConflictError{Field: "status", Reason: "import job is not running"}
+	}
+	r.job.Status = status
+	r.job.Error = message
+	return &r.job, nil
+}
+
+func (r *fakeImportJobRepository) FailStale(ctx context.Context, before time.Time, message string) (int64, error) {
+	r.staleSince = before
+	r.job.Status = models.ImportFailed
+	r.job.Error = message
+	return 1, nil
+}
+
+func newTestImportUC(chunkSize int, users *fakeUserRepository, jobs *fakeImportJobRepository) ImportProvider {
+	cfg := &config.Config{ConfigBatch: config.ConfigBatch{ImportChunkSize: chunkSize, ImportStaleAfter: 15 * time.Minute}}
+	return NewImport(users, jobs, cfg, policy.Default())
+}
+
+func TestRunImportFlushesRejectedRows(t *testing.T) {
+	users := &fakeUserRepository{}
+	jobs := &fakeImportJobRepository{job: models.ImportJob{Format: models.ImportCSV}}
+
+	file := "name,age,gender,email\n" + strings.Repeat("bob,300,male,bob@example.com\n", 5) + "ann,30,female,ann@example.com\n"
+	job, err := newTestImportUC(2, users, jobs).RunImport(context.Background(), uuid.New(), strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if job.Status != models.ImportCompleted || job.TotalRows != 6 || job.ImportedRows != 1 || job.RejectedRows != 5 {
+		t.Fatalf("job = %+v, want 6 rows with 1 imported and 5 rejected", job)
+	}
+	if len(jobs.flushes) != 3 {
+		t.Fatalf("flushes = %d, want 3", len(jobs.flushes))
+	}
+	for i, flush := range jobs.flushes {
+		if flush.TotalRows > 2 || len(flush.RowErrors) != int(flush.RejectedRows) {
+			t.Fatalf("flush %d = %+v, want at most 2 rows with their errors", i, flush)
+		}
+	}
+	if line := jobs.flushes[0].RowErrors[0].Line; line != 2 {
+		t.Fatalf("first rejected line = %d, want 2", line)
+	}
+}
+
+func TestRunImportKeepsLinesOfDatabaseRejections(t *testing.T) {
+	users := &fakeUserRepository{takenEmails: map[string]bool{"taken@example.com": true}}
+	jobs := &fakeImportJobRepository{job: models.ImportJob{Format: models.ImportNDJSON}}
+
+	file := `{"name":"ann","age":30,"gender":"female","email":"ann@example.com"}
+{"name":"bob","age":40,"gender":"male","email":"taken@example.com"}
+`
+	job, err := newTestImportUC(10, users, jobs).RunImport(context.Background(), uuid.New(), strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if job.ImportedRows != 1 || job.RejectedRows != 1 || len(users.inserted) != 1 {
+		t.Fatalf("job = %+v, want 1 imported and 1 rejected", job)
+	}
+	rowErrors := jobs.flushes[0].RowErrors
+	if len(rowErrors) != 1 || rowErrors[0].Line != 2 || !strings.Contains(rowErrors[0].Raw, "taken@example.com") {
+		t.Fatalf("row errors = %+v, want line 2 with its raw record", rowErrors)
+	}
+}
+
+func TestFailStaleImportJobs(t *testing.T) {
+	jobs := &fakeImportJobRepository{job: models.ImportJob{Status: models.ImportRunning}}
+
+	failed, err := newTestImportUC(10, &fakeUserRepository{}, jobs).FailStaleImportJobs(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if failed != 1 || jobs.job.Status != models.ImportFailed || jobs.job.Error == "" {
+		t.Fatalf("failed = %d, job = %+v, want the job failed with a reason", failed, jobs.job)
+	}
+	if age := time.Since(jobs.staleSince); age < 15*time.Minute || age > 16*time.Minute {
+		t.Fatalf("stale since %s ago, want 15m", age)
+	}
+}
+
+func TestRunImportStopsOnceTheJobIsNoLongerRunning(t *testing.T) {
+	users := &fakeUserRepository{}
+	jobs := &fakeImportJobRepository{job: models.ImportJob{Format: models.ImportCSV}, stopped: true}
+
+	file := "name,age,gender,email\nann,30,female,ann@example.com\n"
+	_, err := newTestImportUC(10, users, jobs).RunImport(context.Background(), uuid.New(), strings.NewReader(file))
+
+	var conflict *apperr.ConflictError
+	if !errors.As(err, &conflict) || conflict.Field != "status" {
+		t.Fatalf("err = %v, want a status conflict", err)
+	}
+	if len(jobs.flushes) != 0 {
+		t.Fatalf("flushes = %d, want none", len(jobs.flushes))
+	}
+}
@@ -2,6 +2,8 @@ package usecase
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/krackl1n/golang-project/internal/models"
@@ -9,7 +11,48 @@ import (
 
 type UserProvider interface {
 	CreateUser(ctx context.Context, createUserDTO *models.CreateUserDTO) (uuid.UUID, error)
-	GetUserById(ctx context.Context, id uuid.UUID) (*models.User, error)
+	CreateUsers(ctx context.Context, createUserDTOs []models.CreateUserDTO, mode models.BatchMode) ([]models.BatchItemResult, error)
+	GetUserById(ctx context.Context, id uuid.UUID, withDeleted bool) (*models.User, error)
+	GetUserAsOf(ctx context.Context, id uuid.UUID, at time.Time, withDeleted bool) (*models.User, error)
+	UserHistory(ctx context.Context, id uuid.UUID, userHistoryDTO *models.UserHistoryDTO) (*models.UserHistoryPage, error)
+	ListUsers(ctx context.Context, criteria *models.UserCriteria) (*models.UserPage, error)
+	SearchUsers(ctx context.Context, searchUsersDTO *models.SearchUsersDTO) ([]models.UserSearchResult, error)
+	AuthorizeExport(ctx context.Context) error
+	ExportUsers(ctx context.Context, filter *models.UserFilter, fn func(user *models.User) error) error
 	UpdateUser(ctx context.Context, user *models.User) error
-	DeleteUser(ctx context.Context, id uuid.UUID) error
+	PatchUser(ctx context.Context, id uuid.UUID, patch *models.UserPatch) (*models.User, error)
+	DeleteUser(ctx context.Context, id uuid.UUID, version int64) error
+	RestoreUser(ctx context.Context, id uuid.UUID) (*models.User, error)
+	PurgeDeletedUsers(ctx context.Context) (int64, error)
+}
+
+type ImportProvider interface {
+	CreateImportJob(ctx context.Context, createImportJobDTO *models.CreateImportJobDTO) (*models.ImportJob, error)
+	GetImportJob(ctx context.Context, id uuid.UUID) (*models.ImportJob, error)
+	RunImport(ctx context.Context, id uuid.UUID, r io.Reader) (*models.ImportJob, error)
+	WriteImportErrors(ctx context.Context, id uuid.UUID, fn func(rowError *models.ImportRowError) error) error
+	FailStaleImportJobs(ctx context.Context) (int64, error)
+}
+
+type WebhookProvider interface {
+	CreateWebhook(ctx context.Context, createWebhookDTO *models.CreateWebhookDTO) (*models.WebhookSubscription, error)
+	ListWebhooks(ctx context.Context) ([]models.WebhookSubscription, error)
+	DeleteWebhook(ctx context.Context, id uuid.UUID) error
+	ListDeliveries(ctx context.Context, subscriptionId uuid.UUID, deliveriesDTO *models.WebhookDeliveriesDTO) (*models.WebhookDeliveryPage, error)
+	GetDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, error)
+	ReplayDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, error)
+}
+
+type APIKeyProvider interface {
+	IssueAPIKey(ctx context.Context, createAPIKeyDTO *models.CreateAPIKeyDTO) (*models.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) (*models.APIKey, error)
+	AuthenticateAPIKey(ctx context.Context, key string) (*models.Principal, error)
+}
+
+type IdempotencyProvider interface {
+	BeginRequest(ctx context.Context, request *models.IdempotencyRecord) (*models.IdempotencyRecord, error)
+	CompleteRequest(ctx context.Context, request *models.IdempotencyRecord) error
+	AbandonRequest(ctx context.Context, request *models.IdempotencyRecord) error
+	PurgeExpiredKeys(ctx context.Context) (int64, error)
 }
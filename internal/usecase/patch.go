@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"encoding/json"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/i18n"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/validation"
+	"github.com/pkg/errors"
+)
+
+// applyPatch applies a JSON Merge Patch (RFC 7396) or JSON Patch (RFC 6902)
+// to the JSON form of the user and validates the result with the models.User
+// rules. The id is immutable; timestamps are owned by the database.
+func applyPatch(user *models.User, patch *models.UserPatch) (*models.User, error) {
+	original, err := json.Marshal(user)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal user")
+	}
+
+	var modified []byte
+	switch patch.Type {
+	case models.MergePatch:
+		modified, err = jsonpatch.MergePatch(original, patch.Document)
+		if err != nil {
+			return nil, apperr.WithDetail(apperr.ErrorMalformedPatch, i18n.DetailMalformedPatch, err)
+		}
+	case models.JSONPatch:
+		ops, err := jsonpatch.DecodePatch(patch.Document)
+		if err != nil {
+			return nil, apperr.WithDetail(apperr.ErrorMalformedPatch, i18n.DetailMalformedPatch, err)
+		}
+		modified, err = ops.Apply(original)
+		if err != nil {
+			return nil, apperr.WithDetail(apperr.ErrorInvalidPatch, i18n.DetailInvalidPatch, err)
+		}
+	default:
+		return nil, apperr.WithDetail(apperr.ErrorMalformedPatch, i18n.DetailUnsupportedPatch, nil)
+	}
+
+	var patched models.User
+	if err := json.Unmarshal(modified, &patched); err != nil {
+		return nil, apperr.WithDetail(apperr.ErrorInvalidPatch, i18n.DetailPatchInvalid, err)
+	}
+
+	if patched.ID != user.ID {
+		return nil, apperr.WithDetail(apperr.ErrorInvalidPatch, i18n.DetailPatchReadOnlyID, nil)
+	}
+
+	// The validator errors stay in the chain and are reported per field.
+	if err := validation.Struct(patched); err != nil {
+		return nil, apperr.WithDetail(apperr.ErrorInvalidPatch, i18n.DetailPatchInvalid, err)
+	}
+
+	return &patched, nil
+}
+
+// diffUsers returns the columns that differ between the two users, or nil
+// when there is nothing to write.
+func diffUsers(current, patched *models.User) *models.UserChanges {
+	changes := &models.UserChanges{}
+	changed := false
+
+	if patched.Name != current.Name {
+		changes.Name = &patched.Name
+		changed = true
+	}
+	if patched.Age != current.Age {
+		changes.Age = &patched.Age
+		changed = true
+	}
+	if patched.Gender != current.Gender {
+		changes.Gender = &patched.Gender
+		changed = true
+	}
+	if patched.Email != current.Email {
+		changes.Email = &patched.Email
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+	return changes
+}
@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/i18n"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/repository"
+	"github.com/pkg/errors"
+)
+
+func testPatchUser() *models.User {
+	return &models.User{ID: uuid.Must(uuid.NewV7()), Name: "Ann", Age: 30, Gender: "female", Email: "ann@example.com", Version: 3}
+}
+
+func TestApplyPatch(t *testing.T) {
+	name, age := "Bob", uint8(31)
+
+	tests := []struct {
+		name    string
+		patch   models.UserPatch
+		want    *models.UserChanges
+		wantErr error
+		detail  string
+	}{
+		{
+			name:  "merge patch",
+			patch: models.UserPatch{Type: models.MergePatch, Document: []byte(`{"name":"Bob"}`)},
+			want:  &models.UserChanges{Name: &name},
+		},
+		{
+			name:  "json patch",
+			patch: models.UserPatch{Type: models.JSONPatch, Document: []byte(`[{"op":"test","path":"/age","value":30},{"op":"replace","path":"/age","value":31}]`)},
+			want:  &models.UserChanges{Age: &age},
+		},
+		{
+			name:  "merge patch without changes",
+			patch: models.UserPatch{Type: models.MergePatch, Document: []byte(`{"name":"Ann"}`)},
+		},
+		{
+			name:  "timestamps are ignored",
+			patch: models.UserPatch{Type: models.MergePatch, Document: []byte(`{"created_at":"2000-01-01T00:00:00Z"}`)},
+		},
+		{
+			name:    "malformed merge patch",
+			patch:   models.UserPatch{Type: models.MergePatch, Document: []byte(`{"name":`)},
+			wantErr: apperr.ErrorMalformedPatch,
+			detail:  i18n.DetailMalformedPatch,
+		},
+		{
+			name:    "malformed json patch",
+			patch:   models.UserPatch{Type: models.JSONPatch, Document: []byte(`{"op":"replace"}`)},
+			wantErr: apperr.ErrorMalformedPatch,
+			detail:  i18n.DetailMalformedPatch,
+		},
+		{
+			name:    "failed test operation",
+			patch:   models.UserPatch{Type: models.JSONPatch, Document: []byte(`[{"op":"test","path":"/age","value":99}]`)},
+			wantErr: apperr.ErrorInvalidPatch,
+			detail:  i18n.DetailInvalidPatch,
+		},
+		{
+			name:    "read-only id",
+			patch:   models.UserPatch{Type: models.MergePatch, Document: []byte(`{"id":"` + uuid.NewString() + `"}`)},
+			wantErr: apperr.ErrorInvalidPatch,
+			detail:  i18n.DetailPatchReadOnlyID,
+		},
+		{
+			name:    "removed required field",
+			patch:   models.UserPatch{Type: models.MergePatch, Document: []byte(`{"name":null}`)},
+			wantErr: apperr.ErrorInvalidPatch,
+			detail:  i18n.DetailPatchInvalid,
+		},
+		{
+			name:    "invalid value",
+			patch:   models.UserPatch{Type: models.JSONPatch, Document: []byte(`[{"op":"replace","path":"/gender","value":"other"}]`)},
+			wantErr: apperr.ErrorInvalidPatch,
+			detail:  i18n.DetailPatchInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := testPatchUser()
+			patched, err := applyPatch(user, &tt.patch)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("applyPatch() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				var detailErr *apperr.DetailError
+				if !errors.As(err, &detailErr) || detailErr.Detail != tt.detail {
+					t.Fatalf("applyPatch() error = %v, want detail %s", err, tt.detail)
+				}
+				return
+			}
+
+			changes := diffUsers(user, patched)
+			switch {
+			case tt.want == nil && changes != nil:
+				t.Fatalf("changes = %+v, want none", changes)
+			case tt.want != nil && (changes == nil || !sameChanges(changes, tt.want)):
+				t.Fatalf("changes = %+v, want %+v", changes, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyPatchReportsInvalidFields(t *testing.T) {
+	patch := &models.UserPatch{Type: models.JSONPatch, Document: []byte(`[{"op":"replace","path":"/gender","value":"other"}]`)}
+	_, err := applyPatch(testPatchUser(), patch)
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) || len(validationErrs) != 1 || validationErrs[0].Field() != "gender" {
+		t.Fatalf("applyPatch() error = %v, want the gender field reported", err)
+	}
+}
+
+func sameChanges(a, b *models.UserChanges) bool {
+	same := func(x, y *string) bool { return (x == nil) == (y == nil) && (x == nil || *x == *y) }
+	sameAge := (a.Age == nil) == (b.Age == nil) && (a.Age == nil || *a.Age == *b.Age)
+	return same(a.Name, b.Name) && same(a.Gender, b.Gender) && same(a.Email, b.Email) && sameAge
+}
+
+// fakePatchRepository serves one user and records the fields written.
+type fakePatchRepository struct {
+	repository.UserProvider
+	user    *models.User
+	changes *models.UserChanges
+}
+
+func (r *fakePatchRepository) GetByID(ctx context.Context, id uuid.UUID, withDeleted bool) (*models.User, error) {
+	user := *r.user
+	return &user, nil
+}
+
+func (r *fakePatchRepository) UpdateFields(ctx context.Context, id uuid.UUID, changes *models.UserChanges) (*models.User, error) {
+	r.changes = changes
+	user := *r.user
+	user.Name, user.Version = *changes.Name, user.Version+1
+	return &user, nil
+}
+
+func TestPatchUser(t *testing.T) {
+	repo := &fakePatchRepository{user: testPatchUser()}
+	uc := newTestUserUC(repo)
+	merge := func(doc string, version int64) *models.UserPatch {
+		return &models.UserPatch{Type: models.MergePatch, Document: []byte(doc), Version: version}
+	}
+
+	if _, err := uc.PatchUser(context.Background(), repo.user.ID, merge(`{"name":"Bob"}`, 2)); err != apperr.ErrorPreconditionFailed {
+		t.Fatalf("stale If-Match: error = %v, want %v", err, apperr.ErrorPreconditionFailed)
+	}
+
+	user, err := uc.PatchUser(context.Background(), repo.user.ID, merge(`{"name":"Ann"}`, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.changes != nil || user.Version != 3 {
+		t.Fatalf("no-op patch wrote %+v and returned version %d, want nothing written", repo.changes, user.Version)
+	}
+
+	user, err = uc.PatchUser(context.Background(), repo.user.ID, merge(`{"name":"Bob"}`, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.changes.Version != 3 || user.Name != "Bob" || user.Version != 4 {
+		t.Fatalf("wrote %+v and returned %+v, want the name written at the read version", repo.changes, user)
+	}
+}
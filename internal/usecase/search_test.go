@@ -0,0 +1,41 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/repository"
+)
+
+// fakeSearchRepository records the query the usecase builds.
+type fakeSearchRepository struct {
+	repository.UserProvider
+	query *models.UserSearchQuery
+}
+
+func (r *fakeSearchRepository) SearchUsers(ctx context.Context, query *models.UserSearchQuery) ([]models.UserSearchResult, error) {
+	r.query = query
+	return []models.UserSearchResult{}, nil
+}
+
+func TestSearchUsersTrimsTextAndClampsLimit(t *testing.T) {
+	tests := []struct {
+		dto  models.SearchUsersDTO
+		want models.UserSearchQuery
+	}{
+		{dto: models.SearchUsersDTO{Query: "  ann smith \t"}, want: models.UserSearchQuery{Text: "ann smith", Limit: 2}},
+		{dto: models.SearchUsersDTO{Query: "ann", Limit: 1}, want: models.UserSearchQuery{Text: "ann", Limit: 1}},
+		{dto: models.SearchUsersDTO{Query: "ann", Limit: 50}, want: models.UserSearchQuery{Text: "ann", Limit: 3}},
+	}
+
+	for _, tt := range tests {
+		repo := &fakeSearchRepository{}
+		if _, err := newTestUserUC(repo).SearchUsers(context.Background(), &tt.dto); err != nil {
+			t.Fatal(err)
+		}
+		if *repo.query != tt.want {
+			t.Errorf("SearchUsers(%+v) queried %+v, want %+v", tt.dto, *repo.query, tt.want)
+		}
+	}
+}
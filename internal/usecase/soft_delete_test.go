@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/krackl1n/golang-project/config"
+	"github.com/krackl1n/golang-project/internal/apperr"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/policy"
+	"github.com/krackl1n/golang-project/internal/repository"
+	"github.com/krackl1n/golang-project/internal/requestctx"
+	"github.com/pkg/errors"
+)
+
+// fakePurgeRepository records the cutoff it was asked to purge before.
+type fakePurgeRepository struct {
+	repository.UserProvider
+	deletedBefore time.Time
+}
+
+func (r *fakePurgeRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	r.deletedBefore = deletedBefore
+	return 2, nil
+}
+
+func TestPurgeDeletedUsersKeepsTheRetentionPeriod(t *testing.T) {
+	repo := &fakePurgeRepository{}
+	cfg := &config.Config{ConfigSoftDelete: config.ConfigSoftDelete{Retention: 24 * time.Hour}}
+	uc := New(repo, cfg, policy.Default())
+
+	purged, err := uc.PurgeDeletedUsers(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 2 {
+		t.Fatalf("purged = %d, want 2", purged)
+	}
+	if cutoff := time.Since(repo.deletedBefore); cutoff < 24*time.Hour || cutoff > 24*time.Hour+time.Minute {
+		t.Fatalf("purged users deleted before %s ago, want the 24h retention", cutoff)
+	}
+}
+
+func TestPurgeDeletedUsersNeedsDeletePermission(t *testing.T) {
+	repo := &fakePurgeRepository{}
+	ctx := requestctx.WithPrincipal(context.Background(), &models.Principal{Subject: "support", Roles: []string{models.RoleSupport}})
+
+	var forbiddenErr *apperr.ForbiddenError
+	if _, err := newTestUserUC(repo).PurgeDeletedUsers(ctx); !errors.As(err, &forbiddenErr) {
+		t.Fatalf("error = %v, want forbidden", err)
+	}
+	if !repo.deletedBefore.IsZero() {
+		t.Fatal("repository purged without permission")
+	}
+}
@@ -0,0 +1,121 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedUserUC puts a span around every call to a UserProvider, the way
+// cache.CacheDecorator wraps the repository.
+type tracedUserUC struct {
+	next UserProvider
+}
+
+// WithTracing returns uc with a span per call, named after the method.
+func WithTracing(uc UserProvider) UserProvider {
+	return &tracedUserUC{next: uc}
+}
+
+func startSpan(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "usecase."+method, trace.WithAttributes(attrs...))
+}
+
+func userIDAttr(id uuid.UUID) attribute.KeyValue {
+	return attribute.String("user.id", id.String())
+}
+
+func (uc *tracedUserUC) CreateUser(ctx context.Context, createUserDTO *models.CreateUserDTO) (id uuid.UUID, err error) {
+	ctx, span := startSpan(ctx, "CreateUser")
+	defer func() {
+		span.SetAttributes(userIDAttr(id))
+		tracing.End(span, err)
+	}()
+	return uc.next.CreateUser(ctx, createUserDTO)
+}
+
+func (uc *tracedUserUC) CreateUsers(ctx context.Context, createUserDTOs []models.CreateUserDTO, mode models.BatchMode) (results []models.BatchItemResult, err error) {
+	ctx, span := startSpan(ctx, "CreateUsers", attribute.Int("batch.size", len(createUserDTOs)), attribute.String("batch.mode", string(mode)))
+	defer func() { tracing.End(span, err) }()
+	return uc.next.CreateUsers(ctx, createUserDTOs, mode)
+}
+
+func (uc *tracedUserUC) GetUserById(ctx context.Context, id uuid.UUID, withDeleted bool) (user *models.User, err error) {
+	ctx, span := startSpan(ctx, "GetUserById", userIDAttr(id))
+	defer func() { tracing.End(span, err) }()
+	return uc.next.GetUserById(ctx, id, withDeleted)
+}
+
+func (uc *tracedUserUC) GetUserAsOf(ctx context.Context, id uuid.UUID, at time.Time, withDeleted bool) (user *models.User, err error) {
+	ctx, span := startSpan(ctx, "GetUserAsOf", userIDAttr(id))
+	defer func() { tracing.End(span, err) }()
+	return uc.next.GetUserAsOf(ctx, id, at, withDeleted)
+}
+
+func (uc *tracedUserUC) UserHistory(ctx context.Context, id uuid.UUID, userHistoryDTO *models.UserHistoryDTO) (page *models.UserHistoryPage, err error) {
+	ctx, span := startSpan(ctx, "UserHistory", userIDAttr(id))
+	defer func() { tracing.End(span, err) }()
+	return uc.next.UserHistory(ctx, id, userHistoryDTO)
+}
+
+func (uc *tracedUserUC) ListUsers(ctx context.Context, criteria *models.UserCriteria) (page *models.UserPage, err error) {
+	ctx, span := startSpan(ctx, "ListUsers")
+	defer func() { tracing.End(span, err) }()
+	return uc.next.ListUsers(ctx, criteria)
+}
+
+func (uc *tracedUserUC) SearchUsers(ctx context.Context, searchUsersDTO *models.SearchUsersDTO) (results []models.UserSearchResult, err error) {
+	ctx, span := startSpan(ctx, "SearchUsers")
+	defer func() { tracing.End(span, err) }()
+	return uc.next.SearchUsers(ctx, searchUsersDTO)
+}
+
+func (uc *tracedUserUC) AuthorizeExport(ctx context.Context) (err error) {
+	ctx, span := startSpan(ctx, "AuthorizeExport")
+	defer func() { tracing.End(span, err) }()
+	return uc.next.AuthorizeExport(ctx)
+}
+
+func (uc *tracedUserUC) ExportUsers(ctx context.Context, filter *models.UserFilter, fn func(user *models.User) error) (err error) {
+	ctx, span := startSpan(ctx, "ExportUsers")
+	defer func() { tracing.End(span, err) }()
+	return uc.next.ExportUsers(ctx, filter, fn)
+}
+
+func (uc *tracedUserUC) UpdateUser(ctx context.Context, user *models.User) (err error) {
+	ctx, span := startSpan(ctx, "UpdateUser", userIDAttr(user.ID))
+	defer func() { tracing.End(span, err) }()
+	return uc.next.UpdateUser(ctx, user)
+}
+
+func (uc *tracedUserUC) PatchUser(ctx context.Context, id uuid.UUID, patch *models.UserPatch) (user *models.User, err error) {
+	ctx, span := startSpan(ctx, "PatchUser", userIDAttr(id))
+	defer func() { tracing.End(span, err) }()
+	return uc.next.PatchUser(ctx, id, patch)
+}
+
+func (uc *tracedUserUC) DeleteUser(ctx context.Context, id uuid.UUID, version int64) (err error) {
+	ctx, span := startSpan(ctx, "DeleteUser", userIDAttr(id))
+	defer func() { tracing.End(span, err) }()
+	return uc.next.DeleteUser(ctx, id, version)
+}
+
+func (uc *tracedUserUC) RestoreUser(ctx context.Context, id uuid.UUID) (user *models.User, err error) {
+	ctx, span := startSpan(ctx, "RestoreUser", userIDAttr(id))
+	defer func() { tracing.End(span, err) }()
+	return uc.next.RestoreUser(ctx, id)
+}
+
+func (uc *tracedUserUC) PurgeDeletedUsers(ctx context.Context) (purged int64, err error) {
+	ctx, span := startSpan(ctx, "PurgeDeletedUsers")
+	defer func() {
+		span.SetAttributes(attribute.Int64("users.purged", purged))
+		tracing.End(span, err)
+	}()
+	return uc.next.PurgeDeletedUsers(ctx)
+}
@@ -2,46 +2,122 @@ package usecase
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/config"
+	"github.com/krackl1n/golang-project/internal/apperr"
 	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/policy"
 	"github.com/krackl1n/golang-project/internal/repository"
+	"github.com/krackl1n/golang-project/internal/requestctx"
+	"github.com/krackl1n/golang-project/internal/validation"
 	"github.com/pkg/errors"
 )
 
 type userUC struct {
 	userRepository repository.UserProvider
+	pagination     config.ConfigPagination
+	softDelete     config.ConfigSoftDelete
+	batch          config.ConfigBatch
+	policy         *policy.Policy
 }
 
-func New(userRepository repository.UserProvider) UserProvider {
+// New returns the user usecase. Every operation is first authorized by
+// accessPolicy for the principal of the context.
+func New(userRepository repository.UserProvider, cfg *config.Config, accessPolicy *policy.Policy) UserProvider {
 	return &userUC{
 		userRepository: userRepository,
+		pagination:     cfg.ConfigPagination,
+		softDelete:     cfg.ConfigSoftDelete,
+		batch:          cfg.ConfigBatch,
+		policy:         accessPolicy,
 	}
 }
 
 func (uc *userUC) CreateUser(ctx context.Context, createUserDTO *models.CreateUserDTO) (uuid.UUID, error) {
-	userId, err := uuid.NewV7()
-	if err != nil {
-		return userId, errors.Wrap(err, "generate UUID")
+	if err := uc.authorize(ctx, policy.ActionCreate, uuid.Nil); err != nil {
+		return uuid.Nil, err
 	}
 
-	user := &models.User{
-		ID:     userId,
-		Name:   createUserDTO.Name,
-		Age:    createUserDTO.Age,
-		Gender: createUserDTO.Gender,
-		Email:  createUserDTO.Email,
+	user, err := newUser(createUserDTO)
+	if err != nil {
+		return uuid.Nil, err
 	}
 
 	if _, err := uc.userRepository.Create(ctx, user); err != nil {
 		return uuid.Nil, err
 	}
 
-	return userId, nil
+	return user.ID, nil
 }
 
-func (uc *userUC) GetUserById(ctx context.Context, id uuid.UUID) (*models.User, error) {
-	user, err := uc.userRepository.GetByID(ctx, id)
+// CreateUsers validates every item and inserts the valid ones in one batch.
+// In atomic mode a single invalid or rejected item aborts the whole batch.
+func (uc *userUC) CreateUsers(ctx context.Context, createUserDTOs []models.CreateUserDTO, mode models.BatchMode) ([]models.BatchItemResult, error) {
+	if err := uc.authorize(ctx, policy.ActionCreate, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	if len(createUserDTOs) == 0 {
+		return nil, &apperr.ValidationError{Field: "users", Reason: "batch is empty"}
+	}
+	if len(createUserDTOs) > uc.batch.MaxSize {
+		return nil, &apperr.ValidationError{Field: "users", Reason: fmt.Sprintf("batch exceeds %d users", uc.batch.MaxSize)}
+	}
+
+	results := make([]models.BatchItemResult, len(createUserDTOs))
+	users := make([]models.User, 0, len(createUserDTOs))
+	indexes := make([]int, 0, len(createUserDTOs))
+	for i := range createUserDTOs {
+		results[i].Index = i
+		if err := validation.Struct(createUserDTOs[i]); err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		user, err := newUser(&createUserDTOs[i])
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+		indexes = append(indexes, i)
+	}
+
+	if mode == models.BatchAtomic && len(users) < len(createUserDTOs) {
+		for _, i := range indexes {
+			results[i].Err = apperr.ErrorBatchAborted
+		}
+		return results, nil
+	}
+	if len(users) == 0 {
+		return results, nil
+	}
+
+	errs, err := uc.userRepository.CreateBatch(ctx, users, mode == models.BatchAtomic)
+	if err != nil {
+		return nil, errors.Wrap(err, "create users batch")
+	}
+
+	for k, i := range indexes {
+		if errs[k] != nil {
+			results[i].Err = errs[k]
+			continue
+		}
+		results[i].User = &users[k]
+	}
+
+	return results, nil
+}
+
+func (uc *userUC) GetUserById(ctx context.Context, id uuid.UUID, withDeleted bool) (*models.User, error) {
+	if err := uc.authorize(ctx, policy.ActionRead, id); err != nil {
+		return nil, err
+	}
+
+	user, err := uc.userRepository.GetByID(ctx, id, withDeleted)
 	if err != nil {
 		return nil, errors.Wrap(err, "userUC get by Id")
 	}
@@ -49,7 +125,142 @@ func (uc *userUC) GetUserById(ctx context.Context, id uuid.UUID) (*models.User,
 	return user, nil
 }
 
+// GetUserAsOf returns the user as it was at the given moment. Like
+// GetUserById it hides a user that was deleted by then unless withDeleted.
+func (uc *userUC) GetUserAsOf(ctx context.Context, id uuid.UUID, at time.Time, withDeleted bool) (*models.User, error) {
+	if err := uc.authorize(ctx, policy.ActionRead, id); err != nil {
+		return nil, err
+	}
+
+	user, err := uc.userRepository.GetAsOf(ctx, id, at)
+	if err != nil {
+		return nil, errors.Wrap(err, "userUC get as of")
+	}
+	if user.DeletedAt != nil && !withDeleted {
+		return nil, apperr.ErrorNotFound
+	}
+
+	return user, nil
+}
+
+// UserHistory pages through the recorded writes of a user, newest first.
+func (uc *userUC) UserHistory(ctx context.Context, id uuid.UUID, userHistoryDTO *models.UserHistoryDTO) (*models.UserHistoryPage, error) {
+	if err := uc.authorize(ctx, policy.ActionRead, id); err != nil {
+		return nil, err
+	}
+
+	limit := uc.pageSize(userHistoryDTO.Limit)
+
+	var before int64
+	if userHistoryDTO.Cursor != "" {
+		var err error
+		if before, err = decodeSeqCursor(userHistoryDTO.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	entries, err := uc.userRepository.History(ctx, id, before, limit+1)
+	if err != nil {
+		return nil, errors.Wrap(err, "user history")
+	}
+	// Every user has at least its creation or snapshot entry.
+	if len(entries) == 0 && before == 0 {
+		return nil, apperr.ErrorNotFound
+	}
+
+	page := &models.UserHistoryPage{
+		Entries: entries,
+	}
+	if len(entries) > limit {
+		page.Entries = entries[:limit]
+		page.NextCursor = encodeSeqCursor(page.Entries[limit-1].ID)
+	}
+
+	return page, nil
+}
+
+func (uc *userUC) ListUsers(ctx context.Context, criteria *models.UserCriteria) (*models.UserPage, error) {
+	if err := uc.authorize(ctx, policy.ActionRead, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	limit := uc.pageSize(criteria.Limit)
+
+	sort := criteria.UserSort
+	if sort.Field == "" {
+		sort.Field = models.UserSortByID
+	}
+
+	filter := &models.UserListFilter{
+		UserFilter: criteria.UserFilter,
+		UserSort:   sort,
+		Limit:      limit + 1,
+	}
+	if criteria.Cursor != "" {
+		cur, err := decodeCursor(criteria.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if filter.After, err = cur.position(sort); err != nil {
+			return nil, err
+		}
+	}
+
+	users, err := uc.userRepository.List(ctx, filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "list users")
+	}
+
+	page := &models.UserPage{
+		Users: users,
+	}
+	if len(users) > limit {
+		page.Users = users[:limit]
+		page.NextCursor = encodeCursor(newCursor(&page.Users[limit-1], sort))
+	}
+
+	return page, nil
+}
+
+func (uc *userUC) SearchUsers(ctx context.Context, searchUsersDTO *models.SearchUsersDTO) ([]models.UserSearchResult, error) {
+	if err := uc.authorize(ctx, policy.ActionRead, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	results, err := uc.userRepository.SearchUsers(ctx, &models.UserSearchQuery{
+		Text:  strings.TrimSpace(searchUsersDTO.Query),
+		Limit: uc.pageSize(searchUsersDTO.Limit),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "search users")
+	}
+
+	return results, nil
+}
+
+// AuthorizeExport lets a caller that streams the export report a denial
+// before the response starts. ExportUsers checks the same again.
+func (uc *userUC) AuthorizeExport(ctx context.Context) error {
+	return uc.authorize(ctx, policy.ActionRead, uuid.Nil)
+}
+
+func (uc *userUC) ExportUsers(ctx context.Context, filter *models.UserFilter, fn func(user *models.User) error) error {
+	if err := uc.authorize(ctx, policy.ActionRead, uuid.Nil); err != nil {
+		return err
+	}
+
+	if err := uc.userRepository.Export(ctx, filter, fn); err != nil {
+		return errors.Wrap(err, "export users")
+	}
+
+	return nil
+}
+
 func (uc *userUC) UpdateUser(ctx context.Context, user *models.User) error {
+	if err := uc.authorize(ctx, policy.ActionUpdate, user.ID); err != nil {
+		return err
+	}
+
 	if err := uc.userRepository.Update(ctx, user); err != nil {
 		return errors.Wrap(err, "update user")
 	}
@@ -57,10 +268,110 @@ func (uc *userUC) UpdateUser(ctx context.Context, user *models.User) error {
 	return nil
 }
 
-func (uc *userUC) DeleteUser(ctx context.Context, id uuid.UUID) error {
-	if err := uc.userRepository.Delete(ctx, id); err != nil {
+func (uc *userUC) PatchUser(ctx context.Context, id uuid.UUID, patch *models.UserPatch) (*models.User, error) {
+	if err := uc.authorize(ctx, policy.ActionUpdate, id); err != nil {
+		return nil, err
+	}
+
+	current, err := uc.userRepository.GetByID(ctx, id, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "get user")
+	}
+	if patch.Version != 0 && patch.Version != current.Version {
+		return nil, apperr.ErrorPreconditionFailed
+	}
+
+	patched, err := applyPatch(current, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := diffUsers(current, patched)
+	if changes == nil {
+		return current, nil
+	}
+	// Guard against writes that landed between the read and the update.
+	changes.Version = current.Version
+
+	user, err := uc.userRepository.UpdateFields(ctx, id, changes)
+	if err != nil {
+		return nil, errors.Wrap(err, "patch user")
+	}
+
+	return user, nil
+}
+
+func (uc *userUC) DeleteUser(ctx context.Context, id uuid.UUID, version int64) error {
+	if err := uc.authorize(ctx, policy.ActionDelete, id); err != nil {
+		return err
+	}
+
+	if err := uc.userRepository.Delete(ctx, id, version); err != nil {
 		return errors.Wrap(err, "delete user")
 	}
 
 	return nil
 }
+
+func (uc *userUC) RestoreUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	if err := uc.authorize(ctx, policy.ActionDelete, id); err != nil {
+		return nil, err
+	}
+
+	user, err := uc.userRepository.Restore(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "restore user")
+	}
+
+	return user, nil
+}
+
+func (uc *userUC) PurgeDeletedUsers(ctx context.Context) (int64, error) {
+	if err := uc.authorize(ctx, policy.ActionDelete, uuid.Nil); err != nil {
+		return 0, err
+	}
+
+	purged, err := uc.userRepository.Purge(ctx, time.Now().Add(-uc.softDelete.Retention))
+	if err != nil {
+		return 0, errors.Wrap(err, "purge deleted users")
+	}
+
+	return purged, nil
+}
+
+// authorize checks action against the policy for the principal of ctx.
+// owner is the user the action targets, or uuid.Nil.
+func (uc *userUC) authorize(ctx context.Context, action policy.Action, owner uuid.UUID) error {
+	return uc.policy.Authorize(requestctx.Principal(ctx), action, owner)
+}
+
+func (uc *userUC) pageSize(limit int) int {
+	return pageSize(uc.pagination, limit)
+}
+
+// pageSize clamps a requested page size to the configured bounds; zero or
+// less asks for the default.
+func pageSize(pagination config.ConfigPagination, limit int) int {
+	if limit <= 0 {
+		return pagination.DefaultPageSize
+	}
+	if limit > pagination.MaxPageSize {
+		return pagination.MaxPageSize
+	}
+	return limit
+}
+
+func newUser(createUserDTO *models.CreateUserDTO) (*models.User, error) {
+	userId, err := uuid.NewV7()
+	if err != nil {
+		return nil, errors.Wrap(err, "generate UUID")
+	}
+
+	return &models.User{
+		ID:     userId,
+		Name:   createUserDTO.Name,
+		Age:    createUserDTO.Age,
+		Gender: createUserDTO.Gender,
+		Email:  createUserDTO.Email,
+	}, nil
+}
@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/config"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/repository"
+	"github.com/pkg/errors"
+)
+
+type webhookUC struct {
+	webhookRepository repository.WebhookProvider
+	pagination        config.ConfigPagination
+}
+
+func NewWebhook(webhookRepository repository.WebhookProvider, cfg *config.Config) WebhookProvider {
+	return &webhookUC{
+		webhookRepository: webhookRepository,
+		pagination:        cfg.ConfigPagination,
+	}
+}
+
+// CreateWebhook stores a subscription. Without a client secret a random
+// one is generated; either way it is returned only here.
+func (uc *webhookUC) CreateWebhook(ctx context.Context, createWebhookDTO *models.CreateWebhookDTO) (*models.WebhookSubscription, error) {
+	subscriptionId, err := uuid.NewV7()
+	if err != nil {
+		return nil, errors.Wrap(err, "generate UUID")
+	}
+
+	secret := createWebhookDTO.Secret
+	if secret == "" {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, errors.Wrap(err, "generate secret")
+		}
+		secret = hex.EncodeToString(key)
+	}
+
+	eventTypes := make([]models.EventType, 0, len(createWebhookDTO.EventTypes))
+	for _, eventType := range createWebhookDTO.EventTypes {
+		eventTypes = append(eventTypes, models.EventType(eventType))
+	}
+
+	subscription := &models.WebhookSubscription{
+		ID:         subscriptionId,
+		URL:        createWebhookDTO.URL,
+		EventTypes: eventTypes,
+		Secret:     secret,
+	}
+	if err := uc.webhookRepository.CreateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+func (uc *webhookUC) ListWebhooks(ctx context.Context) ([]models.WebhookSubscription, error) {
+	subscriptions, err := uc.webhookRepository.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "list webhooks")
+	}
+
+	return subscriptions, nil
+}
+
+func (uc *webhookUC) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	if err := uc.webhookRepository.DeleteSubscription(ctx, id); err != nil {
+		return errors.Wrap(err, "delete webhook")
+	}
+
+	return nil
+}
+
+// ListDeliveries pages through the delivery log of a subscription, newest
+// first.
+func (uc *webhookUC) ListDeliveries(ctx context.Context, subscriptionId uuid.UUID, deliveriesDTO *models.WebhookDeliveriesDTO) (*models.WebhookDeliveryPage, error) {
+	limit := pageSize(uc.pagination, deliveriesDTO.Limit)
+
+	filter := &models.WebhookDeliveryFilter{
+		SubscriptionID: subscriptionId,
+		Status:         models.WebhookDeliveryStatus(deliveriesDTO.Status),
+		Limit:          limit + 1,
+	}
+	if deliveriesDTO.Cursor != "" {
+		var err error
+		if filter.Before, err = decodeSeqCursor(deliveriesDTO.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	deliveries, err := uc.webhookRepository.ListDeliveries(ctx, filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "list webhook deliveries")
+	}
+
+	page := &models.WebhookDeliveryPage{
+		Deliveries: deliveries,
+	}
+	if len(deliveries) > limit {
+		page.Deliveries = deliveries[:limit]
+		page.NextCursor = encodeSeqCursor(page.Deliveries[limit-1].ID)
+	}
+
+	return page, nil
+}
+
+func (uc *webhookUC) GetDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, error) {
+	delivery, err := uc.webhookRepository.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "get webhook delivery")
+	}
+
+	return delivery, nil
+}
+
+func (uc *webhookUC) ReplayDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, error) {
+	delivery, err := uc.webhookRepository.ReplayDelivery(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "replay webhook delivery")
+	}
+
+	return delivery, nil
+}
@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/krackl1n/golang-project/config"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/repository"
+)
+
+// fakeWebhookRepository keeps created subscriptions and a delivery log
+// newest first.
+type fakeWebhookRepository struct {
+	repository.WebhookProvider
+	subscriptions []models.WebhookSubscription
+	deliveries    []models.WebhookDelivery
+}
+
+func (r *fakeWebhookRepository) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	r.subscriptions = append(r.subscriptions, *subscription)
+	return nil
+}
+
+func (r *fakeWebhookRepository) ListDeliveries(ctx context.Context, filter *models.WebhookDeliveryFilter) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if filter.Before == 0 || delivery.ID < filter.Before {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries[:min(filter.Limit, len(deliveries))], nil
+}
+
+func newTestWebhookUC(webhooks repository.WebhookProvider) WebhookProvider {
+	cfg := &config.Config{ConfigPagination: config.ConfigPagination{DefaultPageSize: 2, MaxPageSize: 3}}
+	return NewWebhook(webhooks, cfg)
+}
+
+func TestCreateWebhookSecret(t *testing.T) {
+	repo := &fakeWebhookRepository{}
+	uc := newTestWebhookUC(repo)
+
+	given, err := uc.CreateWebhook(context.Background(), &models.CreateWebhookDTO{URL: "https://example.com/hook", Secret: "a secret of my own"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := uc.CreateWebhook(context.Background(), &models.CreateWebhookDTO{URL: "https://example.com/hook", EventTypes: []string{"UserCreated"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := uc.CreateWebhook(context.Background(), &models.CreateWebhookDTO{URL: "https://example.com/hook"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if given.Secret != "a secret of my own" {
+		t.Errorf("secret = %q, want the one given", given.Secret)
+	}
+	if len(first.Secret) != 64 || first.Secret == second.Secret {
+		t.Errorf("generated secrets %q and %q, want distinct 32 byte hex strings", first.Secret, second.Secret)
+	}
+	if len(first.EventTypes) != 1 || first.EventTypes[0] != models.UserCreatedEvent {
+		t.Errorf("event types = %v, want UserCreated", first.EventTypes)
+	}
+	if len(repo.subscriptions) != 3 || repo.subscriptions[1].Secret != first.Secret {
+		t.Errorf("stored %+v, want every subscription with its secret", repo.subscriptions)
+	}
+}
+
+func TestListDeliveriesPages(t *testing.T) {
+	repo := &fakeWebhookRepository{}
+	for id := int64(3); id > 0; id-- {
+		repo.deliveries = append(repo.deliveries, models.WebhookDelivery{ID: id})
+	}
+	uc := newTestWebhookUC(repo)
+
+	page, err := uc.ListDeliveries(context.Background(), uuid.New(), &models.WebhookDeliveriesDTO{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Deliveries) != 2 || page.NextCursor == "" {
+		t.Fatalf("first page = %+v, want 2 deliveries and a cursor", page)
+	}
+
+	page, err = uc.ListDeliveries(context.Background(), uuid.New(), &models.WebhookDeliveriesDTO{Cursor: page.NextCursor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Deliveries) != 1 || page.Deliveries[0].ID != 1 || page.NextCursor != "" {
+		t.Fatalf("last page = %+v, want delivery 1 alone", page)
+	}
+}
@@ -0,0 +1,67 @@
+package validation
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	ru_translations "github.com/go-playground/validator/v10/translations/ru"
+	"github.com/krackl1n/golang-project/internal/i18n"
+	"github.com/pkg/errors"
+)
+
+var validate = newValidator()
+
+// Struct validates s with the shared validator. Its errors can be
+// translated with any i18n translator.
+func Struct(s any) error {
+	return validate.Struct(s)
+}
+
+// newValidator reports fields by the names clients use: the json tag, or
+// the query tag for query-string DTOs.
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(fieldName)
+
+	if err := en_translations.RegisterDefaultTranslations(v, i18n.Translator("en")); err != nil {
+		panic(errors.Wrap(err, "register en translations"))
+	}
+	if err := ru_translations.RegisterDefaultTranslations(v, i18n.Translator("ru")); err != nil {
+		panic(errors.Wrap(err, "register ru translations"))
+	}
+
+	return v
+}
+
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "query"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+
+	return field.Name
+}
+
+// Describe renders err as one English line, e.g. for reports that are
+// produced outside of a request and have no client language.
+func Describe(err error) string {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err.Error()
+	}
+
+	trans := i18n.Translator("en")
+	messages := make([]string, 0, len(validationErrors))
+	for _, fieldError := range validationErrors {
+		messages = append(messages, fieldError.Translate(trans))
+	}
+
+	return strings.Join(messages, "; ")
+}
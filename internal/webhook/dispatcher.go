@@ -0,0 +1,124 @@
+package webhook
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/krackl1n/golang-project/config"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/repository"
+)
+
+// Dispatcher sends due webhook deliveries in the background.
+type Dispatcher struct {
+	webhooks repository.WebhookProvider
+	sender   *Sender
+	cfg      config.ConfigWebhook
+}
+
+func NewDispatcher(webhooks repository.WebhookProvider, sender *Sender, cfg config.ConfigWebhook) *Dispatcher {
+	return &Dispatcher{
+		webhooks: webhooks,
+		sender:   sender,
+		cfg:      cfg,
+	}
+}
+
+// Start polls for due deliveries until the returned function is called.
+func (d *Dispatcher) Start() func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-timer.C:
+				handled, err := d.dispatch(ctx)
+				if err != nil && ctx.Err() == nil {
+					slog.Error("dispatch webhook deliveries", slog.Any("error", err))
+				}
+				if err == nil && handled == d.cfg.BatchSize {
+					timer.Reset(0)
+					continue
+				}
+				timer.Reset(d.cfg.PollInterval)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// dispatch sends one batch of due deliveries and returns how many were
+// claimed. The batch is leased rather than locked and its deliveries go
+// out concurrently, so a slow receiver holds up neither a transaction nor
+// the other receivers. A failed delivery is retried after the backoff of
+// retryAfter, or marked dead when retryAfter gives up.
+func (d *Dispatcher) dispatch(ctx context.Context) (int, error) {
+	deliveries, err := d.webhooks.ClaimDeliveries(ctx, d.cfg.BatchSize, d.cfg.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	leaseCtx, cancel := context.WithTimeout(ctx, d.cfg.Lease)
+	defer cancel()
+
+	attempts := make([]models.WebhookAttempt, len(deliveries))
+	var wg sync.WaitGroup
+	for i := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			attempts[i] = d.sender.Send(leaseCtx, &deliveries[i])
+		}()
+	}
+	wg.Wait()
+
+	for i := range deliveries {
+		delivery, attempt := &deliveries[i], attempts[i]
+
+		status, retryIn := models.WebhookDelivered, time.Duration(0)
+		if attempt.Err != nil {
+			var retry bool
+			retryIn, retry = d.retryAfter(delivery.Attempts + 1)
+			status = models.WebhookPending
+			if !retry {
+				status = models.WebhookDead
+			}
+			slog.WarnContext(ctx, "webhook delivery failed", slog.Int64("id", delivery.ID), slog.Int("attempt", delivery.Attempts+1),
+				slog.String("status", string(status)), slog.Any("error", attempt.Err))
+		}
+
+		if err := d.webhooks.RecordAttempt(ctx, delivery.ID, attempt, status, retryIn); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+// retryAfter backs off exponentially from RetryBase up to RetryMax and
+// gives up after MaxAttempts, which sends the delivery to the dead letters.
+func (d *Dispatcher) retryAfter(attempts int) (time.Duration, bool) {
+	if attempts >= d.cfg.MaxAttempts {
+		return 0, false
+	}
+
+	delay := d.cfg.RetryBase
+	for i := 1; i < attempts && delay < d.cfg.RetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, d.cfg.RetryMax), true
+}
@@ -0,0 +1,155 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/krackl1n/golang-project/config"
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/repository"
+)
+
+const testSecret = "whsec_test"
+
+type recordedAttempt struct {
+	attempt models.WebhookAttempt
+	status  models.WebhookDeliveryStatus
+	retryIn time.Duration
+}
+
+// fakeWebhooks hands out its deliveries once and keeps the recorded
+// attempts by delivery id.
+type fakeWebhooks struct {
+	repository.WebhookProvider
+	mu         sync.Mutex
+	deliveries []models.WebhookDelivery
+	recorded   map[int64]recordedAttempt
+}
+
+func (w *fakeWebhooks) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	claimed := w.deliveries[:min(limit, len(w.deliveries))]
+	w.deliveries = w.deliveries[len(claimed):]
+	return claimed, nil
+}
+
+func (w *fakeWebhooks) RecordAttempt(ctx context.Context, id int64, attempt models.WebhookAttempt, status models.WebhookDeliveryStatus, retryIn time.Duration) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.recorded[id] = recordedAttempt{attempt: attempt, status: status, retryIn: retryIn}
+	return nil
+}
+
+func testDispatcherConfig() config.ConfigWebhook {
+	return config.ConfigWebhook{BatchSize: 10, Lease: time.Minute, MaxAttempts: 3, RetryBase: time.Second, RetryMax: time.Minute}
+}
+
+// receiver answers with the status named in the path, after checking the
+// signature the way a subscriber would.
+func newReceiver(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := Verify(testSecret, r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderSignature), body, time.Minute); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if !json.Valid(body) || r.Header.Get(HeaderID) == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		status, _ := strconv.Atoi(r.URL.Path[1:])
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDispatcherDispatch(t *testing.T) {
+	receiver := newReceiver(t)
+	delivery := func(id int64, status string, attempts int, secret string) models.WebhookDelivery {
+		return models.WebhookDelivery{
+			ID: id, EventType: models.UserCreatedEvent, Payload: json.RawMessage(`{"type":"UserCreated"}`),
+			Attempts: attempts, URL: receiver.URL + "/" + status, Secret: secret,
+		}
+	}
+	webhooks := &fakeWebhooks{
+		deliveries: []models.WebhookDelivery{
+			delivery(1, "204", 0, testSecret),
+			delivery(2, "500", 1, testSecret),
+			delivery(3, "500", 2, testSecret),
+			delivery(4, "204", 0, "wrong secret"),
+		},
+		recorded: map[int64]recordedAttempt{},
+	}
+
+	dispatcher := NewDispatcher(webhooks, NewSender(receiver.Client()), testDispatcherConfig())
+	handled, err := dispatcher.dispatch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if handled != 4 {
+		t.Fatalf("handled = %d, want 4", handled)
+	}
+
+	want := map[int64]struct {
+		status     models.WebhookDeliveryStatus
+		statusCode int
+		retryIn    time.Duration
+	}{
+		1: {status: models.WebhookDelivered, statusCode: http.StatusNoContent},
+		2: {status: models.WebhookPending, statusCode: http.StatusInternalServerError, retryIn: 2 * time.Second},
+		3: {status: models.WebhookDead, statusCode: http.StatusInternalServerError},
+		4: {status: models.WebhookPending, statusCode: http.StatusUnauthorized, retryIn: time.Second},
+	}
+	for id, w := range want {
+		got := webhooks.recorded[id]
+		if got.status != w.status || got.attempt.StatusCode != w.statusCode || got.retryIn != w.retryIn {
+			t.Errorf("delivery %d recorded as %+v, want %s with %d and retry in %s", id, got, w.status, w.statusCode, w.retryIn)
+		}
+	}
+}
+
+func TestDispatcherSlowReceiverHoldsUpNoOther(t *testing.T) {
+	receiver := newReceiver(t)
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	webhooks := &fakeWebhooks{
+		deliveries: []models.WebhookDelivery{
+			{ID: 1, Payload: json.RawMessage(`{}`), URL: slow.URL, Secret: testSecret},
+			{ID: 2, Payload: json.RawMessage(`{}`), URL: receiver.URL + "/200", Secret: testSecret},
+		},
+		recorded: map[int64]recordedAttempt{},
+	}
+	cfg := testDispatcherConfig()
+	cfg.Lease = 200 * time.Millisecond
+
+	start := time.Now()
+	if _, err := NewDispatcher(webhooks, NewSender(http.DefaultClient), cfg).dispatch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("dispatch took %s, want it cut off by the lease", elapsed)
+	}
+	if webhooks.recorded[1].status != models.WebhookPending || webhooks.recorded[1].attempt.Err == nil {
+		t.Errorf("slow delivery recorded as %+v, want a failed attempt", webhooks.recorded[1])
+	}
+	if webhooks.recorded[2].status != models.WebhookDelivered {
+		t.Errorf("fast delivery recorded as %+v, want delivered", webhooks.recorded[2])
+	}
+}
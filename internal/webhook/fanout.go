@@ -0,0 +1,31 @@
+package webhook
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/repository"
+)
+
+// FanoutPublisher is the outbox publisher of webhooks: it turns every
+// event into one pending delivery per matching subscription.
+type FanoutPublisher struct {
+	webhooks repository.WebhookProvider
+}
+
+func NewFanoutPublisher(webhooks repository.WebhookProvider) *FanoutPublisher {
+	return &FanoutPublisher{webhooks: webhooks}
+}
+
+func (p *FanoutPublisher) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	queued, err := p.webhooks.EnqueueDeliveries(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	if queued > 0 {
+		slog.DebugContext(ctx, "queued webhook deliveries", slog.String("event_id", event.ID.String()), slog.Int64("count", queued))
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/krackl1n/golang-project/internal/models"
+	"github.com/krackl1n/golang-project/internal/tracing"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Sender posts signed deliveries. The client is injected so deliveries can
+// be pointed at an httptest server.
+type Sender struct {
+	client *http.Client
+}
+
+func NewSender(client *http.Client) *Sender {
+	return &Sender{client: client}
+}
+
+// Send makes one delivery attempt. Only a 2xx response counts as delivered.
+// The attempt gets a client span whose trace context goes along in the
+// traceparent header.
+func (s *Sender) Send(ctx context.Context, delivery *models.WebhookDelivery) (attempt models.WebhookAttempt) {
+	ctx, span := tracing.Tracer().Start(ctx, "webhook "+string(delivery.EventType),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.HTTPRequestMethodPost, attribute.Int64("webhook.delivery.id", delivery.ID)),
+	)
+	defer func() {
+		if attempt.StatusCode != 0 {
+			span.SetAttributes(semconv.HTTPResponseStatusCode(attempt.StatusCode))
+		}
+		tracing.End(span, attempt.Err)
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return models.WebhookAttempt{Err: errors.Wrap(err, "build request")}
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	timestamp := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "golang-project-webhooks")
+	req.Header.Set(HeaderID, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return models.WebhookAttempt{Err: errors.Wrap(err, "post webhook")}
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	attempt = models.WebhookAttempt{StatusCode: resp.StatusCode}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Err = errors.New(fmt.Sprintf("unexpected status %d", resp.StatusCode))
+	}
+	return attempt
+}
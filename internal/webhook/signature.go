@@ -0,0 +1,62 @@
+// Package webhook delivers user events to subscribed HTTP endpoints.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	HeaderID        = "Webhook-Id"
+	HeaderTimestamp = "Webhook-Timestamp"
+	HeaderSignature = "Webhook-Signature"
+
+	signatureVersion = "v1"
+)
+
+var (
+	ErrorInvalidSignature = errors.New("invalid webhook signature")
+	ErrorStaleTimestamp   = errors.New("webhook timestamp outside tolerance")
+)
+
+// Sign returns the Webhook-Signature value for body sent at timestamp:
+// "v1=" and the hex HMAC-SHA256 of "<unix timestamp>.<body>" keyed with
+// the subscription secret.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signatureVersion + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the headers of a received webhook the way a receiver
+// should: the signature must match and the timestamp must be within
+// tolerance of now, which stops replays of captured requests.
+func Verify(secret, timestamp, signature string, body []byte, tolerance time.Duration) error {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrorInvalidSignature
+	}
+
+	sentAt := time.Unix(unix, 0)
+	if age := time.Since(sentAt); age > tolerance || age < -tolerance {
+		return ErrorStaleTimestamp
+	}
+
+	expected := Sign(secret, sentAt, body)
+	for _, candidate := range strings.Split(signature, ",") {
+		if hmac.Equal([]byte(strings.TrimSpace(candidate)), []byte(expected)) {
+			return nil
+		}
+	}
+
+	return ErrorInvalidSignature
+}
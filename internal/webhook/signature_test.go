@@ -0,0 +1,41 @@
+package webhook
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	now := time.Now()
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	signature := Sign("secret", now, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		signature string
+		body      []byte
+		want      error
+	}{
+		{name: "valid", secret: "secret", timestamp: timestamp, signature: signature, body: body},
+		{name: "one of several", secret: "secret", timestamp: timestamp, signature: "v1=00, " + signature, body: body},
+		{name: "other secret", secret: "rotated", timestamp: timestamp, signature: signature, body: body, want: ErrorInvalidSignature},
+		{name: "changed body", secret: "secret", timestamp: timestamp, signature: signature, body: []byte(`{"id":"2"}`), want: ErrorInvalidSignature},
+		{name: "stale", secret: "secret", timestamp: strconv.FormatInt(now.Add(-time.Hour).Unix(), 10), signature: signature, body: body, want: ErrorStaleTimestamp},
+		{name: "bad timestamp", secret: "secret", timestamp: "yesterday", signature: signature, body: body, want: ErrorInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.timestamp, tt.signature, tt.body, 5*time.Minute)
+			if !errors.Is(err, tt.want) && err != tt.want {
+				t.Fatalf("Verify() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}